@@ -1,10 +1,16 @@
 package main
 
 import (
-	"strings"
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/BeanCodeDe/authi/internal/app/authi/api"
-	"github.com/BeanCodeDe/authi/internal/app/authi/util"
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/BeanCodeDe/authi/internal/app/authi/tracing"
 	"github.com/BeanCodeDe/authi/pkg/parser"
 	log "github.com/sirupsen/logrus"
 )
@@ -22,31 +28,59 @@ ____________________________________O/_______
 `
 
 func main() {
-	setLogLevel()
+	if len(os.Args) > 1 && os.Args[1] == buildBreachFilterCommand {
+		if err := buildBreachFilter(os.Args[2:]); err != nil {
+			log.Fatalf("Error while building breach filter: %v", err)
+		}
+		return
+	}
+
+	config, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error while loading configuration: %v", err)
+	}
+	logging.Init(config.LogLevel, config.LogFormat)
 	if log.GetLevel() > log.WarnLevel {
 		println(banner)
 	}
 	log.Debug("Start Server")
-	tokenParser, err := parser.NewJWTParser()
+	shutdownTracing, err := tracing.Init(&config.Tracing)
+	if err != nil {
+		log.Fatalf("Error while initializing tracing: %v", err)
+	}
+	tokenParser, err := parser.NewJWTParserWithKeyPath(config.Token.PublicKeyPath)
 	if err != nil {
 		log.Fatalf("Error while initializing token parser: %v", err)
 	}
 
-	_, err = api.NewUserApi(tokenParser)
+	server, err := api.NewServer(config, tokenParser)
 	if err != nil {
-		log.Fatalf("Error while initializing user api: %v", err)
+		log.Fatalf("Error while initializing server: %v", err)
 	}
 
-}
+	signalContext, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-func setLogLevel() {
-	logLevel := util.GetEnvWithFallback("LOG_LEVEL", "info")
-	switch strings.ToLower(logLevel) {
-	case "debug":
-		log.SetLevel(log.DebugLevel)
-	case "info":
-		log.SetLevel(log.InfoLevel)
-	case "warn", "warning":
-		log.SetLevel(log.WarnLevel)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start()
+	}()
+
+	select {
+	case err = <-serverErr:
+	case <-signalContext.Done():
+		log.Info("Shutting down server")
+	}
+
+	shutdownContext, cancel := context.WithTimeout(context.Background(), time.Duration(config.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if shutdownErr := server.Shutdown(shutdownContext); shutdownErr != nil {
+		log.Errorf("Error while shutting down server: %v", shutdownErr)
+	}
+	if shutdownErr := shutdownTracing(shutdownContext); shutdownErr != nil {
+		log.Errorf("Error while shutting down tracing: %v", shutdownErr)
+	}
+	if err != nil {
+		log.Fatalf("Error while running server: %v", err)
 	}
 }
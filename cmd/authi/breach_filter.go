@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/breach"
+	log "github.com/sirupsen/logrus"
+)
+
+const buildBreachFilterCommand = "build-breach-filter"
+
+// Builds a bloom filter for the breached password check from a hash list in the hibp format
+func buildBreachFilter(args []string) error {
+	flags := flag.NewFlagSet(buildBreachFilterCommand, flag.ExitOnError)
+	input := flags.String("input", "", "hash list with one sha-1 hash per line, e.g. the pwned passwords list")
+	output := flags.String("output", "", "file the bloom filter is written to")
+	falsePositiveRate := flags.Float64("false-positive-rate", 0.001, "rate of passwords that are wrongly reported as breached")
+	flags.Parse(args)
+	if *input == "" || *output == "" {
+		flags.Usage()
+		return errors.New("input and output have to be set")
+	}
+
+	hashList, err := os.Open(*input)
+	if err != nil {
+		return fmt.Errorf("error while opening hash list: %v", err)
+	}
+	defer hashList.Close()
+
+	filter, err := breach.BuildBloomFilter(hashList, *falsePositiveRate)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		return fmt.Errorf("error while creating bloom filter file: %v", err)
+	}
+	size, err := filter.WriteTo(file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("error while writing bloom filter: %v", err)
+	}
+	log.Infof("Bloom filter with %d bytes written to %s", size, *output)
+	return nil
+}
@@ -0,0 +1,371 @@
+package api
+
+import (
+	stdContext "context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/BeanCodeDe/authi/pkg/parser"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	MIMEApplicationJSONLines = "application/x-ndjson"
+
+	defaultPageSize = 100
+	maxPageSize     = 1000
+	exportPageSize  = 1000
+
+	userIdQuery    = "user_id"
+	eventTypeQuery = "event_type"
+	successQuery   = "success"
+	fromQuery      = "from"
+	toQuery        = "to"
+	limitQuery     = "limit"
+	offsetQuery    = "offset"
+	searchQuery    = "search"
+	statusQuery    = "status"
+)
+
+type (
+	AdminApi struct {
+		facade core.Facade
+	}
+)
+
+// Creates the middleware that only lets requests with the admin api key or, if tokenParser is set, with a token of a user with the admin role pass.
+// Audit events of these requests are recorded with the admin as actor
+func newAdminAuthMiddleware(apiKey string, tokenParser parser.Parser) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			logger := context.Get(loggerKey).(*log.Entry)
+			request := context.Request()
+			if requestApiKey := request.Header.Get(adapter.AdminApiKeyHeaderName); apiKey != "" && requestApiKey != "" {
+				if subtle.ConstantTimeCompare([]byte(requestApiKey), []byte(apiKey)) != 1 {
+					logger.Warn("Request to admin api with wrong api key")
+					return echo.ErrUnauthorized
+				}
+				context.SetRequest(request.WithContext(core.ContextWithActor(request.Context(), core.ActorAdmin)))
+				return next(context)
+			}
+
+			if authHeader := request.Header.Get(adapter.AuthorizationHeaderName); tokenParser != nil && authHeader != "" {
+				claims, err := tokenParser.ParseToken(authHeader)
+				if err != nil {
+					logger.Warnf("Error while parsing token of admin request: %v", err)
+					return echo.ErrUnauthorized
+				}
+				if !claims.HasRole(adapter.RoleAdmin) {
+					logger.Warnf("User %v without admin role requested admin api", claims.UserId)
+					return echo.ErrForbidden
+				}
+				context.Set(adapter.ClaimName, *claims)
+				context.SetRequest(request.WithContext(core.ContextWithActor(request.Context(), claims.UserId.String())))
+				return next(context)
+			}
+
+			logger.Warn("Request to admin api without api key or token")
+			return echo.ErrUnauthorized
+		}
+	}
+}
+
+// Lists audit events, newest first. Supports the filters user_id, event_type, success, from and to and paging with limit and offset
+func (adminApi *AdminApi) GetAuditEvents(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Get audit events")
+
+	filter, err := bindAuditEventFilter(context)
+	if err != nil {
+		logger.Warnf("Error while binding audit event filter: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	auditEvents, err := adminApi.facade.GetAuditEvents(context.Request().Context(), filter)
+	if err != nil {
+		logger.Errorf("Something went wrong while loading audit events: %v", err)
+		return echo.ErrInternalServerError
+	}
+	return context.JSON(http.StatusOK, auditEvents)
+}
+
+// Writes all audit events that match the filters as json lines, newest first. Pages continue after the last written event,
+// so events that are added during the export don't shift the pages
+func (adminApi *AdminApi) ExportAuditEvents(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Export audit events")
+
+	filter, err := bindAuditEventFilter(context)
+	if err != nil {
+		logger.Warnf("Error while binding audit event filter: %v", err)
+		return echo.ErrBadRequest
+	}
+	filter.Limit = exportPageSize
+	filter.Offset = 0
+
+	response := context.Response()
+	response.Header().Set(echo.HeaderContentType, MIMEApplicationJSONLines)
+	encoder := json.NewEncoder(response)
+	for {
+		auditEvents, err := adminApi.facade.GetAuditEvents(context.Request().Context(), filter)
+		if err != nil {
+			logger.Errorf("Something went wrong while exporting audit events: %v", err)
+			if response.Committed {
+				return nil
+			}
+			return echo.ErrInternalServerError
+		}
+		if !response.Committed {
+			response.WriteHeader(http.StatusOK)
+		}
+		for _, auditEvent := range auditEvents {
+			if err := encoder.Encode(auditEvent); err != nil {
+				logger.Warnf("Error while writing audit event: %v", err)
+				return nil
+			}
+		}
+		response.Flush()
+		if len(auditEvents) < filter.Limit {
+			return nil
+		}
+		lastEvent := auditEvents[len(auditEvents)-1]
+		filter.BeforeTime = &lastEvent.EventTime
+		filter.BeforeId = lastEvent.Id
+	}
+}
+
+func bindAuditEventFilter(context echo.Context) (*core.AuditEventFilter, error) {
+	filter := &core.AuditEventFilter{EventType: context.QueryParam(eventTypeQuery), Limit: defaultPageSize}
+
+	if value := context.QueryParam(userIdQuery); value != "" {
+		userId, err := uuid.Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		filter.UserId = &userId
+	}
+	if value := context.QueryParam(successQuery); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+		filter.Success = &success
+	}
+	if value := context.QueryParam(fromQuery); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+		filter.From = &from
+	}
+	if value := context.QueryParam(toQuery); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+		filter.To = &to
+	}
+	limit, offset, err := bindPaging(context)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+	return filter, nil
+}
+
+// Reads limit and offset of a page. Without limit defaultPageSize is used
+func bindPaging(context echo.Context) (int, int, error) {
+	limit := defaultPageSize
+	offset := 0
+	if value := context.QueryParam(limitQuery); value != "" {
+		var err error
+		if limit, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+		if limit < 1 || limit > maxPageSize {
+			return 0, 0, fmt.Errorf("limit has to be between 1 and %d", maxPageSize)
+		}
+	}
+	if value := context.QueryParam(offsetQuery); value != "" {
+		var err error
+		if offset, err = strconv.Atoi(value); err != nil {
+			return 0, 0, err
+		}
+		if offset < 0 {
+			return 0, 0, errors.New("offset can't be negative")
+		}
+	}
+	return limit, offset, nil
+}
+
+// Lists users, oldest first. Supports the filters search, that matches the start of the user id, username or email ignoring the case, and status and paging with limit and offset
+func (adminApi *AdminApi) GetUsers(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Get users")
+
+	filter, err := bindUserFilter(context)
+	if err != nil {
+		logger.Warnf("Error while binding user filter: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	users, err := adminApi.facade.GetUsers(context.Request().Context(), filter)
+	if err != nil {
+		logger.Errorf("Something went wrong while loading users: %v", err)
+		return echo.ErrInternalServerError
+	}
+	return context.JSON(http.StatusOK, users)
+}
+
+func (adminApi *AdminApi) GetUser(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Get user")
+
+	userId, err := bindUserId(context)
+	if err != nil {
+		return err
+	}
+
+	user, err := adminApi.facade.GetUser(context.Request().Context(), userId)
+	if err != nil {
+		return adminErrorResponse(logger, "loading user", err)
+	}
+	return context.JSON(http.StatusOK, user)
+}
+
+// Sets the status of the user. Every status except active revokes all sessions
+func (adminApi *AdminApi) SetUserStatus(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	userStatus := new(adapter.UserStatusDTO)
+	if err := context.Bind(userStatus); err != nil {
+		logger.Warnf("Could not bind user status, %v", err)
+		return echo.ErrBadRequest
+	}
+	if err := context.Validate(userStatus); err != nil {
+		logger.Warnf("Could not validate user status, %v", err)
+		return echo.ErrBadRequest
+	}
+	return adminApi.setUserStatus(context, userStatus)
+}
+
+// Disables the user. The body with a reason is optional
+func (adminApi *AdminApi) DisableUser(context echo.Context) error {
+	return adminApi.setUserStatusWithOptionalReason(context, adapter.UserStatusDisabled)
+}
+
+// Activates the user again. The body with a reason is optional
+func (adminApi *AdminApi) EnableUser(context echo.Context) error {
+	return adminApi.setUserStatusWithOptionalReason(context, adapter.UserStatusActive)
+}
+
+func (adminApi *AdminApi) setUserStatusWithOptionalReason(context echo.Context, status string) error {
+	userStatus := new(adapter.UserStatusDTO)
+	if err := context.Bind(userStatus); err != nil {
+		context.Get(loggerKey).(*log.Entry).Warnf("Could not bind user status, %v", err)
+		return echo.ErrBadRequest
+	}
+	userStatus.Status = status
+	return adminApi.setUserStatus(context, userStatus)
+}
+
+func (adminApi *AdminApi) setUserStatus(context echo.Context, userStatus *adapter.UserStatusDTO) error {
+	return adminApi.changeUser(context, "setting status of user", func(ctx stdContext.Context, userId uuid.UUID) error {
+		return adminApi.facade.SetUserStatus(ctx, userId, userStatus.Status, userStatus.Reason)
+	})
+}
+
+func (adminApi *AdminApi) RevokeSessions(context echo.Context) error {
+	return adminApi.changeUser(context, "revoking sessions of user", adminApi.facade.RevokeSessions)
+}
+
+func (adminApi *AdminApi) DeleteUser(context echo.Context) error {
+	return adminApi.changeUser(context, "deleting user", adminApi.facade.DeleteUser)
+}
+
+// Sets a new password for the user and revokes all sessions
+func (adminApi *AdminApi) ResetPassword(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Reset password")
+
+	userId, authenticate, err := bindAuthenticate(context)
+	if err != nil {
+		return err
+	}
+
+	if err := adminApi.facade.ResetPassword(context.Request().Context(), userId, authenticate.Password); err != nil {
+		return adminErrorResponse(logger, "resetting password", err)
+	}
+	logger.Debugf("Password of user %s reset", userId)
+	return context.NoContent(http.StatusNoContent)
+}
+
+func (adminApi *AdminApi) changeUser(context echo.Context, action string, change func(ctx stdContext.Context, userId uuid.UUID) error) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Admin %s", action)
+
+	userId, err := bindUserId(context)
+	if err != nil {
+		return err
+	}
+
+	if err := change(context.Request().Context(), userId); err != nil {
+		return adminErrorResponse(logger, action, err)
+	}
+	logger.Debugf("Finished %s %s", action, userId)
+	return context.NoContent(http.StatusNoContent)
+}
+
+func adminErrorResponse(logger *log.Entry, action string, err error) error {
+	if errors.Is(err, core.ErrUserNotFound) {
+		logger.Warnf("User not found while %s", action)
+		return echo.ErrNotFound
+	}
+	if errors.Is(err, core.ErrUnknownUserStatus) {
+		logger.Warnf("Unknown status while %s", action)
+		return echo.ErrBadRequest
+	}
+	if errors.Is(err, core.ErrPasswordPolicy) {
+		logger.Warnf("Password violates policy while %s: %v", action, err)
+		return passwordPolicyErrorResponse(err)
+	}
+	logger.Errorf("Something went wrong while %s: %v", action, err)
+	return echo.ErrInternalServerError
+}
+
+func bindUserId(context echo.Context) (uuid.UUID, error) {
+	userId, err := uuid.Parse(context.Param(userIdParam))
+	if err != nil {
+		context.Get(loggerKey).(*log.Entry).Warnf("Error while binding userId: %v", err)
+		return uuid.Nil, echo.ErrBadRequest
+	}
+	return userId, nil
+}
+
+func bindUserFilter(context echo.Context) (*core.UserFilter, error) {
+	filter := &core.UserFilter{Search: context.QueryParam(searchQuery)}
+
+	if value := context.QueryParam(statusQuery); value != "" {
+		if !slices.Contains(adapter.UserStatuses, value) {
+			return nil, fmt.Errorf("unknown status %s", value)
+		}
+		filter.Status = value
+	}
+	limit, offset, err := bindPaging(context)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+	filter.Offset = offset
+	return filter, nil
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/BeanCodeDe/authi/pkg/parser"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+const (
+	adminApiKey = "someAdminApiKeyWithAtLeast32Characters"
+)
+
+func newAdminContext(t *testing.T, target string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	return c, rec
+}
+
+// Admin auth Tests
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	for _, apiKey := range []string{"", "someWrongApiKey", adminApiKey} {
+		c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath)
+		if apiKey != "" {
+			c.Request().Header.Set(adapter.AdminApiKeyHeaderName, apiKey)
+		}
+		var handlerCalled bool
+		handler := newAdminAuthMiddleware(adminApiKey, nil)(func(context echo.Context) error {
+			handlerCalled = true
+			return nil
+		})
+
+		err := handler(c)
+
+		if apiKey == adminApiKey {
+			assert.Nil(t, err)
+			assert.True(t, handlerCalled)
+		} else {
+			assert.Equal(t, echo.ErrUnauthorized, err)
+			assert.False(t, handlerCalled)
+		}
+	}
+}
+
+func TestAdminAuthMiddleware_AdminRole(t *testing.T) {
+	adminId := uuid.New()
+	for _, claims := range []*adapter.Claims{{UserId: adminId, Roles: []string{adapter.RoleAdmin}}, {UserId: adminId}} {
+		c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiUsersPath)
+		c.Request().Header.Set(adapter.AuthorizationHeaderName, "Bearer someToken")
+		tokenParser := &parser.ParserMock{ParseTokenResponseArray: []*parser.ParseTokenResponse{{Claim: claims}}}
+		var actor string
+		handler := newAdminAuthMiddleware(adminApiKey, tokenParser)(func(context echo.Context) error {
+			actor = context.Get(adapter.ClaimName).(adapter.Claims).UserId.String()
+			return nil
+		})
+
+		err := handler(c)
+
+		if claims.HasRole(adapter.RoleAdmin) {
+			assert.Nil(t, err)
+			assert.Equal(t, adminId.String(), actor)
+		} else {
+			assert.Equal(t, echo.ErrForbidden, err)
+		}
+		assert.Equal(t, "Bearer someToken", tokenParser.ParseTokenRecordArray[0].AuthorizationString)
+	}
+}
+
+func TestAdminAuthMiddleware_TokenWithoutRoleSupport(t *testing.T) {
+	c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiUsersPath)
+	c.Request().Header.Set(adapter.AuthorizationHeaderName, "Bearer someToken")
+	handler := newAdminAuthMiddleware(adminApiKey, nil)(func(context echo.Context) error {
+		return nil
+	})
+
+	err := handler(c)
+
+	assert.Equal(t, echo.ErrUnauthorized, err)
+}
+
+func TestNewServer_AdminRoutesOnlyWithApiKey(t *testing.T) {
+	for _, apiKey := range []string{"", adminApiKey} {
+		serverConfig := config.Default()
+		serverConfig.Metrics.Enabled = false
+		serverConfig.Admin.ApiKey = apiKey
+		server, err := newServer(serverConfig, &parser.ParserMock{}, &db.DBMock{}, &core.CoreMock{})
+		assert.Nil(t, err)
+
+		var adminRoutes int
+		for _, route := range server.echo.Routes() {
+			if strings.HasPrefix(route.Path, adapter.AuthiAdminPath) {
+				adminRoutes++
+			}
+		}
+		assert.Equal(t, apiKey != "", adminRoutes > 0)
+	}
+}
+
+func TestNewServer_AdminRoutesWithRole(t *testing.T) {
+	serverConfig := config.Default()
+	serverConfig.Metrics.Enabled = false
+	serverConfig.Admin.RoleEnabled = true
+	server, err := newServer(serverConfig, &parser.ParserMock{}, &db.DBMock{}, &core.CoreMock{})
+	assert.Nil(t, err)
+
+	var userRoutes int
+	for _, route := range server.echo.Routes() {
+		if strings.HasPrefix(route.Path, adapter.AuthiAdminPath+adapter.AuthiUsersPath) {
+			userRoutes++
+		}
+	}
+	assert.Equal(t, 8, userRoutes)
+}
+
+// GetAuditEvents Tests
+
+func TestGetAuditEvents_Successfully(t *testing.T) {
+	auditEvents := []*adapter.AuditEventDTO{{Id: 1, EventType: core.AuditUserLogin, UserId: userId, Success: true}}
+	facade := &core.CoreMock{GetAuditEventsResponseArray: []*core.GetAuditEventsResponse{{AuditEvents: auditEvents}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath+"?user_id="+userId.String()+"&event_type=user.login&success=false&from=2024-01-01T00:00:00Z&limit=10&offset=20")
+
+	err := adminApi.GetAuditEvents(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"event_type":"user.login"`)
+	assert.Equal(t, 1, len(facade.GetAuditEventsRecordArray))
+	filter := facade.GetAuditEventsRecordArray[0].Filter
+	assert.Equal(t, userId, *filter.UserId)
+	assert.Equal(t, core.AuditUserLogin, filter.EventType)
+	assert.False(t, *filter.Success)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), *filter.From)
+	assert.Nil(t, filter.To)
+	assert.Equal(t, 10, filter.Limit)
+	assert.Equal(t, 20, filter.Offset)
+}
+
+func TestGetAuditEvents_DefaultPaging(t *testing.T) {
+	facade := &core.CoreMock{GetAuditEventsResponseArray: []*core.GetAuditEventsResponse{{AuditEvents: []*adapter.AuditEventDTO{}}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath)
+
+	err := adminApi.GetAuditEvents(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, &core.AuditEventFilter{Limit: defaultPageSize}, facade.GetAuditEventsRecordArray[0].Filter)
+}
+
+func TestGetAuditEvents_WrongFilter(t *testing.T) {
+	for _, query := range []string{"user_id=someUser", "success=maybe", "from=yesterday", "limit=0", "limit=1001", "offset=-1"} {
+		facade := &core.CoreMock{}
+		adminApi := &AdminApi{facade}
+		c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath+"?"+query)
+
+		err := adminApi.GetAuditEvents(c)
+
+		assert.Equal(t, echo.ErrBadRequest, err, query)
+		assert.Equal(t, 0, len(facade.GetAuditEventsRecordArray))
+	}
+}
+
+func TestGetAuditEvents_InternalServerError(t *testing.T) {
+	facade := &core.CoreMock{GetAuditEventsResponseArray: []*core.GetAuditEventsResponse{{Err: errors.New("some error")}}}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath)
+
+	err := adminApi.GetAuditEvents(c)
+
+	assert.Equal(t, echo.ErrInternalServerError, err)
+}
+
+// ExportAuditEvents Tests
+
+func TestExportAuditEvents_Successfully(t *testing.T) {
+	firstPage := make([]*adapter.AuditEventDTO, exportPageSize)
+	for i := range firstPage {
+		firstPage[i] = &adapter.AuditEventDTO{Id: int64(exportPageSize - i), EventTime: time.Now(), UserId: uuid.New()}
+	}
+	secondPage := []*adapter.AuditEventDTO{{Id: 0, UserId: uuid.New()}}
+	facade := &core.CoreMock{GetAuditEventsResponseArray: []*core.GetAuditEventsResponse{{AuditEvents: firstPage}, {AuditEvents: secondPage}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath+adapter.AuthiExportPath+"?event_type=user.deleted&limit=5")
+
+	err := adminApi.ExportAuditEvents(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, MIMEApplicationJSONLines, rec.Header().Get(echo.HeaderContentType))
+	var lines int
+	scanner := bufio.NewScanner(rec.Body)
+	for scanner.Scan() {
+		lines++
+	}
+	assert.Equal(t, exportPageSize+1, lines)
+	assert.Equal(t, 2, len(facade.GetAuditEventsRecordArray))
+	assert.Equal(t, core.AuditUserDeleted, facade.GetAuditEventsRecordArray[1].Filter.EventType)
+	assert.Equal(t, 0, facade.GetAuditEventsRecordArray[1].Filter.Offset)
+	assert.Equal(t, firstPage[exportPageSize-1].EventTime, *facade.GetAuditEventsRecordArray[1].Filter.BeforeTime)
+	assert.Equal(t, int64(1), facade.GetAuditEventsRecordArray[1].Filter.BeforeId)
+}
+
+func TestExportAuditEvents_InternalServerError(t *testing.T) {
+	facade := &core.CoreMock{GetAuditEventsResponseArray: []*core.GetAuditEventsResponse{{Err: errors.New("some error")}}}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiAuditEventsPath+adapter.AuthiExportPath)
+
+	err := adminApi.ExportAuditEvents(c)
+
+	assert.Equal(t, echo.ErrInternalServerError, err)
+}
+
+// Admin user Tests
+
+func newAdminUserContext(t *testing.T, method string, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(method, adapter.AuthiAdminPath+adapter.AuthiUsersPath+"/"+userId.String(), strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiAdminPath + adapter.AuthiUsersPath + "/:" + userIdParam)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	return c, rec
+}
+
+func TestGetUsers_Successfully(t *testing.T) {
+	users := []*adapter.UserDTO{{Id: userId, Status: adapter.UserStatusDisabled}}
+	facade := &core.CoreMock{GetUsersResponseArray: []*core.GetUsersResponse{{Users: users}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiUsersPath+"?search=ab12&status=disabled&limit=5&offset=10")
+
+	err := adminApi.GetUsers(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), userId.String())
+	assert.Equal(t, &core.UserFilter{Search: "ab12", Status: adapter.UserStatusDisabled, Limit: 5, Offset: 10}, facade.GetUsersRecordArray[0].Filter)
+}
+
+func TestGetUsers_WrongFilter(t *testing.T) {
+	for _, query := range []string{"status=maybe", "limit=0", "offset=-1"} {
+		facade := &core.CoreMock{}
+		adminApi := &AdminApi{facade}
+		c, _ := newAdminContext(t, adapter.AuthiAdminPath+adapter.AuthiUsersPath+"?"+query)
+
+		err := adminApi.GetUsers(c)
+
+		assert.Equal(t, echo.ErrBadRequest, err, query)
+		assert.Equal(t, 0, len(facade.GetUsersRecordArray))
+	}
+}
+
+func TestGetUser_Successfully(t *testing.T) {
+	facade := &core.CoreMock{GetUserResponseArray: []*core.GetUserResponse{{User: &adapter.UserDTO{Id: userId, Admin: true}}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminUserContext(t, http.MethodGet, "")
+
+	err := adminApi.GetUser(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"admin":true`)
+	assert.Equal(t, userId, facade.GetUserRecordArray[0].UserId)
+}
+
+func TestGetUser_NotFound(t *testing.T) {
+	facade := &core.CoreMock{GetUserResponseArray: []*core.GetUserResponse{{Err: core.ErrUserNotFound}}}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminUserContext(t, http.MethodGet, "")
+
+	err := adminApi.GetUser(c)
+
+	assert.Equal(t, echo.ErrNotFound, err)
+}
+
+func TestDisableUser_Successfully(t *testing.T) {
+	facade := &core.CoreMock{SetUserStatusResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminUserContext(t, http.MethodPost, "")
+
+	err := adminApi.DisableUser(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, &core.SetUserStatusRecord{UserId: userId, Status: adapter.UserStatusDisabled}, facade.SetUserStatusRecordArray[0])
+}
+
+func TestEnableUser_WithReason(t *testing.T) {
+	facade := &core.CoreMock{SetUserStatusResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminUserContext(t, http.MethodPost, `{"status":"locked","reason":"ticket 42"}`)
+
+	err := adminApi.EnableUser(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, &core.SetUserStatusRecord{UserId: userId, Status: adapter.UserStatusActive, Reason: "ticket 42"}, facade.SetUserStatusRecordArray[0])
+}
+
+func TestSetUserStatus_Successfully(t *testing.T) {
+	facade := &core.CoreMock{SetUserStatusResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminUserContext(t, http.MethodPut, `{"status":"locked","reason":"compromised"}`)
+
+	err := adminApi.SetUserStatus(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, &core.SetUserStatusRecord{UserId: userId, Status: adapter.UserStatusLocked, Reason: "compromised"}, facade.SetUserStatusRecordArray[0])
+}
+
+func TestSetUserStatus_UnknownStatus(t *testing.T) {
+	for _, body := range []string{`{"status":"deleted"}`, `{"reason":"no status"}`} {
+		facade := &core.CoreMock{}
+		adminApi := &AdminApi{facade}
+		c, _ := newAdminUserContext(t, http.MethodPut, body)
+
+		err := adminApi.SetUserStatus(c)
+
+		assert.Equal(t, echo.ErrBadRequest, err, body)
+		assert.Equal(t, 0, len(facade.SetUserStatusRecordArray))
+	}
+}
+
+func TestSetUserStatus_NotFound(t *testing.T) {
+	facade := &core.CoreMock{SetUserStatusResponseArray: []*core.ErrorResponse{{Err: core.ErrUserNotFound}}}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminUserContext(t, http.MethodPut, `{"status":"pending"}`)
+
+	err := adminApi.SetUserStatus(c)
+
+	assert.Equal(t, echo.ErrNotFound, err)
+}
+
+func TestRevokeSessions_InternalServerError(t *testing.T) {
+	facade := &core.CoreMock{RevokeSessionsResponseArray: []*core.ErrorResponse{{Err: errors.New("some error")}}}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminUserContext(t, http.MethodPost, "")
+
+	err := adminApi.RevokeSessions(c)
+
+	assert.Equal(t, echo.ErrInternalServerError, err)
+}
+
+func TestResetPassword_Successfully(t *testing.T) {
+	facade := &core.CoreMock{ResetPasswordResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	adminApi := &AdminApi{facade}
+	c, rec := newAdminUserContext(t, http.MethodPut, authenticationUserJson)
+
+	err := adminApi.ResetPassword(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, userId, facade.ResetPasswordRecordArray[0].UserId)
+	assert.Equal(t, password, facade.ResetPasswordRecordArray[0].Password)
+}
+
+func TestResetPassword_WithoutPassword(t *testing.T) {
+	facade := &core.CoreMock{}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminUserContext(t, http.MethodPut, "{}")
+
+	err := adminApi.ResetPassword(c)
+
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.ResetPasswordRecordArray))
+}
+
+func TestResetPassword_PasswordPolicy(t *testing.T) {
+	facade := &core.CoreMock{ResetPasswordResponseArray: []*core.ErrorResponse{{Err: &core.PasswordPolicyError{Violations: []string{adapter.PasswordTooShort}}}}}
+	adminApi := &AdminApi{facade}
+	c, _ := newAdminUserContext(t, http.MethodPut, authenticationUserJson)
+
+	err := adminApi.ResetPassword(c)
+
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, &adapter.PasswordPolicyErrorDTO{Message: core.ErrPasswordPolicy.Error(), Violations: []string{adapter.PasswordTooShort}}), err)
+}
@@ -13,21 +13,46 @@ type (
 		CreateUser(context echo.Context) error
 		RefreshToken(context echo.Context) error
 		LoginUser(context echo.Context) error
+		LoginUserByIdentifier(context echo.Context) error
+		GetLoginHistory(context echo.Context) error
+		SendEmailVerification(context echo.Context) error
+		VerifyEmail(context echo.Context) error
+		RequestPasswordReset(context echo.Context) error
+		ConfirmPasswordReset(context echo.Context) error
+		UpdatePassword(context echo.Context) error
+		ChangeExpiredPassword(context echo.Context) error
+		DeleteUser(context echo.Context) error
+		LoginWithMfa(context echo.Context) error
+		EnrollTotp(context echo.Context) error
+		ConfirmTotp(context echo.Context) error
+		DisableTotp(context echo.Context) error
+		RegenerateRecoveryCodes(context echo.Context) error
+		BeginPasskeyRegistration(context echo.Context) error
+		FinishPasskeyRegistration(context echo.Context) error
+		BeginPasskeyLogin(context echo.Context) error
+		LoginWithPasskey(context echo.Context) error
 	}
 )
 
+var _ Api = (*UserApi)(nil)
+
 const (
-	CorrelationIdHeader = "X-Correlation-ID"
+	CorrelationIdHeader = adapter.CorrelationIdHeader
 )
 
 func bindAuthenticate(context echo.Context) (uuid.UUID, *adapter.AuthenticateDTO, error) {
+	return bindUserBody[adapter.AuthenticateDTO](context)
+}
+
+// Binds and validates the body and the user id of the path
+func bindUserBody[T any](context echo.Context) (uuid.UUID, *T, error) {
 	logger := context.Get(loggerKey).(*log.Entry)
-	authenticate := new(adapter.AuthenticateDTO)
-	if err := context.Bind(authenticate); err != nil {
+	body := new(T)
+	if err := context.Bind(body); err != nil {
 		logger.Warnf("Could not bind auth, %v", err)
 		return uuid.Nil, nil, echo.ErrBadRequest
 	}
-	if err := context.Validate(authenticate); err != nil {
+	if err := context.Validate(body); err != nil {
 		logger.Warnf("Could not validate auth, %v", err)
 		return uuid.Nil, nil, echo.ErrBadRequest
 	}
@@ -38,7 +63,7 @@ func bindAuthenticate(context echo.Context) (uuid.UUID, *adapter.AuthenticateDTO
 		return uuid.Nil, nil, echo.ErrBadRequest
 	}
 
-	return userId, authenticate, nil
+	return userId, body, nil
 }
 
 func checkUserId(context echo.Context, userId uuid.UUID) error {
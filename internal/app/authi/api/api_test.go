@@ -26,6 +26,7 @@ var (
 	password                      = "some_password"
 	refreshToken                  = "some_refresh_token"
 	authenticationUserJson        = fmt.Sprintf(`{"password":"%s"}`, password)
+	updatePasswordJson            = fmt.Sprintf(`{"current_password":"%s","new_password":"some_new_password"}`, password)
 	authenticateObject            = &adapter.AuthenticateDTO{Password: password}
 	authenticationUserInvalidJson = `{"password":""}`
 	claimUser                     = adapter.Claims{UserId: userId}
@@ -0,0 +1,38 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// Longer correlation ids are replaced, so callers can't blow up the log
+	maxCorrelationIdLength = 128
+)
+
+// Creates the middleware that takes the correlation id of the caller or generates a new one, if it is missing or doesn't match the pattern.
+// The id is stored in the context of the request for logging and downstream calls and returned in the response header
+func newCorrelationIdMiddleware(pattern string) (echo.MiddlewareFunc, error) {
+	correlationIdPattern, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("error while compiling correlation id pattern: %v", err)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(context echo.Context) error {
+			request := context.Request()
+			correlationId := request.Header.Get(CorrelationIdHeader)
+			if len(correlationId) > maxCorrelationIdLength || !correlationIdPattern.MatchString(correlationId) {
+				correlationId = uuid.NewString()
+			}
+
+			context.SetRequest(request.WithContext(adapter.ContextWithCorrelationId(request.Context(), correlationId)))
+			context.Response().Header().Set(CorrelationIdHeader, correlationId)
+			return next(context)
+		}
+	}, nil
+}
@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func sendWithCorrelationId(t *testing.T, pattern string, correlationId string) (string, string) {
+	correlationIdMiddleware, err := newCorrelationIdMiddleware(pattern)
+	assert.Nil(t, err)
+	var contextCorrelationId string
+	e := echo.New()
+	e.Use(correlationIdMiddleware)
+	e.GET("/", func(context echo.Context) error {
+		contextCorrelationId = adapter.CorrelationIdFromContext(context.Request().Context())
+		return context.NoContent(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if correlationId != "" {
+		req.Header.Set(CorrelationIdHeader, correlationId)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec.Header().Get(CorrelationIdHeader), contextCorrelationId
+}
+
+func TestCorrelationIdMiddleware_KeepsValidId(t *testing.T) {
+	correlationId := uuid.NewString()
+
+	responseCorrelationId, contextCorrelationId := sendWithCorrelationId(t, config.CorrelationIdPatternUuid, correlationId)
+
+	assert.Equal(t, correlationId, responseCorrelationId)
+	assert.Equal(t, correlationId, contextCorrelationId)
+}
+
+func TestCorrelationIdMiddleware_GeneratesMissingId(t *testing.T) {
+	responseCorrelationId, contextCorrelationId := sendWithCorrelationId(t, config.CorrelationIdPatternUuid, "")
+
+	_, err := uuid.Parse(responseCorrelationId)
+	assert.Nil(t, err)
+	assert.Equal(t, responseCorrelationId, contextCorrelationId)
+}
+
+func TestCorrelationIdMiddleware_ReplacesWrongFormat(t *testing.T) {
+	responseCorrelationId, contextCorrelationId := sendWithCorrelationId(t, config.CorrelationIdPatternUuid, "someCorrelationId")
+
+	_, err := uuid.Parse(responseCorrelationId)
+	assert.Nil(t, err)
+	assert.Equal(t, responseCorrelationId, contextCorrelationId)
+}
+
+func TestCorrelationIdMiddleware_CustomPattern(t *testing.T) {
+	responseCorrelationId, _ := sendWithCorrelationId(t, `^[a-zA-Z0-9-]+$`, "someCorrelationId")
+	assert.Equal(t, "someCorrelationId", responseCorrelationId)
+
+	tooLongCorrelationId := strings.Repeat("a", maxCorrelationIdLength+1)
+	responseCorrelationId, _ = sendWithCorrelationId(t, `^[a-zA-Z0-9-]+$`, tooLongCorrelationId)
+	assert.NotEqual(t, tooLongCorrelationId, responseCorrelationId)
+}
+
+func TestNewCorrelationIdMiddleware_WrongPattern(t *testing.T) {
+	correlationIdMiddleware, err := newCorrelationIdMiddleware("[a-z")
+
+	assert.Nil(t, correlationIdMiddleware)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	ctx "context"
+	"net/http"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	healthCheckTimeout = 2 * time.Second
+
+	databaseCheck   = "database"
+	migrationsCheck = "migrations"
+	signKeyCheck    = "sign_key"
+)
+
+type (
+	HealthApi struct {
+		dbConnection db.Connection
+		facade       core.Facade
+	}
+)
+
+// Answers as long as the process is running
+func (healthApi *HealthApi) Healthz(context echo.Context) error {
+	return context.JSON(http.StatusOK, &adapter.HealthDTO{Status: adapter.HealthStatusUp})
+}
+
+// Answers with status 503 if one of the checks fails, so no requests are routed to this instance
+func (healthApi *HealthApi) Readyz(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	checkContext, cancel := ctx.WithTimeout(context.Request().Context(), healthCheckTimeout)
+	defer cancel()
+
+	checks := []struct {
+		name  string
+		check func() error
+	}{
+		{databaseCheck, func() error { return healthApi.dbConnection.Ping(checkContext) }},
+		{migrationsCheck, healthApi.dbConnection.CheckMigrations},
+		{signKeyCheck, healthApi.facade.CheckSignKey},
+	}
+
+	health := &adapter.HealthDTO{Status: adapter.HealthStatusUp, Checks: make(map[string]string, len(checks))}
+	for _, check := range checks {
+		if err := check.check(); err != nil {
+			logger.Warnf("Readiness check %s failed: %v", check.name, err)
+			health.Status = adapter.HealthStatusDown
+			health.Checks[check.name] = adapter.HealthStatusDown
+			continue
+		}
+		health.Checks[check.name] = adapter.HealthStatusUp
+	}
+
+	if health.Status != adapter.HealthStatusUp {
+		return context.JSON(http.StatusServiceUnavailable, health)
+	}
+	return context.JSON(http.StatusOK, health)
+}
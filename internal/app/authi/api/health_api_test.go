@@ -0,0 +1,82 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthz_Successfully(t *testing.T) {
+	healthApi := &HealthApi{dbConnection: &db.DBMock{}, facade: &core.CoreMock{}}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiHealthPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	err := healthApi.Healthz(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"up"}`, rec.Body.String())
+}
+
+func TestReadyz_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{PingResponseArray: []*db.ErrorResponse{{Err: nil}}, CheckMigrationsResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	facade := &core.CoreMock{CheckSignKeyResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	healthApi := &HealthApi{dbConnection: dbConnection, facade: facade}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiReadyPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+
+	err := healthApi.Readyz(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"up","checks":{"database":"up","migrations":"up","sign_key":"up"}}`, rec.Body.String())
+	assert.Equal(t, 1, len(dbConnection.PingRecordArray))
+	assert.Equal(t, 1, len(dbConnection.CheckMigrationsRecordArray))
+	assert.Equal(t, 1, len(facade.CheckSignKeyRecordArray))
+}
+
+func TestReadyz_DatabaseDown(t *testing.T) {
+	dbConnection := &db.DBMock{PingResponseArray: []*db.ErrorResponse{{Err: errSome}}, CheckMigrationsResponseArray: []*db.ErrorResponse{{Err: errSome}}}
+	facade := &core.CoreMock{CheckSignKeyResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	healthApi := &HealthApi{dbConnection: dbConnection, facade: facade}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiReadyPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+
+	err := healthApi.Readyz(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"down","checks":{"database":"down","migrations":"down","sign_key":"up"}}`, rec.Body.String())
+}
+
+func TestReadyz_SignKeyInvalid(t *testing.T) {
+	dbConnection := &db.DBMock{PingResponseArray: []*db.ErrorResponse{{Err: nil}}, CheckMigrationsResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	facade := &core.CoreMock{CheckSignKeyResponseArray: []*core.ErrorResponse{{Err: errSome}}}
+	healthApi := &HealthApi{dbConnection: dbConnection, facade: facade}
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiReadyPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+
+	err := healthApi.Readyz(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"status":"down","checks":{"database":"up","migrations":"up","sign_key":"down"}}`, rec.Body.String())
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+)
+
+// Writes one access log entry for each request with the logger of the request, so the correlation id is part of the entry
+func accessLogMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(context echo.Context) error {
+		start := time.Now()
+		err := next(context)
+
+		request := context.Request()
+		route := routeOf(context)
+		logger := context.Get(loggerKey).(*log.Entry)
+		level := log.InfoLevel
+		if isProbeRoute(route) {
+			level = log.DebugLevel
+		}
+		logger.WithFields(log.Fields{
+			logging.MethodField:    request.Method,
+			logging.RouteField:     route,
+			logging.StatusField:    responseStatus(context, err),
+			logging.LatencyField:   float64(time.Since(start).Microseconds()) / 1000,
+			logging.UserIdField:    userIdOf(context),
+			logging.RemoteIpField:  context.RealIP(),
+			logging.UserAgentField: request.UserAgent(),
+		}).Log(level, "Request handled")
+		return err
+	}
+}
+
+// Probes of orchestrators and prometheus are only logged on debug level, otherwise they would flood the log
+func isProbeRoute(route string) bool {
+	return route == adapter.AuthiHealthPath || route == adapter.AuthiReadyPath || route == MetricsPath
+}
+
+// Id of the user the request is about. Requests without user id in path or token are logged without user id
+func userIdOf(context echo.Context) string {
+	if userId := context.Param(userIdParam); userId != "" {
+		return userId
+	}
+	if claims, ok := context.Get(adapter.ClaimName).(adapter.Claims); ok {
+		return claims.UserId.String()
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func captureLog(t *testing.T) *bytes.Buffer {
+	var output bytes.Buffer
+	logger := log.StandardLogger()
+	previousOutput, previousFormatter, previousLevel := logger.Out, logger.Formatter, logger.Level
+	logger.SetOutput(&output)
+	logger.SetFormatter(logging.NewFormatter(config.LogFormatJson))
+	logger.SetLevel(log.InfoLevel)
+	t.Cleanup(func() {
+		logger.SetOutput(previousOutput)
+		logger.SetFormatter(previousFormatter)
+		logger.SetLevel(previousLevel)
+	})
+	return &output
+}
+
+func newAccessLogEcho(t *testing.T) *echo.Echo {
+	correlationIdMiddleware, err := newCorrelationIdMiddleware(config.CorrelationIdPatternUuid)
+	assert.Nil(t, err)
+	e := echo.New()
+	e.Use(correlationIdMiddleware, setLoggerMiddleware, accessLogMiddleware)
+	e.PATCH("/user/:"+userIdParam, func(context echo.Context) error {
+		return echo.ErrUnauthorized
+	})
+	e.GET(MetricsPath, func(context echo.Context) error {
+		return context.NoContent(http.StatusOK)
+	})
+	return e
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	output := captureLog(t)
+	userId := uuid.NewString()
+	correlationId := uuid.NewString()
+	req := httptest.NewRequest(http.MethodPatch, "/user/"+userId, nil)
+	req.Header.Set(CorrelationIdHeader, correlationId)
+	req.Header.Set(echo.HeaderAuthorization, "Bearer someToken")
+
+	newAccessLogEcho(t).ServeHTTP(httptest.NewRecorder(), req)
+
+	entry := map[string]any{}
+	assert.Nil(t, json.Unmarshal(output.Bytes(), &entry))
+	assert.Equal(t, "Request handled", entry["msg"])
+	assert.Equal(t, http.MethodPatch, entry[logging.MethodField])
+	assert.Equal(t, "/user/:userId", entry[logging.RouteField])
+	assert.Equal(t, float64(http.StatusUnauthorized), entry[logging.StatusField])
+	assert.Equal(t, userId, entry[logging.UserIdField])
+	assert.Equal(t, correlationId, entry[logging.CorrelationIdField])
+	assert.Contains(t, entry, logging.LatencyField)
+	assert.NotContains(t, output.String(), "someToken")
+}
+
+func TestAccessLogMiddleware_ProbesOnDebugLevel(t *testing.T) {
+	output := captureLog(t)
+	req := httptest.NewRequest(http.MethodGet, MetricsPath, nil)
+	req.Header.Set(CorrelationIdHeader, uuid.NewString())
+
+	newAccessLogEcho(t).ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Empty(t, output.String())
+}
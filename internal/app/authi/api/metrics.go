@@ -0,0 +1,47 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	MetricsPath = "/metrics"
+
+	unknownRoute = "unknown"
+)
+
+// Counts every request with its route template, so requests for different users end up in the same series
+func metricsMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(context echo.Context) error {
+		start := time.Now()
+		err := next(context)
+
+		metrics.ObserveRequest(context.Request().Method, routeOf(context), responseStatus(context, err), time.Since(start))
+		return err
+	}
+}
+
+// Route template of the request, e.g. /user/:userId, instead of the path with the actual user id
+func routeOf(context echo.Context) string {
+	if route := context.Path(); route != "" {
+		return route
+	}
+	return unknownRoute
+}
+
+// Status of the response. If the handler returned an error, the error handler of echo hasn't written the status yet
+func responseStatus(context echo.Context, err error) int {
+	if err == nil || context.Response().Committed {
+		return context.Response().Status
+	}
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/BeanCodeDe/authi/pkg/parser"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_SameAddress(t *testing.T) {
+	dbConnection := &db.DBMock{StatsResponseArray: []*db.PoolStats{{}}}
+	server, url, serverErr := startTestServer(t, dbConnection)
+
+	resp, err := http.Post(url+adapter.AuthiRootPath, echo.MIMEApplicationJSON, nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	resp, err = http.Get(url + MetricsPath)
+	assert.Nil(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `authi_http_requests_total{method="POST",route="/user",status="201"}`)
+	assert.Contains(t, string(body), "authi_db_pool_total_connections")
+	assert.Nil(t, server.MetricsListenerAddr())
+
+	assert.Nil(t, server.Shutdown(context.Background()))
+	assert.Nil(t, <-serverErr)
+}
+
+func TestMetrics_OwnPort(t *testing.T) {
+	serverConfig := config.Default()
+	serverConfig.Server.Address = "127.0.0.1"
+	serverConfig.Server.Port = 0
+	serverConfig.Metrics.Port = freePort(t)
+	dbConnection := &db.DBMock{StatsResponseArray: []*db.PoolStats{{}}}
+	server, err := newServer(serverConfig, &parser.ParserMock{}, dbConnection, &core.CoreMock{})
+	assert.Nil(t, err)
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start()
+	}()
+	assert.Eventually(t, func() bool { return server.ListenerAddr() != nil && server.MetricsListenerAddr() != nil }, time.Second, 10*time.Millisecond)
+
+	resp, err := http.Get("http://" + server.ListenerAddr().String() + MetricsPath)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get("http://" + server.MetricsListenerAddr().String() + MetricsPath)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	assert.Nil(t, server.Shutdown(context.Background()))
+	assert.Nil(t, <-serverErr)
+	assert.Equal(t, 1, len(dbConnection.CloseRecordArray))
+}
+
+func TestMetrics_Disabled(t *testing.T) {
+	serverConfig := config.Default()
+	serverConfig.Metrics.Enabled = false
+	server, err := newServer(serverConfig, &parser.ParserMock{}, &db.DBMock{}, &core.CoreMock{})
+	assert.Nil(t, err)
+
+	for _, route := range server.echo.Routes() {
+		assert.NotEqual(t, MetricsPath, route.Path)
+	}
+}
+
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Minimal time after which unused buckets are removed
+	rateLimiterMinExpiresIn = 1 * time.Minute
+
+	loginRoute        = "login"
+	createUserIdRoute = "create_user_id"
+	defaultRoute      = "default"
+)
+
+func newRateLimiterMiddleware(route string, limit config.RateLimit, storeType string, dbConnection db.Connection) (echo.MiddlewareFunc, error) {
+	store, err := newRateLimiterStore(route, storeType, dbConnection, limit.Rate, limit.Burst)
+	if err != nil {
+		return nil, err
+	}
+
+	config := middleware.RateLimiterConfig{
+		Skipper:             middleware.DefaultSkipper,
+		Store:               store,
+		IdentifierExtractor: rateLimiterIdentifier(route),
+		ErrorHandler: func(context echo.Context, err error) error {
+			return context.JSON(http.StatusForbidden, nil)
+		},
+		DenyHandler: func(context echo.Context, identifier string, err error) error {
+			metrics.CountRateLimitDenied(route)
+			return context.JSON(http.StatusTooManyRequests, nil)
+		},
+	}
+	return middleware.RateLimiterWithConfig(config), nil
+}
+
+func newRateLimiterStore(route string, storeType string, dbConnection db.Connection, limit float64, burst int) (middleware.RateLimiterStore, error) {
+	expiresIn := rateLimiterExpiresIn(limit, burst)
+	switch strings.ToLower(storeType) {
+	case config.RateLimiterStoreMemory:
+		return middleware.NewRateLimiterMemoryStoreWithConfig(
+			middleware.RateLimiterMemoryStoreConfig{Rate: rate.Limit(limit), Burst: burst, ExpiresIn: expiresIn},
+		), nil
+	case config.RateLimiterStoreDatabase:
+		return db.NewRateLimiterStore(dbConnection, route, limit, burst, expiresIn), nil
+	default:
+		return nil, fmt.Errorf("no rate limiter store %s found", storeType)
+	}
+}
+
+// Unused buckets are only removed once they are full again, so clients can't reset their limit by waiting less than the refill time
+func rateLimiterExpiresIn(limit float64, burst int) time.Duration {
+	refillTime := time.Duration(float64(burst) / limit * float64(time.Second))
+	return max(refillTime, rateLimiterMinExpiresIn)
+}
+
+// Limits are counted per route, ip and user, so one client can't use up the limit of other clients
+func rateLimiterIdentifier(route string) middleware.Extractor {
+	return func(context echo.Context) (string, error) {
+		return route + ":" + context.RealIP() + ":" + context.Param(userIdParam), nil
+	}
+}
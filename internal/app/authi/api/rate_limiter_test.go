@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRateLimiterStore_Memory(t *testing.T) {
+	store, err := newRateLimiterStore(loginRoute, "memory", nil, 1, 5)
+
+	assert.Nil(t, err)
+	assert.IsType(t, &middleware.RateLimiterMemoryStore{}, store)
+}
+
+func TestNewRateLimiterStore_Database(t *testing.T) {
+	store, err := newRateLimiterStore(loginRoute, "Database", &db.DBMock{}, 1, 5)
+
+	assert.Nil(t, err)
+	assert.IsType(t, &db.RateLimiterStore{}, store)
+}
+
+func TestNewRateLimiterStore_Unknown(t *testing.T) {
+	store, err := newRateLimiterStore(loginRoute, "redis", nil, 1, 5)
+
+	assert.Nil(t, store)
+	assert.ErrorContains(t, err, "redis")
+}
+
+func TestNewRateLimiterMiddleware_UnknownStore(t *testing.T) {
+	rateLimiter, err := newRateLimiterMiddleware(loginRoute, config.RateLimit{Rate: 1, Burst: 2}, "redis", nil)
+
+	assert.Nil(t, rateLimiter)
+	assert.ErrorContains(t, err, "redis")
+}
+
+func TestNewRateLimiterMiddleware_DenyAfterBurst(t *testing.T) {
+	rateLimiter, err := newRateLimiterMiddleware(loginRoute, config.RateLimit{Rate: 1, Burst: 2}, config.RateLimiterStoreMemory, nil)
+	assert.Nil(t, err)
+
+	e := echo.New()
+	e.POST(adapter.AuthiRootPath+"/:"+userIdParam+adapter.AuthiLoginPath, func(c echo.Context) error { return c.NoContent(http.StatusOK) }, rateLimiter)
+
+	statusCodes := make([]int, 3)
+	for i := range statusCodes {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath+"/"+userId.String()+adapter.AuthiLoginPath, nil))
+		statusCodes[i] = rec.Code
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, statusCodes)
+}
+
+func TestRateLimiterExpiresIn(t *testing.T) {
+	assert.Equal(t, time.Minute, rateLimiterExpiresIn(10, 30))
+	assert.Equal(t, 100*time.Second, rateLimiterExpiresIn(0.1, 10))
+	assert.Equal(t, 10*time.Minute, rateLimiterExpiresIn(1.0/60, 10))
+}
+
+func TestRateLimiterIdentifier(t *testing.T) {
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, nil)
+	c := e.NewContext(req, nil)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+
+	identifier, err := rateLimiterIdentifier(loginRoute)(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "login:192.0.2.1:"+userId.String(), identifier)
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/BeanCodeDe/authi/internal/app/authi/webhook"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	echoMiddleware "github.com/BeanCodeDe/authi/pkg/middleware"
+	"github.com/BeanCodeDe/authi/pkg/parser"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme/autocert"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+type (
+	// Http server of authi. It is created with all routes, but only listens after Start is called
+	Server struct {
+		echo           *echo.Echo
+		metricsEcho    *echo.Echo
+		dbConnection   db.Connection
+		dispatcher     *webhook.Dispatcher
+		address        string
+		metricsAddress string
+	}
+)
+
+// Creates the database connection, the user facade and the server with all routes
+func NewServer(config *config.Config, parser parser.Parser) (*Server, error) {
+	dbConnection, err := db.NewConnection(&config.Database)
+	if err != nil {
+		return nil, fmt.Errorf("error while initializing database: %v", err)
+	}
+
+	userFacade, err := core.NewUserFacade(config, dbConnection)
+	if err != nil {
+		dbConnection.Close()
+		return nil, fmt.Errorf("error while initializing user facade: %v", err)
+	}
+
+	server, err := newServer(config, parser, dbConnection, core.NewTracedFacade(userFacade))
+	if err != nil {
+		dbConnection.Close()
+		return nil, err
+	}
+	return server, nil
+}
+
+func newServer(config *config.Config, parser parser.Parser, dbConnection db.Connection, facade core.Facade) (*Server, error) {
+	echoMiddleware := echoMiddleware.NewEchoMiddleware(parser)
+	api := &UserApi{facade}
+
+	e := newEcho()
+	e.AutoTLSManager.Cache = autocert.DirCache("/var/www/.cache")
+	var middlewares []echo.MiddlewareFunc
+	if config.Metrics.Enabled {
+		middlewares = append(middlewares, metricsMiddleware)
+	}
+	correlationIdMiddleware, err := newCorrelationIdMiddleware(config.Server.CorrelationIdPattern)
+	if err != nil {
+		return nil, err
+	}
+	cors := middleware.CORSWithConfig(middleware.CORSConfig{ExposeHeaders: []string{CorrelationIdHeader}})
+	e.Use(append(middlewares, tracingMiddleware, cors, correlationIdMiddleware, setClientInfoMiddleware, setLoggerMiddleware, accessLogMiddleware, middleware.Recover())...)
+
+	loginRateLimiter, err := newRateLimiterMiddleware(loginRoute, config.RateLimit.Login, config.RateLimit.Store, dbConnection)
+	if err != nil {
+		return nil, err
+	}
+	createUserIdRateLimiter, err := newRateLimiterMiddleware(createUserIdRoute, config.RateLimit.CreateUserId, config.RateLimit.Store, dbConnection)
+	if err != nil {
+		return nil, err
+	}
+	defaultRateLimiter, err := newRateLimiterMiddleware(defaultRoute, config.RateLimit.Default, config.RateLimit.Store, dbConnection)
+	if err != nil {
+		return nil, err
+	}
+
+	e.Validator = &CustomValidator{validator: validator.New()}
+
+	healthApi := &HealthApi{dbConnection: dbConnection, facade: facade}
+	e.GET(adapter.AuthiHealthPath, healthApi.Healthz)
+	e.GET(adapter.AuthiReadyPath, healthApi.Readyz)
+
+	e.POST(adapter.AuthiLoginPath, api.LoginUserByIdentifier, loginRateLimiter)
+	e.POST(adapter.AuthiLoginPath+adapter.AuthiMfaPath, api.LoginWithMfa, loginRateLimiter)
+	e.POST(adapter.AuthiPasswordResetPath, api.RequestPasswordReset, loginRateLimiter)
+	e.POST(adapter.AuthiPasswordResetPath+adapter.AuthiConfirmPath, api.ConfirmPasswordReset, loginRateLimiter)
+
+	userGroup := e.Group(adapter.AuthiRootPath)
+	userGroup.POST("", api.CreateUserId, createUserIdRateLimiter)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiLoginPath, api.LoginUser, loginRateLimiter)
+	userGroup.PUT("/:"+userIdParam, api.CreateUser, defaultRateLimiter)
+	userGroup.PATCH("/:"+userIdParam+adapter.AuthiRefreshPath, api.RefreshToken, defaultRateLimiter, echoMiddleware.CheckToken)
+	userGroup.PATCH("/:"+userIdParam, api.UpdatePassword, defaultRateLimiter, echoMiddleware.CheckToken)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiExpiredPasswordPath, api.ChangeExpiredPassword, loginRateLimiter)
+	userGroup.DELETE("/:"+userIdParam, api.DeleteUser, defaultRateLimiter, echoMiddleware.CheckToken)
+	userGroup.GET("/:"+userIdParam+adapter.AuthiLoginsPath, api.GetLoginHistory, defaultRateLimiter, echoMiddleware.CheckToken)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiVerifyEmailPath, api.SendEmailVerification, defaultRateLimiter, echoMiddleware.CheckToken)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiVerifyEmailPath+adapter.AuthiConfirmPath, api.VerifyEmail, defaultRateLimiter)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiMfaPath+adapter.AuthiTotpPath, api.EnrollTotp, defaultRateLimiter, echoMiddleware.CheckToken)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiMfaPath+adapter.AuthiTotpPath+adapter.AuthiConfirmPath, api.ConfirmTotp, loginRateLimiter, echoMiddleware.CheckToken)
+	userGroup.DELETE("/:"+userIdParam+adapter.AuthiMfaPath+adapter.AuthiTotpPath, api.DisableTotp, loginRateLimiter, echoMiddleware.CheckToken)
+	userGroup.POST("/:"+userIdParam+adapter.AuthiMfaPath+adapter.AuthiRecoveryCodesPath, api.RegenerateRecoveryCodes, loginRateLimiter, echoMiddleware.CheckToken)
+
+	if config.Passkey.RpId != "" {
+		e.POST(adapter.AuthiLoginPath+adapter.AuthiPasskeyPath, api.BeginPasskeyLogin, loginRateLimiter)
+		e.POST(adapter.AuthiLoginPath+adapter.AuthiPasskeyPath+adapter.AuthiConfirmPath, api.LoginWithPasskey, loginRateLimiter)
+		userGroup.POST("/:"+userIdParam+adapter.AuthiPasskeyPath, api.BeginPasskeyRegistration, defaultRateLimiter, echoMiddleware.CheckToken)
+		userGroup.POST("/:"+userIdParam+adapter.AuthiPasskeyPath+adapter.AuthiConfirmPath, api.FinishPasskeyRegistration, loginRateLimiter, echoMiddleware.CheckToken)
+	}
+
+	if config.Admin.ApiKey != "" || config.Admin.RoleEnabled {
+		adminTokenParser := parser
+		if !config.Admin.RoleEnabled {
+			adminTokenParser = nil
+		}
+		adminApi := &AdminApi{facade}
+		adminGroup := e.Group(adapter.AuthiAdminPath, defaultRateLimiter, newAdminAuthMiddleware(config.Admin.ApiKey, adminTokenParser))
+		adminGroup.GET(adapter.AuthiAuditEventsPath, adminApi.GetAuditEvents)
+		adminGroup.GET(adapter.AuthiAuditEventsPath+adapter.AuthiExportPath, adminApi.ExportAuditEvents)
+		adminGroup.GET(adapter.AuthiUsersPath, adminApi.GetUsers)
+		adminGroup.GET(adapter.AuthiUsersPath+"/:"+userIdParam, adminApi.GetUser)
+		adminGroup.POST(adapter.AuthiUsersPath+"/:"+userIdParam+adapter.AuthiDisablePath, adminApi.DisableUser)
+		adminGroup.POST(adapter.AuthiUsersPath+"/:"+userIdParam+adapter.AuthiEnablePath, adminApi.EnableUser)
+		adminGroup.PUT(adapter.AuthiUsersPath+"/:"+userIdParam+adapter.AuthiStatusPath, adminApi.SetUserStatus)
+		adminGroup.POST(adapter.AuthiUsersPath+"/:"+userIdParam+adapter.AuthiLogoutPath, adminApi.RevokeSessions)
+		adminGroup.PUT(adapter.AuthiUsersPath+"/:"+userIdParam+adapter.AuthiPasswordPath, adminApi.ResetPassword)
+		adminGroup.DELETE(adapter.AuthiUsersPath+"/:"+userIdParam, adminApi.DeleteUser)
+	}
+
+	server := &Server{echo: e, dbConnection: dbConnection, address: fmt.Sprintf("%s:%d", config.Server.Address, config.Server.Port)}
+	if len(config.Webhook.Subscriptions) > 0 {
+		server.dispatcher = webhook.NewDispatcher(&config.Webhook, dbConnection)
+	}
+	if config.Metrics.Enabled {
+		metrics.RegisterPool(dbConnection)
+		if config.Metrics.Port == 0 {
+			e.GET(MetricsPath, echo.WrapHandler(metrics.Handler()))
+		} else {
+			server.metricsEcho = newEcho()
+			server.metricsEcho.GET(MetricsPath, echo.WrapHandler(metrics.Handler()))
+			server.metricsAddress = fmt.Sprintf("%s:%d", config.Server.Address, config.Metrics.Port)
+		}
+	}
+	return server, nil
+}
+
+func newEcho() *echo.Echo {
+	e := echo.New()
+	e.HideBanner = true
+	if log.GetLevel() < log.InfoLevel {
+		e.HidePort = true
+	}
+	return e
+}
+
+// Starts listening for requests and blocks until the server is shut down. If metrics have their own port, both listeners are started
+// and the first error of one of them is returned. Webhooks are delivered in the background while the server is running
+func (server *Server) Start() error {
+	if server.dispatcher != nil {
+		server.dispatcher.Start()
+	}
+	serverErr := make(chan error, 2)
+	go func() {
+		serverErr <- startEcho(server.echo, server.address)
+	}()
+	if server.metricsEcho != nil {
+		go func() {
+			serverErr <- startEcho(server.metricsEcho, server.metricsAddress)
+		}()
+	}
+	return <-serverErr
+}
+
+func startEcho(e *echo.Echo, address string) error {
+	if err := e.Start(address); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("error while running server on %s: %w", address, err)
+	}
+	return nil
+}
+
+// Stops accepting new requests and waits for running requests until ctx is done. The database connection is closed afterwards in any case
+func (server *Server) Shutdown(ctx context.Context) error {
+	err := server.echo.Shutdown(ctx)
+	if server.metricsEcho != nil {
+		err = errors.Join(err, server.metricsEcho.Shutdown(ctx))
+	}
+	if server.dispatcher != nil {
+		server.dispatcher.Stop(ctx)
+	}
+	server.dbConnection.Close()
+	if err != nil {
+		return fmt.Errorf("error while shutting down server: %w", err)
+	}
+	return nil
+}
+
+// Address the server is listening on or nil if it isn't started yet
+func (server *Server) ListenerAddr() net.Addr {
+	return server.echo.ListenerAddr()
+}
+
+// Address the metrics are served on or nil if they share the address of the server or it isn't started yet
+func (server *Server) MetricsListenerAddr() net.Addr {
+	if server.metricsEcho == nil {
+		return nil
+	}
+	return server.metricsEcho.ListenerAddr()
+}
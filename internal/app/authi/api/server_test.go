@@ -0,0 +1,118 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/core"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/BeanCodeDe/authi/pkg/parser"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func startTestServer(t *testing.T, dbConnection db.Connection) (*Server, string, chan error) {
+	serverConfig := config.Default()
+	serverConfig.Server.Address = "127.0.0.1"
+	serverConfig.Server.Port = 0
+	server, err := newServer(serverConfig, &parser.ParserMock{}, dbConnection, &core.CoreMock{})
+	assert.Nil(t, err)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- server.Start()
+	}()
+
+	assert.Eventually(t, func() bool { return server.ListenerAddr() != nil }, time.Second, 10*time.Millisecond)
+	return server, "http://" + server.ListenerAddr().String(), serverErr
+}
+
+func TestServer_StartAndShutdown(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	server, url, serverErr := startTestServer(t, dbConnection)
+
+	resp, err := http.Post(url+adapter.AuthiRootPath, echo.MIMEApplicationJSON, nil)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	err = server.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, <-serverErr)
+	assert.Equal(t, 1, len(dbConnection.CloseRecordArray))
+
+	_, err = http.Post(url+adapter.AuthiRootPath, echo.MIMEApplicationJSON, nil)
+	assert.NotNil(t, err)
+}
+
+func TestServer_ShutdownWaitsForRunningRequests(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	server, url, serverErr := startTestServer(t, dbConnection)
+	requestStarted := make(chan struct{})
+	server.echo.GET("/slow", func(context echo.Context) error {
+		close(requestStarted)
+		time.Sleep(200 * time.Millisecond)
+		return context.NoContent(http.StatusOK)
+	})
+
+	statusCode := make(chan int, 1)
+	go func() {
+		resp, err := http.Get(url + "/slow")
+		if err != nil {
+			statusCode <- 0
+			return
+		}
+		resp.Body.Close()
+		statusCode <- resp.StatusCode
+	}()
+	<-requestStarted
+
+	err := server.Shutdown(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, <-statusCode)
+	assert.Nil(t, <-serverErr)
+	assert.Equal(t, 1, len(dbConnection.CloseRecordArray))
+}
+
+func TestServer_ShutdownTimeout(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	server, url, serverErr := startTestServer(t, dbConnection)
+	requestStarted := make(chan struct{})
+	releaseRequest := make(chan struct{})
+	server.echo.GET("/slow", func(context echo.Context) error {
+		close(requestStarted)
+		<-releaseRequest
+		return context.NoContent(http.StatusOK)
+	})
+
+	go func() {
+		if resp, err := http.Get(url + "/slow"); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-requestStarted
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := server.Shutdown(ctx)
+	close(releaseRequest)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Nil(t, <-serverErr)
+	assert.Equal(t, 1, len(dbConnection.CloseRecordArray))
+}
+
+func TestServer_StartError(t *testing.T) {
+	serverConfig := config.Default()
+	serverConfig.Server.Address = "127.0.0.1"
+	serverConfig.Server.Port = -1
+	server, err := newServer(serverConfig, &parser.ParserMock{}, &db.DBMock{}, &core.CoreMock{})
+	assert.Nil(t, err)
+
+	err = server.Start()
+	assert.ErrorContains(t, err, "error while running server")
+}
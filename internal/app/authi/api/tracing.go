@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/tracing"
+	"github.com/labstack/echo/v4"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Starts a span for each request. A traceparent header of the caller is used as parent of the span
+func tracingMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(context echo.Context) error {
+		request := context.Request()
+		route := routeOf(context)
+		ctx := otel.GetTextMapPropagator().Extract(request.Context(), propagation.HeaderCarrier(request.Header))
+		ctx, span := tracing.Tracer().Start(ctx, request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(request.Method), semconv.HTTPRoute(route)),
+		)
+		defer span.End()
+		context.SetRequest(request.WithContext(ctx))
+
+		err := next(context)
+		status := responseStatus(context, err)
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if err != nil {
+			span.RecordError(err)
+		}
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
+	}
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func setTestTracerProvider(t *testing.T) *tracetest.SpanRecorder {
+	spanRecorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return spanRecorder
+}
+
+func TestTracingMiddleware_ContinuesTrace(t *testing.T) {
+	spanRecorder := setTestTracerProvider(t)
+	server, url, serverErr := startTestServer(t, &db.DBMock{})
+
+	request, err := http.NewRequest(http.MethodPost, url+adapter.AuthiRootPath, nil)
+	assert.Nil(t, err)
+	request.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(request)
+	assert.Nil(t, err)
+	resp.Body.Close()
+	assert.Nil(t, server.Shutdown(context.Background()))
+	assert.Nil(t, <-serverErr)
+
+	spans := spanRecorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "POST "+adapter.AuthiRootPath, spans[0].Name())
+	assert.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusCreated))
+}
+
+func TestTracingMiddleware_ServerError(t *testing.T) {
+	spanRecorder := setTestTracerProvider(t)
+	e := echo.New()
+	e.Use(tracingMiddleware)
+	e.GET("/broken", func(context echo.Context) error {
+		return echo.ErrInternalServerError
+	})
+
+	e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/broken", nil))
+
+	spans := spanRecorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "GET /broken", spans[0].Name())
+	assert.Equal(t, "Error", spans[0].Status().Code.String())
+	assert.Contains(t, spans[0].Attributes(), attribute.Int("http.response.status_code", http.StatusInternalServerError))
+}
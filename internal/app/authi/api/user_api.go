@@ -1,20 +1,16 @@
 package api
 
 import (
-	"fmt"
+	"errors"
 	"net/http"
-	"time"
+	"strings"
 
 	"github.com/BeanCodeDe/authi/internal/app/authi/core"
-	"github.com/BeanCodeDe/authi/internal/app/authi/util"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
 	"github.com/BeanCodeDe/authi/pkg/adapter"
-	echoMiddleware "github.com/BeanCodeDe/authi/pkg/middleware"
-	"github.com/BeanCodeDe/authi/pkg/parser"
 	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
-	"github.com/labstack/echo/v4/middleware"
 	log "github.com/sirupsen/logrus"
-	"golang.org/x/crypto/acme/autocert"
 	"gopkg.in/go-playground/validator.v9"
 )
 
@@ -40,66 +36,6 @@ func (cv *CustomValidator) Validate(i interface{}) error {
 	return cv.validator.Struct(i)
 }
 
-func NewUserApi(parser parser.Parser) (*UserApi, error) {
-	userFacade, err := core.NewUserFacade()
-	if err != nil {
-		return nil, fmt.Errorf("error while initializing user facade: %v", err)
-	}
-
-	echoMiddleware := echoMiddleware.NewEchoMiddleware(parser)
-	api := &UserApi{userFacade}
-
-	e := echo.New()
-	e.HideBanner = true
-	if log.GetLevel() < log.InfoLevel {
-		e.HidePort = true
-	}
-	e.AutoTLSManager.Cache = autocert.DirCache("/var/www/.cache")
-	e.Use(middleware.CORS(), setLoggerMiddleware, middleware.Recover())
-
-	config := middleware.RateLimiterConfig{
-		Skipper: middleware.DefaultSkipper,
-		Store: middleware.NewRateLimiterMemoryStoreWithConfig(
-			middleware.RateLimiterMemoryStoreConfig{Rate: 10, Burst: 30, ExpiresIn: 1 * time.Minute},
-		),
-		IdentifierExtractor: func(ctx echo.Context) (string, error) {
-			userId := ctx.Param(userIdParam)
-			if userId != "" {
-				return userId, nil
-			}
-			return ctx.RealIP(), nil
-		},
-		ErrorHandler: func(context echo.Context, err error) error {
-			return context.JSON(http.StatusForbidden, nil)
-		},
-		DenyHandler: func(context echo.Context, identifier string, err error) error {
-			return context.JSON(http.StatusTooManyRequests, nil)
-		},
-	}
-
-	e.Use(middleware.RateLimiterWithConfig(config))
-
-	e.Validator = &CustomValidator{validator: validator.New()}
-
-	userGroup := e.Group(adapter.AuthiRootPath)
-	userGroup.POST("", api.CreateUserId)
-	userGroup.POST("/:"+userIdParam+adapter.AuthiLoginPath, api.LoginUser)
-	userGroup.PUT("/:"+userIdParam, api.CreateUser)
-	userGroup.PATCH("/:"+userIdParam+adapter.AuthiRefreshPath, api.RefreshToken, echoMiddleware.CheckToken)
-	userGroup.PATCH("/:"+userIdParam, api.UpdatePassword, echoMiddleware.CheckToken)
-	userGroup.DELETE("/:"+userIdParam, api.DeleteUser, echoMiddleware.CheckToken)
-
-	address := util.GetEnvWithFallback("ADDRESS", "0.0.0.0")
-	port, err := util.GetEnvIntWithFallback("PORT", 1203)
-	if err != nil {
-		return nil, fmt.Errorf("error while loading port from environment variable: %w", err)
-	}
-	url := fmt.Sprintf("%s:%d", address, port)
-	e.Logger.Fatal(e.Start(url))
-
-	return api, nil
-}
-
 func (userApi *UserApi) CreateUserId(context echo.Context) error {
 	logger := context.Get(loggerKey).(*log.Entry)
 	logger.Debug("Create User Id")
@@ -109,14 +45,24 @@ func (userApi *UserApi) CreateUserId(context echo.Context) error {
 func (userApi *UserApi) CreateUser(context echo.Context) error {
 	logger := context.Get(loggerKey).(*log.Entry)
 	logger.Debugf("Create User")
-	userId, authenticate, err := bindAuthenticate(context)
+	userId, createUser, err := bindUserBody[adapter.CreateUserDTO](context)
 	if err != nil {
 		return err
 	}
 
-	if err := userApi.facade.CreateUser(userId, authenticate.Password, false); err != nil {
+	identifiers := &core.UserIdentifiers{Username: createUser.Username, Email: createUser.Email}
+	if err := userApi.facade.CreateUser(context.Request().Context(), userId, createUser.Password, identifiers, false); err != nil {
 		logger.Warnf("Error while creating user: %v", err)
-		return echo.ErrUnauthorized
+		switch {
+		case errors.Is(err, core.ErrIdentifierTaken):
+			return echo.ErrConflict
+		case errors.Is(err, core.ErrInvalidIdentifier):
+			return echo.ErrBadRequest
+		case errors.Is(err, core.ErrPasswordPolicy):
+			return passwordPolicyErrorResponse(err)
+		default:
+			return echo.ErrUnauthorized
+		}
 	}
 
 	logger.Debugf("Created user with id %s", userId)
@@ -131,9 +77,18 @@ func (userApi *UserApi) LoginUser(context echo.Context) error {
 		return err
 	}
 
-	token, err := userApi.facade.LoginUser(userId, authenticate.Password)
+	clientInfo := &core.ClientInfo{IP: context.RealIP(), UserAgent: context.Request().UserAgent()}
+	token, err := userApi.facade.LoginUser(context.Request().Context(), userId, authenticate.Password, clientInfo)
+	var mfaErr *core.MfaRequiredError
+	if errors.As(err, &mfaErr) {
+		logger.Debugf("Login of user %s requires mfa", userId)
+		return context.JSON(http.StatusAccepted, mfaChallenge(mfaErr))
+	}
 	if err != nil {
 		logger.Warnf("Error while logging in user %v: %v", userId, err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
 		return echo.ErrUnauthorized
 	}
 
@@ -141,6 +96,39 @@ func (userApi *UserApi) LoginUser(context echo.Context) error {
 	return context.JSON(http.StatusOK, token)
 }
 
+// Logs in the user with username or email instead of the user id
+func (userApi *UserApi) LoginUserByIdentifier(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Login some user by identifier")
+	login := new(adapter.LoginDTO)
+	if err := context.Bind(login); err != nil {
+		logger.Warnf("Could not bind login, %v", err)
+		return echo.ErrBadRequest
+	}
+	if err := context.Validate(login); err != nil {
+		logger.Warnf("Could not validate login, %v", err)
+		return echo.ErrBadRequest
+	}
+
+	clientInfo := &core.ClientInfo{IP: context.RealIP(), UserAgent: context.Request().UserAgent()}
+	token, err := userApi.facade.LoginUserByIdentifier(context.Request().Context(), login.Identifier, login.Password, clientInfo)
+	var mfaErr *core.MfaRequiredError
+	if errors.As(err, &mfaErr) {
+		logger.Debugf("Login by identifier requires mfa")
+		return context.JSON(http.StatusAccepted, mfaChallenge(mfaErr))
+	}
+	if err != nil {
+		logger.Warnf("Error while logging in user by identifier: %v", err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
+		return echo.ErrUnauthorized
+	}
+
+	logger.Debugf("Logged in user by identifier")
+	return context.JSON(http.StatusOK, token)
+}
+
 func (userApi *UserApi) RefreshToken(context echo.Context) error {
 	logger := context.Get(loggerKey).(*log.Entry)
 	logger.Debugf("Refresh token")
@@ -158,20 +146,24 @@ func (userApi *UserApi) RefreshToken(context echo.Context) error {
 
 	refreshToken := context.Request().Header.Get(adapter.RefreshTokenHeaderName)
 
-	token, err := userApi.facade.RefreshToken(userId, refreshToken)
+	token, err := userApi.facade.RefreshToken(context.Request().Context(), userId, refreshToken)
 	if err != nil {
 		logger.Errorf("Something went wrong while creating Token: %v", err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
 		return echo.ErrUnauthorized
 	}
 	logger.Debugf("Refresh token for user %s updated", userId)
 	return context.JSON(http.StatusOK, token)
 }
 
+// Updates the password with the current password of the user and answers with new tokens, because all other sessions are revoked
 func (userApi *UserApi) UpdatePassword(context echo.Context) error {
 	logger := context.Get(loggerKey).(*log.Entry)
 	logger.Debugf("Update password")
 
-	userId, authenticate, err := bindAuthenticate(context)
+	userId, updatePassword, err := bindUserBody[adapter.UpdatePasswordDTO](context)
 	if err != nil {
 		return err
 	}
@@ -181,13 +173,51 @@ func (userApi *UserApi) UpdatePassword(context echo.Context) error {
 		return err
 	}
 
-	err = userApi.facade.UpdatePassword(userId, authenticate.Password)
+	claims, _ := context.Get(adapter.ClaimName).(adapter.Claims)
+	ctx := core.ContextWithAmr(context.Request().Context(), claims.Amr)
+	token, err := userApi.facade.UpdatePassword(ctx, userId, updatePassword.CurrentPassword, updatePassword.NewPassword)
 	if err != nil {
-		logger.Errorf("Something went wrong while updating password: %v", err)
+		logger.Warnf("Something went wrong while updating password: %v", err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
+		if errors.Is(err, core.ErrPasswordPolicy) {
+			return passwordPolicyErrorResponse(err)
+		}
 		return echo.ErrUnauthorized
 	}
 	logger.Debugf("Password for user %s updated", userId)
-	return context.NoContent(http.StatusNoContent)
+	return context.JSON(http.StatusOK, token)
+}
+
+// Sets a new password for a user whose password expired. Instead of an access token the password change token of the login is sent as bearer token
+func (userApi *UserApi) ChangeExpiredPassword(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Change expired password")
+
+	userId, updatePassword, err := bindUserBody[adapter.UpdatePasswordDTO](context)
+	if err != nil {
+		return err
+	}
+	changeToken, found := strings.CutPrefix(context.Request().Header.Get(adapter.AuthorizationHeaderName), "Bearer ")
+	if !found {
+		logger.Warnf("No password change token found")
+		return echo.ErrUnauthorized
+	}
+
+	token, err := userApi.facade.ChangeExpiredPassword(context.Request().Context(), userId, changeToken, updatePassword.CurrentPassword, updatePassword.NewPassword)
+	if err != nil {
+		logger.Warnf("Something went wrong while changing expired password: %v", err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
+		if errors.Is(err, core.ErrPasswordPolicy) {
+			return passwordPolicyErrorResponse(err)
+		}
+		return echo.ErrUnauthorized
+	}
+	logger.Debugf("Expired password for user %s changed", userId)
+	return context.JSON(http.StatusOK, token)
 }
 
 func (userApi *UserApi) DeleteUser(context echo.Context) error {
@@ -205,7 +235,7 @@ func (userApi *UserApi) DeleteUser(context echo.Context) error {
 		return err
 	}
 
-	err = userApi.facade.DeleteUser(userId)
+	err = userApi.facade.DeleteUser(context.Request().Context(), userId)
 	if err != nil {
 		logger.Errorf("Something went wrong while deleting user: %v", err)
 		return echo.ErrUnauthorized
@@ -214,19 +244,422 @@ func (userApi *UserApi) DeleteUser(context echo.Context) error {
 	return context.NoContent(http.StatusNoContent)
 }
 
-func setLoggerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		correlationId := c.Request().Header.Get(CorrelationIdHeader)
-		_, err := uuid.Parse(correlationId)
-		if err != nil {
-			log.Warn("Correlation id is not from format uuid. Set default correlation id.")
-			correlationId = "WRONG FORMAT"
+func (userApi *UserApi) GetLoginHistory(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Get login history")
+
+	userId, err := uuid.Parse(context.Param(userIdParam))
+	if err != nil {
+		logger.Warnf("Error while binding userId: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	loginHistory, err := userApi.facade.GetLoginHistory(context.Request().Context(), userId)
+	if err != nil {
+		logger.Errorf("Something went wrong while loading login history: %v", err)
+		return echo.ErrInternalServerError
+	}
+	logger.Debugf("Login history for user %s loaded", userId)
+	return context.JSON(http.StatusOK, loginHistory)
+}
+
+// Sends a mail with a verification token to the email of the user
+func (userApi *UserApi) SendEmailVerification(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Send email verification")
+
+	userId, err := uuid.Parse(context.Param(userIdParam))
+	if err != nil {
+		logger.Warnf("Error while binding userId: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := userApi.facade.SendEmailVerification(context.Request().Context(), userId); err != nil {
+		if errors.Is(err, core.ErrNoEmail) || errors.Is(err, core.ErrEmailAlreadyVerified) {
+			logger.Warnf("Email of user %s can't be verified: %v", userId, err)
+			return echo.ErrConflict
 		}
-		logger := log.WithFields(log.Fields{
-			CorrelationIdHeader: correlationId,
-		})
+		logger.Errorf("Something went wrong while sending email verification: %v", err)
+		return echo.ErrInternalServerError
+	}
+	logger.Debugf("Sent email verification for user %s", userId)
+	return context.NoContent(http.StatusNoContent)
+}
 
-		c.Set(loggerKey, logger)
+// Confirms the email of the user with the token of the verification mail. No access token is needed, so the mail can be opened on any device
+func (userApi *UserApi) VerifyEmail(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Verify email")
+	userId, verifyEmail, err := bindUserBody[adapter.VerifyEmailDTO](context)
+	if err != nil {
+		return err
+	}
+
+	if err := userApi.facade.VerifyEmail(context.Request().Context(), userId, verifyEmail.Token); err != nil {
+		if errors.Is(err, core.ErrInvalidVerificationToken) {
+			logger.Warnf("Invalid verification token for user %s: %v", userId, err)
+			return echo.ErrBadRequest
+		}
+		logger.Errorf("Something went wrong while verifying email: %v", err)
+		return echo.ErrInternalServerError
+	}
+	logger.Debugf("Verified email of user %s", userId)
+	return context.NoContent(http.StatusNoContent)
+}
+
+// Completes a login that was answered with a mfa challenge with a code of the authenticator app
+func (userApi *UserApi) LoginWithMfa(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Login some user with mfa")
+	mfaLogin := new(adapter.MfaLoginDTO)
+	if err := context.Bind(mfaLogin); err != nil {
+		logger.Warnf("Could not bind mfa login, %v", err)
+		return echo.ErrBadRequest
+	}
+	if err := context.Validate(mfaLogin); err != nil {
+		logger.Warnf("Could not validate mfa login, %v", err)
+		return echo.ErrBadRequest
+	}
+
+	clientInfo := &core.ClientInfo{IP: context.RealIP(), UserAgent: context.Request().UserAgent()}
+	token, err := userApi.facade.LoginWithMfa(context.Request().Context(), mfaLogin.MfaChallengeToken, mfaLogin.Code, clientInfo)
+	if err != nil {
+		logger.Warnf("Error while logging in user with mfa: %v", err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
+		return echo.ErrUnauthorized
+	}
+
+	logger.Debugf("Logged in user with mfa")
+	return context.JSON(http.StatusOK, token)
+}
+
+// Generates a new totp secret. Mfa is only enabled after a code of the secret was confirmed
+func (userApi *UserApi) EnrollTotp(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Enroll totp")
+
+	userId, err := uuid.Parse(context.Param(userIdParam))
+	if err != nil {
+		logger.Warnf("Error while binding userId: %v", err)
+		return echo.ErrBadRequest
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	enrollment, err := userApi.facade.EnrollTotp(context.Request().Context(), userId)
+	if err != nil {
+		if errors.Is(err, core.ErrMfaAlreadyEnabled) {
+			logger.Warnf("Totp of user %s can't be enrolled: %v", userId, err)
+			return echo.ErrConflict
+		}
+		logger.Errorf("Something went wrong while enrolling totp: %v", err)
+		return echo.ErrInternalServerError
+	}
+	logger.Debugf("Enrolled totp for user %s", userId)
+	return context.JSON(http.StatusOK, enrollment)
+}
+
+// Enables mfa with a code of the enrolled totp secret and responds with the recovery codes
+func (userApi *UserApi) ConfirmTotp(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Confirm totp")
+
+	userId, mfaCode, err := bindUserBody[adapter.MfaCodeDTO](context)
+	if err != nil {
+		return err
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes, err := userApi.facade.ConfirmTotp(context.Request().Context(), userId, mfaCode.Code)
+	if err != nil {
+		return mfaErrorResponse(logger, userId, err)
+	}
+	logger.Debugf("Confirmed totp for user %s", userId)
+	return context.JSON(http.StatusOK, recoveryCodes)
+}
+
+// Disables mfa. A current code is required besides the access token
+func (userApi *UserApi) DisableTotp(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Disable totp")
+
+	userId, mfaCode, err := bindUserBody[adapter.MfaCodeDTO](context)
+	if err != nil {
+		return err
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	if err := userApi.facade.DisableTotp(context.Request().Context(), userId, mfaCode.Code); err != nil {
+		return mfaErrorResponse(logger, userId, err)
+	}
+	logger.Debugf("Disabled totp for user %s", userId)
+	return context.NoContent(http.StatusNoContent)
+}
+
+// Replaces the recovery codes. A current code is required besides the access token
+func (userApi *UserApi) RegenerateRecoveryCodes(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Regenerate recovery codes")
+
+	userId, mfaCode, err := bindUserBody[adapter.MfaCodeDTO](context)
+	if err != nil {
+		return err
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	recoveryCodes, err := userApi.facade.RegenerateRecoveryCodes(context.Request().Context(), userId, mfaCode.Code)
+	if err != nil {
+		return mfaErrorResponse(logger, userId, err)
+	}
+	logger.Debugf("Regenerated recovery codes for user %s", userId)
+	return context.JSON(http.StatusOK, recoveryCodes)
+}
+
+// Maps errors of changing the mfa of a user to a response
+func mfaErrorResponse(logger *log.Entry, userId uuid.UUID, err error) error {
+	switch {
+	case errors.Is(err, core.ErrInvalidMfaCode):
+		logger.Warnf("Invalid mfa code of user %s: %v", userId, err)
+		return echo.ErrBadRequest
+	case errors.Is(err, core.ErrMfaAlreadyEnabled), errors.Is(err, core.ErrMfaNotEnabled), errors.Is(err, core.ErrMfaNotEnrolled):
+		logger.Warnf("Mfa of user %s can't be changed: %v", userId, err)
+		return echo.ErrConflict
+	case errors.Is(err, core.ErrUserLocked):
+		logger.Warnf("User %s is locked: %v", userId, err)
+		return echo.NewHTTPError(http.StatusLocked)
+	default:
+		logger.Errorf("Something went wrong while changing mfa: %v", err)
+		return echo.ErrInternalServerError
+	}
+}
+
+// Response of a login that has to be completed with a second factor
+func mfaChallenge(mfaErr *core.MfaRequiredError) *adapter.MfaChallengeDTO {
+	return &adapter.MfaChallengeDTO{MfaChallengeToken: mfaErr.MfaChallengeToken, Methods: []string{adapter.MfaMethodTotp}, ExpiresIn: int(mfaErr.ExpiresAt)}
+}
+
+// Answers with the options the browser needs to create a passkey for the user. The current password is required besides the access token
+func (userApi *UserApi) BeginPasskeyRegistration(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Begin passkey registration")
+
+	userId, authenticate, err := bindAuthenticate(context)
+	if err != nil {
+		return err
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	claims, _ := context.Get(adapter.ClaimName).(adapter.Claims)
+	ctx := core.ContextWithAmr(context.Request().Context(), claims.Amr)
+	options, err := userApi.facade.BeginPasskeyRegistration(ctx, userId, authenticate.Password)
+	if err != nil {
+		logger.Warnf("Something went wrong while beginning passkey registration: %v", err)
+		if errors.Is(err, core.ErrUserNotFound) {
+			return echo.ErrNotFound
+		}
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
+		if errors.Is(err, core.ErrMfaRequired) {
+			return echo.ErrForbidden
+		}
+		return echo.ErrUnauthorized
+	}
+	logger.Debugf("Began passkey registration for user %s", userId)
+	return context.JSON(http.StatusOK, options)
+}
+
+// Stores the passkey the browser created with the options of the registration
+func (userApi *UserApi) FinishPasskeyRegistration(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Finish passkey registration")
+
+	userId, passkeyCredential, err := bindUserBody[adapter.PasskeyCredentialDTO](context)
+	if err != nil {
+		return err
+	}
+
+	err = checkUserId(context, userId)
+	if err != nil {
+		return err
+	}
+
+	err = userApi.facade.FinishPasskeyRegistration(context.Request().Context(), userId, passkeyCredential.PasskeyChallengeToken, passkeyCredential.Credential)
+	if err != nil {
+		switch {
+		case errors.Is(err, core.ErrInvalidPasskeyChallenge), errors.Is(err, core.ErrInvalidPasskey):
+			logger.Warnf("Passkey of user %s can't be registered: %v", userId, err)
+			return echo.ErrBadRequest
+		case errors.Is(err, core.ErrPasskeyAlreadyRegistered):
+			logger.Warnf("Passkey of user %s is already registered: %v", userId, err)
+			return echo.ErrConflict
+		default:
+			logger.Errorf("Something went wrong while finishing passkey registration: %v", err)
+			return echo.ErrInternalServerError
+		}
+	}
+	logger.Debugf("Registered passkey for user %s", userId)
+	return context.NoContent(http.StatusNoContent)
+}
+
+// Answers with the options the browser needs to sign in with any passkey of this relying party
+func (userApi *UserApi) BeginPasskeyLogin(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Begin passkey login")
+
+	options, err := userApi.facade.BeginPasskeyLogin(context.Request().Context())
+	if err != nil {
+		logger.Errorf("Something went wrong while beginning passkey login: %v", err)
+		return echo.ErrInternalServerError
+	}
+	logger.Debugf("Began passkey login")
+	return context.JSON(http.StatusOK, options)
+}
+
+// Logs in the owner of the passkey that signed the challenge of the login options
+func (userApi *UserApi) LoginWithPasskey(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Login some user with passkey")
+	passkeyCredential := new(adapter.PasskeyCredentialDTO)
+	if err := context.Bind(passkeyCredential); err != nil {
+		logger.Warnf("Could not bind passkey login, %v", err)
+		return echo.ErrBadRequest
+	}
+	if err := context.Validate(passkeyCredential); err != nil {
+		logger.Warnf("Could not validate passkey login, %v", err)
+		return echo.ErrBadRequest
+	}
+
+	clientInfo := &core.ClientInfo{IP: context.RealIP(), UserAgent: context.Request().UserAgent()}
+	token, err := userApi.facade.LoginWithPasskey(context.Request().Context(), passkeyCredential.PasskeyChallengeToken, passkeyCredential.Credential, clientInfo)
+	if err != nil {
+		logger.Warnf("Error while logging in user with passkey: %v", err)
+		if statusErr := userStatusErrorResponse(err); statusErr != nil {
+			return statusErr
+		}
+		return echo.ErrUnauthorized
+	}
+
+	logger.Debugf("Logged in user with passkey")
+	return context.JSON(http.StatusOK, token)
+}
+
+// Stores ip and user agent of the caller in the context of the request, so the facade can record them in audit events
+func setClientInfoMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		clientInfo := &core.ClientInfo{IP: c.RealIP(), UserAgent: c.Request().UserAgent()}
+		c.SetRequest(c.Request().WithContext(core.ContextWithClientInfo(c.Request().Context(), clientInfo)))
 		return next(c)
 	}
 }
+
+// Stores a logger with the correlation id of the request in the echo context
+func setLoggerMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Set(loggerKey, logging.FromContext(c.Request().Context()))
+		return next(c)
+	}
+}
+
+// Maps errors of users, that aren't allowed to log in because of their status or an expired password, to a response. Returns nil for other errors
+func userStatusErrorResponse(err error) error {
+	var expiredErr *core.PasswordExpiredError
+	switch {
+	case errors.As(err, &expiredErr):
+		return echo.NewHTTPError(http.StatusForbidden, &adapter.PasswordExpiredDTO{Message: core.ErrPasswordExpired.Error(), PasswordChangeToken: expiredErr.PasswordChangeToken})
+	case errors.Is(err, core.ErrUserLocked):
+		return echo.NewHTTPError(http.StatusLocked)
+	case errors.Is(err, core.ErrUserDisabled), errors.Is(err, core.ErrUserPending):
+		return echo.ErrForbidden
+	default:
+		return nil
+	}
+}
+
+// Answers with the codes of the violated rules, so clients can explain what is wrong with the new password
+func passwordPolicyErrorResponse(err error) error {
+	var policyErr *core.PasswordPolicyError
+	if !errors.As(err, &policyErr) {
+		return echo.ErrBadRequest
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, &adapter.PasswordPolicyErrorDTO{Message: core.ErrPasswordPolicy.Error(), Violations: policyErr.Violations})
+}
+
+// Requests a mail to reset the password. The answer is always accepted, so it doesn't reveal whether an account exists
+func (userApi *UserApi) RequestPasswordReset(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Request password reset")
+	passwordReset := new(adapter.PasswordResetDTO)
+	if err := context.Bind(passwordReset); err != nil {
+		logger.Warnf("Could not bind password reset, %v", err)
+		return echo.ErrBadRequest
+	}
+	if err := context.Validate(passwordReset); err != nil {
+		logger.Warnf("Could not validate password reset, %v", err)
+		return echo.ErrBadRequest
+	}
+
+	userApi.facade.RequestPasswordReset(context.Request().Context(), passwordReset.Identifier)
+	return context.NoContent(http.StatusAccepted)
+}
+
+// Sets a new password with the token of a password reset mail. No access token is needed
+func (userApi *UserApi) ConfirmPasswordReset(context echo.Context) error {
+	logger := context.Get(loggerKey).(*log.Entry)
+	logger.Debugf("Confirm password reset")
+	confirmPasswordReset := new(adapter.ConfirmPasswordResetDTO)
+	if err := context.Bind(confirmPasswordReset); err != nil {
+		logger.Warnf("Could not bind password reset confirmation, %v", err)
+		return echo.ErrBadRequest
+	}
+	if err := context.Validate(confirmPasswordReset); err != nil {
+		logger.Warnf("Could not validate password reset confirmation, %v", err)
+		return echo.ErrBadRequest
+	}
+
+	if err := userApi.facade.ConfirmPasswordReset(context.Request().Context(), confirmPasswordReset.Token, confirmPasswordReset.Password); err != nil {
+		if errors.Is(err, core.ErrInvalidResetToken) {
+			logger.Warnf("Invalid password reset token: %v", err)
+			return echo.ErrBadRequest
+		}
+		if errors.Is(err, core.ErrPasswordPolicy) {
+			logger.Warnf("New password violates policy: %v", err)
+			return passwordPolicyErrorResponse(err)
+		}
+		logger.Errorf("Something went wrong while resetting password: %v", err)
+		return echo.ErrInternalServerError
+	}
+	logger.Debugf("Reset password")
+	return context.NoContent(http.StatusNoContent)
+}
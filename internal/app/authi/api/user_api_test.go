@@ -1,13 +1,16 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/BeanCodeDe/authi/internal/app/authi/core"
 	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -90,6 +93,139 @@ func TestCreateUser_CreateUser_InternalServerError(t *testing.T) {
 	assert.Equal(t, false, facade.CreateUserRecordArray[0].InitUser)
 }
 
+func TestCreateUser_WithIdentifiers(t *testing.T) {
+	for body, expectedErr := range map[string]error{
+		`{"password":"somePassword","username":"max","email":"max@example.org"}`: nil,
+		`{"password":"somePassword","username":"max@example.org"}`:               echo.ErrBadRequest,
+		`{"password":"somePassword","email":"max"}`:                              echo.ErrBadRequest,
+	} {
+		facade := &core.CoreMock{CreateUserResponseArray: []*core.ErrorResponse{{Err: nil}}}
+		userApi := &UserApi{facade}
+		// Setup
+		e := echo.New()
+		e.Validator = &CustomValidator{validator: validator.New()}
+		req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.Set(loggerKey, log.WithField("Test", t.Name()))
+		c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam)
+		c.SetParamNames(userIdParam)
+		c.SetParamValues(userId.String())
+		// Exec
+		err := userApi.CreateUser(c)
+		// Assertions
+		assert.Equal(t, expectedErr, err, body)
+		if expectedErr == nil {
+			assert.Equal(t, &core.UserIdentifiers{Username: "max", Email: "max@example.org"}, facade.CreateUserRecordArray[0].Identifiers)
+		} else {
+			assert.Equal(t, 0, len(facade.CreateUserRecordArray))
+		}
+	}
+}
+
+func TestCreateUser_CreateUser_IdentifierTaken(t *testing.T) {
+	facade := &core.CoreMock{CreateUserResponseArray: []*core.ErrorResponse{{Err: fmt.Errorf("some error: %w", core.ErrIdentifierTaken)}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, strings.NewReader(`{"password":"somePassword","username":"max"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.CreateUser(c)
+	// Assertions
+	assert.Equal(t, echo.ErrConflict, err)
+}
+
+func TestCreateUser_CreateUser_PasswordPolicy(t *testing.T) {
+	facade := &core.CoreMock{CreateUserResponseArray: []*core.ErrorResponse{{Err: &core.PasswordPolicyError{Violations: []string{adapter.PasswordTooShort}}}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, strings.NewReader(`{"password":"short"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.CreateUser(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, &adapter.PasswordPolicyErrorDTO{Message: core.ErrPasswordPolicy.Error(), Violations: []string{adapter.PasswordTooShort}}), err)
+}
+
+// LoginUserByIdentifier Tests
+
+func TestLoginUserByIdentifier_Successfully(t *testing.T) {
+	facade := &core.CoreMock{LoginUserByIdentifierResponseArray: successfullyTokenResponse}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiLoginPath, strings.NewReader(`{"identifier":"Max@Example.org","password":"somePassword"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiLoginPath)
+	// Exec
+	err := userApi.LoginUserByIdentifier(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, len(facade.LoginUserByIdentifierRecordArray))
+	assert.Equal(t, 0, len(facade.LoginUserRecordArray))
+	assert.Equal(t, "Max@Example.org", facade.LoginUserByIdentifierRecordArray[0].Identifier)
+	assert.Equal(t, "somePassword", facade.LoginUserByIdentifierRecordArray[0].Password)
+}
+
+func TestLoginUserByIdentifier_WithoutIdentifier(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiLoginPath, strings.NewReader(authenticationUserJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiLoginPath)
+	// Exec
+	err := userApi.LoginUserByIdentifier(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.LoginUserByIdentifierRecordArray))
+}
+
+func TestLoginUserByIdentifier_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{LoginUserByIdentifierResponseArray: errorTokenResponse}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiLoginPath, strings.NewReader(`{"identifier":"max","password":"wrongPassword"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiLoginPath)
+	// Exec
+	err := userApi.LoginUserByIdentifier(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+}
+
 // LoginUser Tests
 
 func TestLoginUser_Successfully(t *testing.T) {
@@ -119,6 +255,7 @@ func TestLoginUser_Successfully(t *testing.T) {
 	assert.Equal(t, 0, len(facade.DeleteUserRecordArray))
 	assert.Equal(t, userId, facade.LoginUserRecordArray[0].UserId)
 	assert.Equal(t, password, facade.LoginUserRecordArray[0].Password)
+	assert.Equal(t, "192.0.2.1", facade.LoginUserRecordArray[0].ClientInfo.IP)
 	assert.Equal(t, "{\"access_token\":\"some_access_token\",\"expires_in\":1,\"refresh_token\":\"some_refresh_token\",\"refresh_expires_in\":2}\n", rec.Body.String())
 
 }
@@ -152,8 +289,72 @@ func TestLoginUser__LoginUser_ErrUnauthorized(t *testing.T) {
 
 }
 
+func TestLoginUser__LoginUser_ErrLocked(t *testing.T) {
+	facade := &core.CoreMock{LoginUserResponseArray: []*core.AuthenticateResponse{{Err: fmt.Errorf("%w until tomorrow", core.ErrUserLocked)}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.LoginUser(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusLocked), err)
+	assert.Equal(t, 1, len(facade.LoginUserRecordArray))
+	assert.Equal(t, userId, facade.LoginUserRecordArray[0].UserId)
+}
+
 // RefreshToken Tests
 
+func TestLoginUser__LoginUser_ErrDisabled(t *testing.T) {
+	facade := &core.CoreMock{LoginUserResponseArray: []*core.AuthenticateResponse{{Err: fmt.Errorf("user is not allowed to log in: %w", core.ErrUserDisabled)}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.LoginUser(c)
+	// Assertions
+	assert.Equal(t, echo.ErrForbidden, err)
+	assert.Equal(t, 1, len(facade.LoginUserRecordArray))
+}
+
+func TestLoginUser__LoginUser_PasswordExpired(t *testing.T) {
+	facade := &core.CoreMock{LoginUserResponseArray: []*core.AuthenticateResponse{{Err: &core.PasswordExpiredError{PasswordChangeToken: "some_change_token"}}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.LoginUser(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusForbidden, &adapter.PasswordExpiredDTO{Message: core.ErrPasswordExpired.Error(), PasswordChangeToken: "some_change_token"}), err)
+	assert.Equal(t, 1, len(facade.LoginUserRecordArray))
+}
+
 func TestRefreshToken_Successfully(t *testing.T) {
 	facade := &core.CoreMock{RefreshTokenResponseArray: successfullyTokenResponse}
 	userApi := &UserApi{facade}
@@ -184,6 +385,28 @@ func TestRefreshToken_Successfully(t *testing.T) {
 
 }
 
+func TestRefreshToken_RefreshToken_ErrPending(t *testing.T) {
+	facade := &core.CoreMock{RefreshTokenResponseArray: []*core.AuthenticateResponse{{Err: fmt.Errorf("user is not allowed to log in: %w", core.ErrUserPending)}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPut, adapter.AuthiRootPath, nil)
+	req.Header.Set(adapter.RefreshTokenHeaderName, refreshToken)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiRefreshPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.RefreshToken(c)
+	// Assertions
+	assert.Equal(t, echo.ErrForbidden, err)
+	assert.Equal(t, 1, len(facade.RefreshTokenRecordArray))
+}
+
 func TestRefreshToken_RefreshToken_ErrUnauthorized(t *testing.T) {
 	facade := &core.CoreMock{RefreshTokenResponseArray: errorTokenResponse}
 	userApi := &UserApi{facade}
@@ -215,12 +438,12 @@ func TestRefreshToken_RefreshToken_ErrUnauthorized(t *testing.T) {
 // Update Password Test
 
 func TestUpdatePassword_Successfully(t *testing.T) {
-	facade := &core.CoreMock{UpdatePasswordResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	facade := &core.CoreMock{UpdatePasswordResponseArray: successfullyTokenResponse}
 	userApi := &UserApi{facade}
 	// Setup
 	e := echo.New()
 	e.Validator = &CustomValidator{validator: validator.New()}
-	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(updatePasswordJson))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
@@ -233,24 +456,25 @@ func TestUpdatePassword_Successfully(t *testing.T) {
 	err := userApi.UpdatePassword(c)
 	// Assertions
 	assert.Nil(t, err)
-	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, http.StatusOK, rec.Code)
 	assert.Equal(t, 0, len(facade.RefreshTokenRecordArray))
 	assert.Equal(t, 0, len(facade.LoginUserRecordArray))
 	assert.Equal(t, 0, len(facade.CreateUserRecordArray))
 	assert.Equal(t, 1, len(facade.UpdatePasswordRecordArray))
 	assert.Equal(t, 0, len(facade.DeleteUserRecordArray))
 	assert.Equal(t, userId, facade.UpdatePasswordRecordArray[0].UserId)
-	assert.Equal(t, password, facade.UpdatePasswordRecordArray[0].Password)
-
+	assert.Equal(t, password, facade.UpdatePasswordRecordArray[0].CurrentPassword)
+	assert.Equal(t, "some_new_password", facade.UpdatePasswordRecordArray[0].Password)
+	assert.Equal(t, "{\"access_token\":\"some_access_token\",\"expires_in\":1,\"refresh_token\":\"some_refresh_token\",\"refresh_expires_in\":2}\n", rec.Body.String())
 }
 
 func TestUpdatePassword_UpdatePassword_ErrUnauthorized(t *testing.T) {
-	facade := &core.CoreMock{UpdatePasswordResponseArray: []*core.ErrorResponse{{Err: errSome}}}
+	facade := &core.CoreMock{UpdatePasswordResponseArray: errorTokenResponse}
 	userApi := &UserApi{facade}
 	// Setup
 	e := echo.New()
 	e.Validator = &CustomValidator{validator: validator.New()}
-	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(updatePasswordJson))
 	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 	rec := httptest.NewRecorder()
 	c := e.NewContext(req, rec)
@@ -269,7 +493,141 @@ func TestUpdatePassword_UpdatePassword_ErrUnauthorized(t *testing.T) {
 	assert.Equal(t, 1, len(facade.UpdatePasswordRecordArray))
 	assert.Equal(t, 0, len(facade.DeleteUserRecordArray))
 	assert.Equal(t, userId, facade.UpdatePasswordRecordArray[0].UserId)
-	assert.Equal(t, password, facade.UpdatePasswordRecordArray[0].Password)
+	assert.Equal(t, password, facade.UpdatePasswordRecordArray[0].CurrentPassword)
+	assert.Equal(t, "some_new_password", facade.UpdatePasswordRecordArray[0].Password)
+}
+
+func TestUpdatePassword_WithoutCurrentPassword_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.UpdatePassword(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.UpdatePasswordRecordArray))
+}
+
+func TestUpdatePassword_UserLocked_ErrLocked(t *testing.T) {
+	facade := &core.CoreMock{UpdatePasswordResponseArray: []*core.AuthenticateResponse{{Err: core.ErrUserLocked}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(updatePasswordJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.UpdatePassword(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusLocked), err)
+	assert.Equal(t, 1, len(facade.UpdatePasswordRecordArray))
+}
+
+// ChangeExpiredPassword Tests
+
+func newChangeExpiredPasswordContext(t *testing.T, body string, authorization string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath+"/"+userId.String()+adapter.AuthiExpiredPasswordPath, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if authorization != "" {
+		req.Header.Set(adapter.AuthorizationHeaderName, authorization)
+	}
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiExpiredPasswordPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	return c, rec
+}
+
+func TestChangeExpiredPassword_Successfully(t *testing.T) {
+	facade := &core.CoreMock{ChangeExpiredPasswordResponseArray: successfullyTokenResponse}
+	userApi := &UserApi{facade}
+	c, rec := newChangeExpiredPasswordContext(t, updatePasswordJson, "Bearer some_change_token")
+
+	err := userApi.ChangeExpiredPassword(c)
+
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, userId, facade.ChangeExpiredPasswordRecordArray[0].UserId)
+	assert.Equal(t, "some_change_token", facade.ChangeExpiredPasswordRecordArray[0].ChangeToken)
+	assert.Equal(t, password, facade.ChangeExpiredPasswordRecordArray[0].CurrentPassword)
+	assert.Equal(t, "some_new_password", facade.ChangeExpiredPasswordRecordArray[0].Password)
+	assert.Equal(t, "{\"access_token\":\"some_access_token\",\"expires_in\":1,\"refresh_token\":\"some_refresh_token\",\"refresh_expires_in\":2}\n", rec.Body.String())
+}
+
+func TestChangeExpiredPassword_WithoutToken_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newChangeExpiredPasswordContext(t, updatePasswordJson, "")
+
+	err := userApi.ChangeExpiredPassword(c)
+
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 0, len(facade.ChangeExpiredPasswordRecordArray))
+}
+
+func TestChangeExpiredPassword_InvalidToken_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{ChangeExpiredPasswordResponseArray: []*core.AuthenticateResponse{{Err: core.ErrInvalidChangeToken}}}
+	userApi := &UserApi{facade}
+	c, _ := newChangeExpiredPasswordContext(t, updatePasswordJson, "Bearer some_access_token")
+
+	err := userApi.ChangeExpiredPassword(c)
+
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 1, len(facade.ChangeExpiredPasswordRecordArray))
+}
+
+func TestChangeExpiredPassword_PasswordReused_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{ChangeExpiredPasswordResponseArray: []*core.AuthenticateResponse{{Err: &core.PasswordPolicyError{Violations: []string{adapter.PasswordReused}}}}}
+	userApi := &UserApi{facade}
+	c, _ := newChangeExpiredPasswordContext(t, updatePasswordJson, "Bearer some_change_token")
+
+	err := userApi.ChangeExpiredPassword(c)
+
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, &adapter.PasswordPolicyErrorDTO{Message: core.ErrPasswordPolicy.Error(), Violations: []string{adapter.PasswordReused}}), err)
+}
+
+func TestUpdatePassword_PasswordPolicy_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{UpdatePasswordResponseArray: []*core.AuthenticateResponse{{Err: &core.PasswordPolicyError{Violations: []string{adapter.PasswordMissingDigit}}}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPatch, adapter.AuthiRootPath, strings.NewReader(updatePasswordJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.UpdatePassword(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, &adapter.PasswordPolicyErrorDTO{Message: core.ErrPasswordPolicy.Error(), Violations: []string{adapter.PasswordMissingDigit}}), err)
+	assert.Equal(t, 1, len(facade.UpdatePasswordRecordArray))
 }
 
 // Delete User Test
@@ -328,3 +686,778 @@ func TestDeleteUser_DeleteUser_ErrUnauthorized(t *testing.T) {
 	assert.Equal(t, 1, len(facade.DeleteUserRecordArray))
 	assert.Equal(t, userId, facade.DeleteUserRecordArray[0].UserId)
 }
+
+// Login History Test
+
+func TestGetLoginHistory_Successfully(t *testing.T) {
+	loginHistory := []*adapter.LoginHistoryDTO{{LoginTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), IP: "192.0.2.1", UserAgent: "some agent", Success: true}}
+	facade := &core.CoreMock{GetLoginHistoryResponseArray: []*core.GetLoginHistoryResponse{{LoginHistory: loginHistory}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiRootPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginsPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.GetLoginHistory(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, len(facade.GetLoginHistoryRecordArray))
+	assert.Equal(t, userId, facade.GetLoginHistoryRecordArray[0].UserId)
+	assert.Equal(t, "[{\"login_time\":\"2024-01-02T03:04:05Z\",\"ip\":\"192.0.2.1\",\"user_agent\":\"some agent\",\"success\":true}]\n", rec.Body.String())
+}
+
+func TestGetLoginHistory_OtherUser_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiRootPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginsPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(uuid.NewString())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.GetLoginHistory(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 0, len(facade.GetLoginHistoryRecordArray))
+}
+
+func TestGetLoginHistory_GetLoginHistory_InternalServerError(t *testing.T) {
+	facade := &core.CoreMock{GetLoginHistoryResponseArray: []*core.GetLoginHistoryResponse{{Err: errSome}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodGet, adapter.AuthiRootPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginsPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.GetLoginHistory(c)
+	// Assertions
+	assert.Equal(t, echo.ErrInternalServerError, err)
+	assert.Equal(t, 1, len(facade.GetLoginHistoryRecordArray))
+	assert.Equal(t, userId, facade.GetLoginHistoryRecordArray[0].UserId)
+}
+
+// SendEmailVerification Tests
+
+func TestSendEmailVerification_Successfully(t *testing.T) {
+	facade := &core.CoreMock{SendEmailVerificationResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiVerifyEmailPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.SendEmailVerification(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, 1, len(facade.SendEmailVerificationRecordArray))
+	assert.Equal(t, userId, facade.SendEmailVerificationRecordArray[0].UserId)
+}
+
+func TestSendEmailVerification_NoEmail_ErrConflict(t *testing.T) {
+	facade := &core.CoreMock{SendEmailVerificationResponseArray: []*core.ErrorResponse{{Err: core.ErrNoEmail}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiVerifyEmailPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.SendEmailVerification(c)
+	// Assertions
+	assert.Equal(t, echo.ErrConflict, err)
+	assert.Equal(t, 1, len(facade.SendEmailVerificationRecordArray))
+}
+
+func TestSendEmailVerification_OtherUser_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiVerifyEmailPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(uuid.NewString())
+	c.Set(adapter.ClaimName, claimUser)
+	// Exec
+	err := userApi.SendEmailVerification(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 0, len(facade.SendEmailVerificationRecordArray))
+}
+
+// VerifyEmail Tests
+
+func TestVerifyEmail_Successfully(t *testing.T) {
+	facade := &core.CoreMock{VerifyEmailResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, strings.NewReader(`{"token":"someToken"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiVerifyEmailPath + adapter.AuthiConfirmPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.VerifyEmail(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, 1, len(facade.VerifyEmailRecordArray))
+	assert.Equal(t, userId, facade.VerifyEmailRecordArray[0].UserId)
+	assert.Equal(t, "someToken", facade.VerifyEmailRecordArray[0].Token)
+}
+
+func TestVerifyEmail_MissingToken_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiVerifyEmailPath + adapter.AuthiConfirmPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.VerifyEmail(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.VerifyEmailRecordArray))
+}
+
+func TestVerifyEmail_InvalidToken_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{VerifyEmailResponseArray: []*core.ErrorResponse{{Err: core.ErrInvalidVerificationToken}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, strings.NewReader(`{"token":"someToken"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiVerifyEmailPath + adapter.AuthiConfirmPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.VerifyEmail(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 1, len(facade.VerifyEmailRecordArray))
+}
+
+// RequestPasswordReset Tests
+
+func TestRequestPasswordReset_Successfully(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiPasswordResetPath, strings.NewReader(`{"identifier":"max@example.org"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.RequestPasswordReset(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, 1, len(facade.RequestPasswordResetRecordArray))
+	assert.Equal(t, "max@example.org", facade.RequestPasswordResetRecordArray[0].Identifier)
+}
+
+func TestRequestPasswordReset_MissingIdentifier_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiPasswordResetPath, strings.NewReader(`{}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.RequestPasswordReset(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.RequestPasswordResetRecordArray))
+}
+
+// ConfirmPasswordReset Tests
+
+func TestConfirmPasswordReset_Successfully(t *testing.T) {
+	facade := &core.CoreMock{ConfirmPasswordResetResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiPasswordResetPath+adapter.AuthiConfirmPath, strings.NewReader(`{"token":"someToken","password":"newPassword"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.ConfirmPasswordReset(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, 1, len(facade.ConfirmPasswordResetRecordArray))
+	assert.Equal(t, "someToken", facade.ConfirmPasswordResetRecordArray[0].Token)
+	assert.Equal(t, "newPassword", facade.ConfirmPasswordResetRecordArray[0].Password)
+}
+
+func TestConfirmPasswordReset_MissingPassword_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiPasswordResetPath+adapter.AuthiConfirmPath, strings.NewReader(`{"token":"someToken"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.ConfirmPasswordReset(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.ConfirmPasswordResetRecordArray))
+}
+
+func TestConfirmPasswordReset_InvalidToken_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{ConfirmPasswordResetResponseArray: []*core.ErrorResponse{{Err: core.ErrInvalidResetToken}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiPasswordResetPath+adapter.AuthiConfirmPath, strings.NewReader(`{"token":"someToken","password":"newPassword"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.ConfirmPasswordReset(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 1, len(facade.ConfirmPasswordResetRecordArray))
+}
+
+func TestConfirmPasswordReset_PasswordPolicy_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{ConfirmPasswordResetResponseArray: []*core.ErrorResponse{{Err: &core.PasswordPolicyError{Violations: []string{adapter.PasswordContainsUsername}}}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiPasswordResetPath+adapter.AuthiConfirmPath, strings.NewReader(`{"token":"someToken","password":"maxPassword"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.ConfirmPasswordReset(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusBadRequest, &adapter.PasswordPolicyErrorDTO{Message: core.ErrPasswordPolicy.Error(), Violations: []string{adapter.PasswordContainsUsername}}), err)
+	assert.Equal(t, 1, len(facade.ConfirmPasswordResetRecordArray))
+}
+
+func TestLoginUser__LoginUser_MfaRequired(t *testing.T) {
+	facade := &core.CoreMock{LoginUserResponseArray: []*core.AuthenticateResponse{{Err: &core.MfaRequiredError{MfaChallengeToken: "some_challenge_token", ExpiresAt: 3}}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, strings.NewReader(authenticationUserJson))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiLoginPath)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	// Exec
+	err := userApi.LoginUser(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "{\"mfa_challenge_token\":\"some_challenge_token\",\"methods\":[\"totp\"],\"expires_in\":3}\n", rec.Body.String())
+}
+
+func TestLoginUserByIdentifier_MfaRequired(t *testing.T) {
+	facade := &core.CoreMock{LoginUserByIdentifierResponseArray: []*core.AuthenticateResponse{{Err: &core.MfaRequiredError{MfaChallengeToken: "some_challenge_token", ExpiresAt: 3}}}}
+	userApi := &UserApi{facade}
+	// Setup
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiLoginPath, strings.NewReader(`{"identifier":"max","password":"some_password"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	// Exec
+	err := userApi.LoginUserByIdentifier(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Contains(t, rec.Body.String(), "some_challenge_token")
+}
+
+func newLoginWithMfaContext(t *testing.T, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiLoginPath+adapter.AuthiMfaPath, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	return c, rec
+}
+
+func TestLoginWithMfa_Successfully(t *testing.T) {
+	facade := &core.CoreMock{LoginWithMfaResponseArray: successfullyTokenResponse}
+	userApi := &UserApi{facade}
+	c, rec := newLoginWithMfaContext(t, `{"mfa_challenge_token":"some_challenge_token","code":"123456"}`)
+	// Exec
+	err := userApi.LoginWithMfa(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "some_challenge_token", facade.LoginWithMfaRecordArray[0].ChallengeToken)
+	assert.Equal(t, "123456", facade.LoginWithMfaRecordArray[0].Code)
+	assert.Equal(t, "192.0.2.1", facade.LoginWithMfaRecordArray[0].ClientInfo.IP)
+}
+
+func TestLoginWithMfa_WithoutCode_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newLoginWithMfaContext(t, `{"mfa_challenge_token":"some_challenge_token"}`)
+	// Exec
+	err := userApi.LoginWithMfa(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.LoginWithMfaRecordArray))
+}
+
+func TestLoginWithMfa_InvalidCode_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{LoginWithMfaResponseArray: []*core.AuthenticateResponse{{Err: core.ErrInvalidMfaCode}}}
+	userApi := &UserApi{facade}
+	c, _ := newLoginWithMfaContext(t, `{"mfa_challenge_token":"some_challenge_token","code":"123456"}`)
+	// Exec
+	err := userApi.LoginWithMfa(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+}
+
+func TestLoginWithMfa_UserLocked_ErrLocked(t *testing.T) {
+	facade := &core.CoreMock{LoginWithMfaResponseArray: []*core.AuthenticateResponse{{Err: core.ErrUserLocked}}}
+	userApi := &UserApi{facade}
+	c, _ := newLoginWithMfaContext(t, `{"mfa_challenge_token":"some_challenge_token","code":"123456"}`)
+	// Exec
+	err := userApi.LoginWithMfa(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusLocked), err)
+}
+
+func newTotpContext(t *testing.T, method string, path string, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(method, adapter.AuthiRootPath, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiMfaPath + path)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	return c, rec
+}
+
+func TestEnrollTotp_Successfully(t *testing.T) {
+	facade := &core.CoreMock{EnrollTotpResponseArray: []*core.EnrollTotpResponse{{Enrollment: &adapter.TotpEnrollmentDTO{Secret: "SOMESECRET", Uri: "otpauth://totp/Authi:max?secret=SOMESECRET"}}}}
+	userApi := &UserApi{facade}
+	c, rec := newTotpContext(t, http.MethodPost, adapter.AuthiTotpPath, "")
+	// Exec
+	err := userApi.EnrollTotp(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, userId, facade.EnrollTotpRecordArray[0].UserId)
+	assert.Equal(t, "{\"secret\":\"SOMESECRET\",\"uri\":\"otpauth://totp/Authi:max?secret=SOMESECRET\"}\n", rec.Body.String())
+}
+
+func TestEnrollTotp_AlreadyEnabled_ErrConflict(t *testing.T) {
+	facade := &core.CoreMock{EnrollTotpResponseArray: []*core.EnrollTotpResponse{{Err: core.ErrMfaAlreadyEnabled}}}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodPost, adapter.AuthiTotpPath, "")
+	// Exec
+	err := userApi.EnrollTotp(c)
+	// Assertions
+	assert.Equal(t, echo.ErrConflict, err)
+}
+
+func TestEnrollTotp_OtherUser_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodPost, adapter.AuthiTotpPath, "")
+	c.Set(adapter.ClaimName, adapter.Claims{UserId: uuid.New()})
+	// Exec
+	err := userApi.EnrollTotp(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 0, len(facade.EnrollTotpRecordArray))
+}
+
+func TestConfirmTotp_Successfully(t *testing.T) {
+	recoveryCodes := &adapter.RecoveryCodesDTO{RecoveryCodes: []string{"abcde-fgh23"}}
+	facade := &core.CoreMock{ConfirmTotpResponseArray: []*core.RecoveryCodesResponse{{RecoveryCodes: recoveryCodes}}}
+	userApi := &UserApi{facade}
+	c, rec := newTotpContext(t, http.MethodPost, adapter.AuthiTotpPath+adapter.AuthiConfirmPath, `{"code":"123456"}`)
+	// Exec
+	err := userApi.ConfirmTotp(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"recovery_codes":["abcde-fgh23"]}`, rec.Body.String())
+	assert.Equal(t, userId, facade.ConfirmTotpRecordArray[0].UserId)
+	assert.Equal(t, "123456", facade.ConfirmTotpRecordArray[0].Code)
+}
+
+func TestConfirmTotp_InvalidCode_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{ConfirmTotpResponseArray: []*core.RecoveryCodesResponse{{Err: core.ErrInvalidMfaCode}}}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodPost, adapter.AuthiTotpPath+adapter.AuthiConfirmPath, `{"code":"123456"}`)
+	// Exec
+	err := userApi.ConfirmTotp(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+}
+
+func TestConfirmTotp_NotEnrolled_ErrConflict(t *testing.T) {
+	facade := &core.CoreMock{ConfirmTotpResponseArray: []*core.RecoveryCodesResponse{{Err: core.ErrMfaNotEnrolled}}}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodPost, adapter.AuthiTotpPath+adapter.AuthiConfirmPath, `{"code":"123456"}`)
+	// Exec
+	err := userApi.ConfirmTotp(c)
+	// Assertions
+	assert.Equal(t, echo.ErrConflict, err)
+}
+
+func TestDisableTotp_Successfully(t *testing.T) {
+	facade := &core.CoreMock{DisableTotpResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	userApi := &UserApi{facade}
+	c, rec := newTotpContext(t, http.MethodDelete, adapter.AuthiTotpPath, `{"code":"123456"}`)
+	// Exec
+	err := userApi.DisableTotp(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "123456", facade.DisableTotpRecordArray[0].Code)
+}
+
+func TestDisableTotp_WithoutCode_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodDelete, adapter.AuthiTotpPath, `{}`)
+	// Exec
+	err := userApi.DisableTotp(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.DisableTotpRecordArray))
+}
+
+func TestDisableTotp_UserLocked_ErrLocked(t *testing.T) {
+	facade := &core.CoreMock{DisableTotpResponseArray: []*core.ErrorResponse{{Err: core.ErrUserLocked}}}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodDelete, adapter.AuthiTotpPath, `{"code":"123456"}`)
+	// Exec
+	err := userApi.DisableTotp(c)
+	// Assertions
+	assert.Equal(t, echo.NewHTTPError(http.StatusLocked), err)
+}
+
+func TestRegenerateRecoveryCodes_Successfully(t *testing.T) {
+	recoveryCodes := &adapter.RecoveryCodesDTO{RecoveryCodes: []string{"abcde-fgh23"}}
+	facade := &core.CoreMock{RegenerateRecoveryCodesResponseArray: []*core.RecoveryCodesResponse{{RecoveryCodes: recoveryCodes}}}
+	userApi := &UserApi{facade}
+	c, rec := newTotpContext(t, http.MethodPost, adapter.AuthiRecoveryCodesPath, `{"code":"123456"}`)
+	// Exec
+	err := userApi.RegenerateRecoveryCodes(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"recovery_codes":["abcde-fgh23"]}`, rec.Body.String())
+	assert.Equal(t, userId, facade.RegenerateRecoveryCodesRecordArray[0].UserId)
+	assert.Equal(t, "123456", facade.RegenerateRecoveryCodesRecordArray[0].Code)
+}
+
+func TestRegenerateRecoveryCodes_Errors(t *testing.T) {
+	for _, testCase := range []struct {
+		err      error
+		expected error
+	}{
+		{core.ErrInvalidMfaCode, echo.ErrBadRequest},
+		{core.ErrMfaNotEnabled, echo.ErrConflict},
+		{core.ErrUserLocked, echo.NewHTTPError(http.StatusLocked)},
+		{fmt.Errorf("some error"), echo.ErrInternalServerError},
+	} {
+		facade := &core.CoreMock{RegenerateRecoveryCodesResponseArray: []*core.RecoveryCodesResponse{{Err: testCase.err}}}
+		userApi := &UserApi{facade}
+		c, _ := newTotpContext(t, http.MethodPost, adapter.AuthiRecoveryCodesPath, `{"code":"123456"}`)
+		// Exec
+		err := userApi.RegenerateRecoveryCodes(c)
+		// Assertions
+		assert.Equal(t, testCase.expected, err)
+	}
+}
+
+func TestRegenerateRecoveryCodes_WrongUser_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newTotpContext(t, http.MethodPost, adapter.AuthiRecoveryCodesPath, `{"code":"123456"}`)
+	c.Set(adapter.ClaimName, adapter.Claims{UserId: uuid.New()})
+	// Exec
+	err := userApi.RegenerateRecoveryCodes(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 0, len(facade.RegenerateRecoveryCodesRecordArray))
+}
+
+func newPasskeyContext(t *testing.T, path string, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiRootPath, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	c.SetPath(adapter.AuthiRootPath + "/:" + userIdParam + adapter.AuthiPasskeyPath + path)
+	c.SetParamNames(userIdParam)
+	c.SetParamValues(userId.String())
+	c.Set(adapter.ClaimName, claimUser)
+	return c, rec
+}
+
+func newLoginWithPasskeyContext(t *testing.T, body string) (echo.Context, *httptest.ResponseRecorder) {
+	e := echo.New()
+	e.Validator = &CustomValidator{validator: validator.New()}
+	req := httptest.NewRequest(http.MethodPost, adapter.AuthiLoginPath+adapter.AuthiPasskeyPath+adapter.AuthiConfirmPath, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.Set(loggerKey, log.WithField("Test", t.Name()))
+	return c, rec
+}
+
+func TestBeginPasskeyRegistration_Successfully(t *testing.T) {
+	options := &adapter.PasskeyOptionsDTO{PasskeyChallengeToken: "some_challenge_token", PublicKey: []byte(`{"challenge":"c29tZUNoYWxsZW5nZQ"}`)}
+	facade := &core.CoreMock{BeginPasskeyRegistrationResponseArray: []*core.PasskeyOptionsResponse{{Options: options}}}
+	userApi := &UserApi{facade}
+	c, rec := newPasskeyContext(t, "", `{"password":"some_password"}`)
+	// Exec
+	err := userApi.BeginPasskeyRegistration(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"passkey_challenge_token":"some_challenge_token","public_key":{"challenge":"c29tZUNoYWxsZW5nZQ"}}`, rec.Body.String())
+	assert.Equal(t, userId, facade.BeginPasskeyRegistrationRecordArray[0].UserId)
+	assert.Equal(t, "some_password", facade.BeginPasskeyRegistrationRecordArray[0].Password)
+}
+
+func TestBeginPasskeyRegistration_WithoutPassword_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newPasskeyContext(t, "", "")
+	// Exec
+	err := userApi.BeginPasskeyRegistration(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.BeginPasskeyRegistrationRecordArray))
+}
+
+func TestBeginPasskeyRegistration_WrongUser_ErrUnauthorized(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newPasskeyContext(t, "", `{"password":"some_password"}`)
+	c.Set(adapter.ClaimName, adapter.Claims{UserId: uuid.New()})
+	// Exec
+	err := userApi.BeginPasskeyRegistration(c)
+	// Assertions
+	assert.Equal(t, echo.ErrUnauthorized, err)
+	assert.Equal(t, 0, len(facade.BeginPasskeyRegistrationRecordArray))
+}
+
+func TestBeginPasskeyRegistration_Errors(t *testing.T) {
+	for _, testCase := range []struct {
+		err      error
+		expected error
+	}{
+		{core.ErrUserNotFound, echo.ErrNotFound},
+		{core.ErrMfaRequired, echo.ErrForbidden},
+		{core.ErrUserDisabled, echo.ErrForbidden},
+		{fmt.Errorf("some error"), echo.ErrUnauthorized},
+	} {
+		facade := &core.CoreMock{BeginPasskeyRegistrationResponseArray: []*core.PasskeyOptionsResponse{{Err: testCase.err}}}
+		userApi := &UserApi{facade}
+		c, _ := newPasskeyContext(t, "", `{"password":"some_password"}`)
+		// Exec
+		err := userApi.BeginPasskeyRegistration(c)
+		// Assertions
+		assert.Equal(t, testCase.expected, err)
+	}
+}
+
+func TestFinishPasskeyRegistration_Successfully(t *testing.T) {
+	facade := &core.CoreMock{FinishPasskeyRegistrationResponseArray: []*core.ErrorResponse{{Err: nil}}}
+	userApi := &UserApi{facade}
+	c, rec := newPasskeyContext(t, adapter.AuthiConfirmPath, `{"passkey_challenge_token":"some_challenge_token","credential":{"id":"c29tZUlk"}}`)
+	// Exec
+	err := userApi.FinishPasskeyRegistration(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, userId, facade.FinishPasskeyRegistrationRecordArray[0].UserId)
+	assert.Equal(t, "some_challenge_token", facade.FinishPasskeyRegistrationRecordArray[0].ChallengeToken)
+	assert.JSONEq(t, `{"id":"c29tZUlk"}`, string(facade.FinishPasskeyRegistrationRecordArray[0].Credential))
+}
+
+func TestFinishPasskeyRegistration_WithoutCredential_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newPasskeyContext(t, adapter.AuthiConfirmPath, `{"passkey_challenge_token":"some_challenge_token"}`)
+	// Exec
+	err := userApi.FinishPasskeyRegistration(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.FinishPasskeyRegistrationRecordArray))
+}
+
+func TestFinishPasskeyRegistration_Errors(t *testing.T) {
+	for _, testCase := range []struct {
+		err      error
+		expected error
+	}{
+		{core.ErrInvalidPasskeyChallenge, echo.ErrBadRequest},
+		{core.ErrInvalidPasskey, echo.ErrBadRequest},
+		{core.ErrPasskeyAlreadyRegistered, echo.ErrConflict},
+		{fmt.Errorf("some error"), echo.ErrInternalServerError},
+	} {
+		facade := &core.CoreMock{FinishPasskeyRegistrationResponseArray: []*core.ErrorResponse{{Err: testCase.err}}}
+		userApi := &UserApi{facade}
+		c, _ := newPasskeyContext(t, adapter.AuthiConfirmPath, `{"passkey_challenge_token":"some_challenge_token","credential":{"id":"c29tZUlk"}}`)
+		// Exec
+		err := userApi.FinishPasskeyRegistration(c)
+		// Assertions
+		assert.Equal(t, testCase.expected, err)
+	}
+}
+
+func TestBeginPasskeyLogin_Successfully(t *testing.T) {
+	options := &adapter.PasskeyOptionsDTO{PasskeyChallengeToken: "some_challenge_token", PublicKey: []byte(`{"rpId":"localhost"}`)}
+	facade := &core.CoreMock{BeginPasskeyLoginResponseArray: []*core.PasskeyOptionsResponse{{Options: options}}}
+	userApi := &UserApi{facade}
+	c, rec := newLoginWithPasskeyContext(t, "")
+	// Exec
+	err := userApi.BeginPasskeyLogin(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"passkey_challenge_token":"some_challenge_token","public_key":{"rpId":"localhost"}}`, rec.Body.String())
+}
+
+func TestBeginPasskeyLogin_Error_ErrInternalServerError(t *testing.T) {
+	facade := &core.CoreMock{BeginPasskeyLoginResponseArray: []*core.PasskeyOptionsResponse{{Err: fmt.Errorf("some error")}}}
+	userApi := &UserApi{facade}
+	c, _ := newLoginWithPasskeyContext(t, "")
+	// Exec
+	err := userApi.BeginPasskeyLogin(c)
+	// Assertions
+	assert.Equal(t, echo.ErrInternalServerError, err)
+}
+
+func TestLoginWithPasskey_Successfully(t *testing.T) {
+	facade := &core.CoreMock{LoginWithPasskeyResponseArray: successfullyTokenResponse}
+	userApi := &UserApi{facade}
+	c, rec := newLoginWithPasskeyContext(t, `{"passkey_challenge_token":"some_challenge_token","credential":{"id":"c29tZUlk"}}`)
+	// Exec
+	err := userApi.LoginWithPasskey(c)
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "some_challenge_token", facade.LoginWithPasskeyRecordArray[0].ChallengeToken)
+	assert.JSONEq(t, `{"id":"c29tZUlk"}`, string(facade.LoginWithPasskeyRecordArray[0].Credential))
+	assert.Equal(t, "192.0.2.1", facade.LoginWithPasskeyRecordArray[0].ClientInfo.IP)
+}
+
+func TestLoginWithPasskey_WithoutChallengeToken_ErrBadRequest(t *testing.T) {
+	facade := &core.CoreMock{}
+	userApi := &UserApi{facade}
+	c, _ := newLoginWithPasskeyContext(t, `{"credential":{"id":"c29tZUlk"}}`)
+	// Exec
+	err := userApi.LoginWithPasskey(c)
+	// Assertions
+	assert.Equal(t, echo.ErrBadRequest, err)
+	assert.Equal(t, 0, len(facade.LoginWithPasskeyRecordArray))
+}
+
+func TestLoginWithPasskey_Errors(t *testing.T) {
+	for _, testCase := range []struct {
+		err      error
+		expected error
+	}{
+		{core.ErrInvalidPasskey, echo.ErrUnauthorized},
+		{core.ErrInvalidPasskeyChallenge, echo.ErrUnauthorized},
+		{core.ErrUserLocked, echo.NewHTTPError(http.StatusLocked)},
+		{core.ErrUserDisabled, echo.ErrForbidden},
+	} {
+		facade := &core.CoreMock{LoginWithPasskeyResponseArray: []*core.AuthenticateResponse{{Err: testCase.err}}}
+		userApi := &UserApi{facade}
+		c, _ := newLoginWithPasskeyContext(t, `{"passkey_challenge_token":"some_challenge_token","credential":{"id":"c29tZUlk"}}`)
+		// Exec
+		err := userApi.LoginWithPasskey(c)
+		// Assertions
+		assert.Equal(t, testCase.expected, err)
+	}
+}
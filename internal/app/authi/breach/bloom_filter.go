@@ -0,0 +1,149 @@
+package breach
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	bloomFilterMagic = "AUTHIBF1"
+)
+
+var (
+	errInvalidBloomFilter = errors.New("file is no bloom filter of authi")
+)
+
+type (
+	// Compact set of sha-1 hashes that is held in memory. Contained passwords are always found, other passwords are
+	// reported as breached with the false positive rate the filter was built for
+	BloomFilter struct {
+		words  []uint64
+		size   uint64
+		hashes uint32
+	}
+)
+
+// Creates an empty filter that holds the count of hashes with the false positive rate
+func NewBloomFilter(count uint64, falsePositiveRate float64) (*BloomFilter, error) {
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("false positive rate %v has to be between 0 and 1", falsePositiveRate)
+	}
+	count = max(count, 1)
+	size := uint64(math.Ceil(-float64(count) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	hashes := uint32(max(math.Round(float64(size)/float64(count)*math.Ln2), 1))
+	return newBloomFilter(size, hashes), nil
+}
+
+func newBloomFilter(size uint64, hashes uint32) *BloomFilter {
+	return &BloomFilter{words: make([]uint64, (size+63)/64), size: size, hashes: hashes}
+}
+
+// Builds a filter from a hash list in the hibp format. The list is read twice, first to count the hashes
+func BuildBloomFilter(hashList io.ReadSeeker, falsePositiveRate float64) (*BloomFilter, error) {
+	var count uint64
+	if err := readHashes(hashList, func([sha1.Size]byte) { count++ }); err != nil {
+		return nil, err
+	}
+	filter, err := NewBloomFilter(count, falsePositiveRate)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := hashList.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("error while rewinding hash list: %v", err)
+	}
+	if err := readHashes(hashList, filter.add); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening bloom filter: %v", err)
+	}
+	defer file.Close()
+
+	filter, err := ReadBloomFilter(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("error while loading bloom filter %s: %v", path, err)
+	}
+	return filter, nil
+}
+
+// Reads a filter in the format written by WriteTo
+func ReadBloomFilter(reader io.Reader) (*BloomFilter, error) {
+	magic := make([]byte, len(bloomFilterMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || string(magic) != bloomFilterMagic {
+		return nil, errInvalidBloomFilter
+	}
+	var header struct {
+		Size   uint64
+		Hashes uint32
+	}
+	if err := binary.Read(reader, binary.BigEndian, &header); err != nil || header.Size == 0 || header.Hashes == 0 {
+		return nil, errInvalidBloomFilter
+	}
+
+	filter := newBloomFilter(header.Size, header.Hashes)
+	if err := binary.Read(reader, binary.BigEndian, filter.words); err != nil {
+		return nil, fmt.Errorf("error while reading bits of bloom filter: %v", err)
+	}
+	return filter, nil
+}
+
+// Writes the filter with a header of the magic, the number of bits and the number of hash functions
+func (filter *BloomFilter) WriteTo(writer io.Writer) (int64, error) {
+	bufferedWriter := bufio.NewWriter(writer)
+	if _, err := bufferedWriter.WriteString(bloomFilterMagic); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bufferedWriter, binary.BigEndian, filter.size); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bufferedWriter, binary.BigEndian, filter.hashes); err != nil {
+		return 0, err
+	}
+	if err := binary.Write(bufferedWriter, binary.BigEndian, filter.words); err != nil {
+		return 0, err
+	}
+	if err := bufferedWriter.Flush(); err != nil {
+		return 0, err
+	}
+	return int64(len(bloomFilterMagic)) + 8 + 4 + int64(len(filter.words))*8, nil
+}
+
+func (filter *BloomFilter) Contains(password string) (bool, error) {
+	return filter.containsHash(hashPassword(password)), nil
+}
+
+func (filter *BloomFilter) add(hash [sha1.Size]byte) {
+	filter.eachBit(hash, func(bit uint64) bool {
+		filter.words[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+}
+
+func (filter *BloomFilter) containsHash(hash [sha1.Size]byte) bool {
+	return filter.eachBit(hash, func(bit uint64) bool {
+		return filter.words[bit/64]&(1<<(bit%64)) != 0
+	})
+}
+
+// Derives the bits of the hash with double hashing from two parts of the sha-1 hash. Stops as soon as the function returns false
+func (filter *BloomFilter) eachBit(hash [sha1.Size]byte, function func(bit uint64) bool) bool {
+	first := binary.BigEndian.Uint64(hash[0:8])
+	second := binary.BigEndian.Uint64(hash[8:16]) | 1
+	for i := uint64(0); i < uint64(filter.hashes); i++ {
+		if !function((first + i*second) % filter.size) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+// Package to check passwords against local lists of breached passwords without calling an external service
+package breach
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+)
+
+const (
+	// Lines of the hibp format are a sha-1 hash and an optional count, so a line is never longer than this
+	maxLineLength = 128
+)
+
+var (
+	errInvalidHash = errors.New("line doesn't start with a sha-1 hash")
+)
+
+type (
+	Checker interface {
+		// Reports whether the password is contained in the list of breached passwords
+		Contains(password string) (bool, error)
+	}
+
+	// File in the format of the pwned passwords list ordered by hash. Every line contains the upper case sha-1 hash and
+	// optionally a colon and the count. The file is searched on disk, so it doesn't have to fit into memory
+	hashFile struct {
+		file *os.File
+		size int64
+	}
+)
+
+// Opens the configured list of breached passwords. Without a configured list no checker is returned
+func NewChecker(breachedConfig *config.BreachedPasswordConfig) (Checker, error) {
+	switch strings.ToLower(breachedConfig.Format) {
+	case config.BreachedPasswordNone:
+		return nil, nil
+	case config.BreachedPasswordHibp:
+		return openHashFile(breachedConfig.File)
+	case config.BreachedPasswordBloom:
+		return LoadBloomFilter(breachedConfig.File)
+	default:
+		return nil, fmt.Errorf("breached password format %s is unknown", breachedConfig.Format)
+	}
+}
+
+func openHashFile(path string) (*hashFile, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error while opening breached password file: %v", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error while reading breached password file: %v", err)
+	}
+
+	hashes := &hashFile{file: file, size: info.Size()}
+	line, _, err := hashes.lineAt(0)
+	if err == nil {
+		_, err = parseHash(line)
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("breached password file %s has no valid first line: %v", path, err)
+	}
+	return hashes, nil
+}
+
+// Binary search over the byte offsets of the file. Each step reads the first line that starts at or after the middle
+func (hashes *hashFile) Contains(password string) (bool, error) {
+	hash := hashPassword(password)
+	low, high := int64(0), hashes.size
+	for low < high {
+		middle := low + (high-low)/2
+		line, start, err := hashes.lineAt(middle)
+		if err != nil {
+			return false, err
+		}
+		if line == nil || start >= high {
+			high = middle
+			continue
+		}
+		lineHash, err := parseHash(line)
+		if err != nil {
+			return false, fmt.Errorf("error while reading breached password file at offset %d: %v", start, err)
+		}
+		switch bytes.Compare(lineHash[:], hash[:]) {
+		case 0:
+			return true, nil
+		case -1:
+			low = start + int64(len(line))
+		default:
+			high = middle
+		}
+	}
+	return false, nil
+}
+
+// Returns the first line that starts at or after the offset including its line break and the offset where it starts.
+// The line is nil if no line starts after the offset
+func (hashes *hashFile) lineAt(offset int64) ([]byte, int64, error) {
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+	buffer := make([]byte, 2*maxLineLength)
+	n, err := hashes.file.ReadAt(buffer, start)
+	if err != nil && err != io.EOF {
+		return nil, 0, fmt.Errorf("error while reading breached password file: %v", err)
+	}
+	buffer = buffer[:n]
+
+	if offset > 0 {
+		lineBreak := bytes.IndexByte(buffer, '\n')
+		if lineBreak < 0 {
+			return nil, 0, nil
+		}
+		buffer = buffer[lineBreak+1:]
+		start += int64(lineBreak) + 1
+	}
+	if len(buffer) == 0 {
+		return nil, 0, nil
+	}
+	if lineBreak := bytes.IndexByte(buffer, '\n'); lineBreak >= 0 {
+		buffer = buffer[:lineBreak+1]
+	} else if int64(len(buffer))+start < hashes.size {
+		return nil, 0, fmt.Errorf("line at offset %d of breached password file is too long", start)
+	}
+	return buffer, start, nil
+}
+
+func hashPassword(password string) [sha1.Size]byte {
+	return sha1.Sum([]byte(password))
+}
+
+// Parses the hash at the start of a line in the hibp format
+func parseHash(line []byte) ([sha1.Size]byte, error) {
+	var hash [sha1.Size]byte
+	line = bytes.TrimSpace(line)
+	if colon := bytes.IndexByte(line, ':'); colon >= 0 {
+		line = line[:colon]
+	}
+	if len(line) != hex.EncodedLen(sha1.Size) {
+		return hash, errInvalidHash
+	}
+	if _, err := hex.Decode(hash[:], line); err != nil {
+		return hash, errInvalidHash
+	}
+	return hash, nil
+}
+
+// Calls the function with every hash of the hash list. Empty lines are skipped
+func readHashes(reader io.Reader, add func(hash [sha1.Size]byte)) error {
+	scanner := bufio.NewScanner(reader)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		hash, err := parseHash(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("error in line %d of hash list: %v", lineNumber, err)
+		}
+		add(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error while reading hash list: %v", err)
+	}
+	return nil
+}
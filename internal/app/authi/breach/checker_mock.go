@@ -0,0 +1,24 @@
+package breach
+
+type (
+	ContainsRecord struct {
+		Password string
+	}
+
+	ContainsResponse struct {
+		Contained bool
+		Err       error
+	}
+
+	CheckerMock struct {
+		ContainsRecordArray   []*ContainsRecord
+		ContainsResponseArray []*ContainsResponse
+	}
+)
+
+func (mock *CheckerMock) Contains(password string) (bool, error) {
+	record := &ContainsRecord{Password: password}
+	mock.ContainsRecordArray = append(mock.ContainsRecordArray, record)
+	response := mock.ContainsResponseArray[len(mock.ContainsRecordArray)-1]
+	return response.Contained, response.Err
+}
@@ -0,0 +1,184 @@
+package breach
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	breachedPasswords = []string{"password", "123456", "qwerty", "letmein", "dragon", "monkey", "football", "iloveyou"}
+)
+
+// Writes the hashes of the passwords sorted like the pwned passwords list with a count in every line
+func writeHashList(t *testing.T, passwords []string, lineBreak string) string {
+	lines := make([]string, len(passwords))
+	for i, password := range passwords {
+		hash := sha1.Sum([]byte(password))
+		lines[i] = fmt.Sprintf("%s:%d", strings.ToUpper(hex.EncodeToString(hash[:])), i*i)
+	}
+	sort.Strings(lines)
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	assert.Nil(t, os.WriteFile(path, []byte(strings.Join(lines, lineBreak)+lineBreak), 0600))
+	return path
+}
+
+func TestNewChecker_None(t *testing.T) {
+	checker, err := NewChecker(&config.BreachedPasswordConfig{Format: config.BreachedPasswordNone})
+
+	assert.Nil(t, err)
+	assert.Nil(t, checker)
+}
+
+func TestNewChecker_UnknownFormat(t *testing.T) {
+	checker, err := NewChecker(&config.BreachedPasswordConfig{Format: "csv", File: "some.csv"})
+
+	assert.Nil(t, checker)
+	assert.ErrorContains(t, err, "breached password format csv is unknown")
+}
+
+func TestNewChecker_MissingFile(t *testing.T) {
+	_, err := NewChecker(&config.BreachedPasswordConfig{Format: config.BreachedPasswordHibp, File: filepath.Join(t.TempDir(), "missing.txt")})
+
+	assert.ErrorContains(t, err, "error while opening breached password file")
+}
+
+func TestHashFile_Contains(t *testing.T) {
+	for _, lineBreak := range []string{"\n", "\r\n"} {
+		checker, err := NewChecker(&config.BreachedPasswordConfig{Format: config.BreachedPasswordHibp, File: writeHashList(t, breachedPasswords, lineBreak)})
+		assert.Nil(t, err)
+
+		for _, password := range breachedPasswords {
+			contained, err := checker.Contains(password)
+			assert.Nil(t, err)
+			assert.True(t, contained, password)
+		}
+		for _, password := range []string{"Some-Pass1", "Password", "", "correct horse battery staple"} {
+			contained, err := checker.Contains(password)
+			assert.Nil(t, err)
+			assert.False(t, contained, password)
+		}
+	}
+}
+
+func TestHashFile_ContainsEveryLine(t *testing.T) {
+	passwords := make([]string, 2000)
+	for i := range passwords {
+		passwords[i] = fmt.Sprintf("breached-%d", i*i)
+	}
+	checker, err := openHashFile(writeHashList(t, passwords, "\n"))
+	assert.Nil(t, err)
+
+	for i, password := range passwords {
+		contained, err := checker.Contains(password)
+		assert.Nil(t, err)
+		assert.True(t, contained, password)
+
+		contained, err = checker.Contains(fmt.Sprintf("other-%d", i))
+		assert.Nil(t, err)
+		assert.False(t, contained)
+	}
+}
+
+func TestHashFile_SingleLineWithoutLineBreak(t *testing.T) {
+	hash := sha1.Sum([]byte("password"))
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	assert.Nil(t, os.WriteFile(path, []byte(hex.EncodeToString(hash[:])), 0600))
+	checker, err := openHashFile(path)
+	assert.Nil(t, err)
+
+	contained, err := checker.Contains("password")
+
+	assert.Nil(t, err)
+	assert.True(t, contained)
+}
+
+func TestHashFile_InvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "passwords.txt")
+	assert.Nil(t, os.WriteFile(path, []byte("password\n123456\n"), 0600))
+
+	_, err := openHashFile(path)
+
+	assert.ErrorContains(t, err, "has no valid first line")
+}
+
+func TestBloomFilter_Contains(t *testing.T) {
+	hashList, err := os.Open(writeHashList(t, breachedPasswords, "\n"))
+	assert.Nil(t, err)
+	defer hashList.Close()
+
+	filter, err := BuildBloomFilter(hashList, 0.001)
+	assert.Nil(t, err)
+
+	for _, password := range breachedPasswords {
+		contained, err := filter.Contains(password)
+		assert.Nil(t, err)
+		assert.True(t, contained, password)
+	}
+	contained, err := filter.Contains("Some-Pass1")
+	assert.Nil(t, err)
+	assert.False(t, contained)
+}
+
+func TestBloomFilter_FalsePositiveRate(t *testing.T) {
+	filter, err := NewBloomFilter(1000, 0.01)
+	assert.Nil(t, err)
+	for i := 0; i < 1000; i++ {
+		filter.add(hashPassword(fmt.Sprintf("breached-%d", i)))
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if filter.containsHash(hashPassword(fmt.Sprintf("other-%d", i))) {
+			falsePositives++
+		}
+	}
+
+	assert.Less(t, falsePositives, 300)
+}
+
+func TestBloomFilter_WriteAndLoad(t *testing.T) {
+	hashList, err := os.Open(writeHashList(t, breachedPasswords, "\n"))
+	assert.Nil(t, err)
+	defer hashList.Close()
+	filter, err := BuildBloomFilter(hashList, 0.001)
+	assert.Nil(t, err)
+	path := filepath.Join(t.TempDir(), "breached.bloom")
+	file, err := os.Create(path)
+	assert.Nil(t, err)
+	size, err := filter.WriteTo(file)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, info.Size(), size)
+	checker, err := NewChecker(&config.BreachedPasswordConfig{Format: config.BreachedPasswordBloom, File: path})
+	assert.Nil(t, err)
+	assert.Equal(t, filter, checker)
+}
+
+func TestReadBloomFilter_Invalid(t *testing.T) {
+	for _, data := range []string{"", "password", bloomFilterMagic} {
+		_, err := ReadBloomFilter(bytes.NewReader([]byte(data)))
+
+		assert.ErrorIs(t, err, errInvalidBloomFilter, data)
+	}
+}
+
+func TestBuildBloomFilter_InvalidHashList(t *testing.T) {
+	_, err := BuildBloomFilter(strings.NewReader("password\n"), 0.001)
+	assert.ErrorContains(t, err, "error in line 1 of hash list")
+
+	_, err = BuildBloomFilter(strings.NewReader(""), 1.5)
+	assert.ErrorContains(t, err, "false positive rate 1.5 has to be between 0 and 1")
+}
@@ -0,0 +1,525 @@
+// Package to load and validate the configuration of authi
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/util"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Environment variable to point to the path of the config file
+	EnvConfigFile = "CONFIG_FILE"
+
+	defaultConfigFile = "/authi.yml"
+
+	minAdminApiKeyLength   = 32
+	minWebhookSecretLength = 16
+
+	LogFormatText = "text"
+	LogFormatJson = "json"
+
+	// Pattern of accepted correlation ids, per default only uuids are accepted
+	CorrelationIdPatternUuid = `^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`
+
+	DatabasePostgres = "postgresql"
+
+	RateLimiterStoreMemory   = "memory"
+	RateLimiterStoreDatabase = "database"
+
+	TracingExporterNone   = "none"
+	TracingExporterStdout = "stdout"
+	TracingExporterOtlp   = "otlp"
+
+	MailSenderLog  = "log"
+	MailSenderFile = "file"
+	MailSenderSmtp = "smtp"
+
+	BreachedPasswordNone  = "none"
+	BreachedPasswordHibp  = "hibp"
+	BreachedPasswordBloom = "bloom"
+)
+
+var (
+	// Config file or environment variables contain invalid values
+	ErrInvalidConfig = errors.New("invalid configuration")
+)
+
+type (
+	// Configuration of authi. Every value can be set in the config file and be overwritten by the environment variable from its env tag
+	Config struct {
+		LogLevel          string                  `yaml:"log_level" env:"LOG_LEVEL"`
+		LogFormat         string                  `yaml:"log_format" env:"LOG_FORMAT"`
+		InitUserFile      string                  `yaml:"init_user_file" env:"INIT_USER_FILE"`
+		Server            ServerConfig            `yaml:"server"`
+		Database          DatabaseConfig          `yaml:"database"`
+		Token             TokenConfig             `yaml:"token"`
+		Login             LoginConfig             `yaml:"login"`
+		RateLimit         RateLimitConfig         `yaml:"rate_limit"`
+		Metrics           MetricsConfig           `yaml:"metrics"`
+		Tracing           TracingConfig           `yaml:"tracing"`
+		Audit             AuditConfig             `yaml:"audit"`
+		Admin             AdminConfig             `yaml:"admin"`
+		Webhook           WebhookConfig           `yaml:"webhook"`
+		Mail              MailConfig              `yaml:"mail"`
+		EmailVerification EmailVerificationConfig `yaml:"email_verification"`
+		PasswordReset     PasswordResetConfig     `yaml:"password_reset"`
+		PasswordPolicy    PasswordPolicyConfig    `yaml:"password_policy"`
+		Mfa               MfaConfig               `yaml:"mfa"`
+		Passkey           PasskeyConfig           `yaml:"passkey"`
+	}
+
+	ServerConfig struct {
+		Address              string `yaml:"address" env:"ADDRESS"`
+		Port                 int    `yaml:"port" env:"PORT"`
+		ShutdownTimeout      int    `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+		CorrelationIdPattern string `yaml:"correlation_id_pattern" env:"CORRELATION_ID_PATTERN"`
+	}
+
+	DatabaseConfig struct {
+		Type     string         `yaml:"type" env:"DATABASE"`
+		Postgres PostgresConfig `yaml:"postgres"`
+	}
+
+	PostgresConfig struct {
+		User             string `yaml:"user" env:"POSTGRES_USER" secret:"true"`
+		Password         string `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true"`
+		DB               string `yaml:"db" env:"POSTGRES_DB"`
+		Host             string `yaml:"host" env:"POSTGRES_HOST"`
+		Port             int    `yaml:"port" env:"POSTGRES_PORT"`
+		Options          string `yaml:"options" env:"POSTGRES_OPTIONS"`
+		MigrationOptions string `yaml:"migration_options" env:"POSTGRES_MIGRATION_OPTIONS"`
+	}
+
+	TokenConfig struct {
+		PrivateKeyPath         string `yaml:"private_key_path" env:"PRIVATE_KEY_PATH"`
+		PublicKeyPath          string `yaml:"public_key_path" env:"PUBLIC_KEY_PATH"`
+		AccessTokenExpireTime  int    `yaml:"access_token_expire_time" env:"ACCESS_TOKEN_EXPIRE_TIME"`
+		RefreshTokenExpireTime int    `yaml:"refresh_token_expire_time" env:"REFRESH_TOKEN_EXPIRE_TIME"`
+	}
+
+	LoginConfig struct {
+		HistorySize              int           `yaml:"history_size" env:"LOGIN_HISTORY_SIZE"`
+		UpdateLastLoginOnRefresh bool          `yaml:"update_last_login_on_refresh" env:"UPDATE_LAST_LOGIN_ON_REFRESH"`
+		Lockout                  LockoutConfig `yaml:"lockout"`
+	}
+
+	LockoutConfig struct {
+		Threshold   int `yaml:"threshold" env:"LOCKOUT_THRESHOLD"`
+		Duration    int `yaml:"duration" env:"LOCKOUT_DURATION"`
+		MaxDuration int `yaml:"max_duration" env:"LOCKOUT_MAX_DURATION"`
+	}
+
+	RateLimitConfig struct {
+		Store        string    `yaml:"store" env:"RATE_LIMITER_STORE"`
+		Login        RateLimit `yaml:"login" env:"RATE_LIMIT_LOGIN"`
+		CreateUserId RateLimit `yaml:"create_user_id" env:"RATE_LIMIT_CREATE_USER_ID"`
+		Default      RateLimit `yaml:"default" env:"RATE_LIMIT_DEFAULT"`
+	}
+
+	MetricsConfig struct {
+		Enabled bool `yaml:"enabled" env:"METRICS_ENABLED"`
+		Port    int  `yaml:"port" env:"METRICS_PORT"`
+	}
+
+	TracingConfig struct {
+		Exporter    string `yaml:"exporter" env:"TRACING_EXPORTER"`
+		Endpoint    string `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+		Insecure    bool   `yaml:"insecure" env:"TRACING_INSECURE"`
+		ServiceName string `yaml:"service_name" env:"TRACING_SERVICE_NAME"`
+	}
+
+	AuditConfig struct {
+		Enabled bool `yaml:"enabled" env:"AUDIT_ENABLED"`
+	}
+
+	// Without api key and role the admin routes are not offered
+	AdminConfig struct {
+		ApiKey      string `yaml:"api_key" env:"ADMIN_API_KEY" secret:"true"`
+		RoleEnabled bool   `yaml:"role_enabled" env:"ADMIN_ROLE_ENABLED"`
+	}
+
+	WebhookConfig struct {
+		MaxAttempts   int                   `yaml:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS"`
+		Timeout       int                   `yaml:"timeout" env:"WEBHOOK_TIMEOUT"`
+		PollInterval  int                   `yaml:"poll_interval" env:"WEBHOOK_POLL_INTERVAL"`
+		Retention     int                   `yaml:"retention" env:"WEBHOOK_RETENTION"`
+		Subscriptions []WebhookSubscription `yaml:"subscriptions"`
+	}
+
+	// Receiver of webhooks. Subscriptions can only be configured in the config file
+	WebhookSubscription struct {
+		Url    string   `yaml:"url"`
+		Secret string   `yaml:"secret"`
+		Events []string `yaml:"events"`
+	}
+
+	// Mails are only logged per default. The file sender appends them to a file, e.g. for tests
+	MailConfig struct {
+		Sender   string     `yaml:"sender" env:"MAIL_SENDER"`
+		From     string     `yaml:"from" env:"MAIL_FROM"`
+		FilePath string     `yaml:"file_path" env:"MAIL_FILE_PATH"`
+		Smtp     SmtpConfig `yaml:"smtp"`
+	}
+
+	SmtpConfig struct {
+		Host     string `yaml:"host" env:"MAIL_SMTP_HOST"`
+		Port     int    `yaml:"port" env:"MAIL_SMTP_PORT"`
+		User     string `yaml:"user" env:"MAIL_SMTP_USER" secret:"true"`
+		Password string `yaml:"password" env:"MAIL_SMTP_PASSWORD" secret:"true"`
+	}
+
+	// The url is sent in the verification mail. The placeholders {userId} and {token} are replaced
+	EmailVerificationConfig struct {
+		ExpireTime int    `yaml:"expire_time" env:"EMAIL_VERIFICATION_EXPIRE_TIME"`
+		Url        string `yaml:"url" env:"EMAIL_VERIFICATION_URL"`
+	}
+
+	// Rules for new passwords. Lengths are counted in characters after the normalization. The history size counts the current password,
+	// the max age is given in days. Both are disabled with 0
+	PasswordPolicyConfig struct {
+		MinLength        int                    `yaml:"min_length" env:"PASSWORD_MIN_LENGTH"`
+		MaxLength        int                    `yaml:"max_length" env:"PASSWORD_MAX_LENGTH"`
+		RequireUppercase bool                   `yaml:"require_uppercase" env:"PASSWORD_REQUIRE_UPPERCASE"`
+		RequireLowercase bool                   `yaml:"require_lowercase" env:"PASSWORD_REQUIRE_LOWERCASE"`
+		RequireDigit     bool                   `yaml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+		RequireSpecial   bool                   `yaml:"require_special" env:"PASSWORD_REQUIRE_SPECIAL"`
+		RejectUserData   bool                   `yaml:"reject_user_data" env:"PASSWORD_REJECT_USER_DATA"`
+		Normalize        bool                   `yaml:"normalize" env:"PASSWORD_NORMALIZE"`
+		HistorySize      int                    `yaml:"history_size" env:"PASSWORD_HISTORY_SIZE"`
+		MaxAge           int                    `yaml:"max_age" env:"PASSWORD_MAX_AGE"`
+		Breached         BreachedPasswordConfig `yaml:"breached"`
+	}
+
+	// Local list of breached passwords. The hibp format is a file of sorted sha-1 hashes, the bloom format a filter built with the build-breach-filter command
+	BreachedPasswordConfig struct {
+		Format string `yaml:"format" env:"PASSWORD_BREACHED_FORMAT"`
+		File   string `yaml:"file" env:"PASSWORD_BREACHED_FILE"`
+	}
+
+	// The url is sent in the password reset mail. The placeholder {token} is replaced
+	PasswordResetConfig struct {
+		ExpireTime int    `yaml:"expire_time" env:"PASSWORD_RESET_EXPIRE_TIME"`
+		Url        string `yaml:"url" env:"PASSWORD_RESET_URL"`
+	}
+
+	// The issuer is shown in authenticator apps. The challenge token of the login expires after the expire time in minutes
+	MfaConfig struct {
+		Issuer              string `yaml:"issuer" env:"MFA_ISSUER"`
+		ChallengeExpireTime int    `yaml:"challenge_expire_time" env:"MFA_CHALLENGE_EXPIRE_TIME"`
+	}
+
+	// Passkeys are only offered if the relying party id is set. It is the domain of the site, the origins are given comma separated.
+	// The challenge of a registration or login expires after the expire time in minutes
+	PasskeyConfig struct {
+		RpId                string `yaml:"rp_id" env:"PASSKEY_RP_ID"`
+		RpName              string `yaml:"rp_name" env:"PASSKEY_RP_NAME"`
+		RpOrigins           string `yaml:"rp_origins" env:"PASSKEY_RP_ORIGINS"`
+		ChallengeExpireTime int    `yaml:"challenge_expire_time" env:"PASSKEY_CHALLENGE_EXPIRE_TIME"`
+	}
+
+	RateLimit struct {
+		Rate  float64 `yaml:"rate" env:"_RATE"`
+		Burst int     `yaml:"burst" env:"_BURST"`
+	}
+)
+
+// Configuration that is used for every value that isn't set in the config file or environment
+func Default() *Config {
+	return &Config{
+		LogLevel:     "info",
+		LogFormat:    LogFormatText,
+		InitUserFile: "/authi.conf",
+		Server:       ServerConfig{Address: "0.0.0.0", Port: 1203, ShutdownTimeout: 10, CorrelationIdPattern: CorrelationIdPatternUuid},
+		Database: DatabaseConfig{
+			Type: DatabasePostgres,
+			Postgres: PostgresConfig{
+				User:             "postgres",
+				DB:               "postgres",
+				Host:             "postgres",
+				Port:             5432,
+				Options:          "sslmode=disable",
+				MigrationOptions: "&x-migrations-table=authi-migration",
+			},
+		},
+		Token: TokenConfig{
+			PrivateKeyPath:         "/token/jwtRS256.key",
+			PublicKeyPath:          "/token/jwtRS256.key.pub",
+			AccessTokenExpireTime:  5,
+			RefreshTokenExpireTime: 10,
+		},
+		Login: LoginConfig{
+			HistorySize: 10,
+			Lockout:     LockoutConfig{Threshold: 5, Duration: 1, MaxDuration: 60},
+		},
+		RateLimit: RateLimitConfig{
+			Store:        RateLimiterStoreMemory,
+			Login:        RateLimit{Rate: 1, Burst: 10},
+			CreateUserId: RateLimit{Rate: 10, Burst: 30},
+			Default:      RateLimit{Rate: 10, Burst: 30},
+		},
+		Metrics: MetricsConfig{Enabled: true},
+		Tracing: TracingConfig{Exporter: TracingExporterNone, Endpoint: "localhost:4318", ServiceName: "authi"},
+		Audit:   AuditConfig{Enabled: true},
+		Webhook: WebhookConfig{MaxAttempts: 10, Timeout: 5, PollInterval: 5, Retention: 7},
+		Mail:    MailConfig{Sender: MailSenderLog, From: "authi@localhost", Smtp: SmtpConfig{Port: 587}},
+		EmailVerification: EmailVerificationConfig{
+			ExpireTime: 1440,
+		},
+		PasswordReset: PasswordResetConfig{
+			ExpireTime: 15,
+		},
+		PasswordPolicy: PasswordPolicyConfig{
+			MinLength:      8,
+			MaxLength:      128,
+			RejectUserData: true,
+			Breached:       BreachedPasswordConfig{Format: BreachedPasswordNone},
+		},
+		Mfa: MfaConfig{
+			Issuer:              "Authi",
+			ChallengeExpireTime: 5,
+		},
+		Passkey: PasskeyConfig{
+			RpName:              "Authi",
+			ChallengeExpireTime: 5,
+		},
+	}
+}
+
+// Loads the config file from environment variable CONFIG_FILE, applies the environment variables and validates the result.
+// All problems are reported together in the returned error
+func Load() (*Config, error) {
+	configFile, explicit := os.LookupEnv(EnvConfigFile)
+	if !explicit {
+		configFile = defaultConfigFile
+	}
+
+	config := Default()
+	if err := config.readFile(configFile, explicit); err != nil {
+		return nil, err
+	}
+
+	errs := applyEnvironment(reflect.ValueOf(config).Elem(), "")
+	errs = append(errs, config.validate()...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w:\n%w", ErrInvalidConfig, errors.Join(errs...))
+	}
+	return config, nil
+}
+
+func (config *Config) readFile(configFile string, mandatory bool) error {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		if os.IsNotExist(err) && !mandatory {
+			return nil
+		}
+		return fmt.Errorf("error while loading config file [%s]: %w", configFile, err)
+	}
+
+	if err := yaml.Unmarshal(data, config); err != nil {
+		return fmt.Errorf("error while parsing config file [%s]: %w", configFile, err)
+	}
+	return nil
+}
+
+// Overwrites every field with an env tag by the value of the environment variable. The env tag of a struct is used as prefix for its fields.
+// Fields with the secret tag can also be read from the file in the environment variable with suffix _FILE
+func applyEnvironment(value reflect.Value, prefix string) []error {
+	var errs []error
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		structField := value.Type().Field(i)
+		envName := prefix + structField.Tag.Get("env")
+
+		if field.Kind() == reflect.Struct {
+			errs = append(errs, applyEnvironment(field, envName)...)
+			continue
+		}
+		if envName == prefix {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			if structField.Tag.Get("secret") != "true" {
+				field.SetString(util.GetEnvWithFallback(envName, field.String()))
+				continue
+			}
+			envValue, err := util.GetEnvOrFileWithFallback(envName, field.String())
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			field.SetString(envValue)
+		case reflect.Int:
+			envValue, err := util.GetEnvIntWithFallback(envName, int(field.Int()))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s is not a number: %w", envName, err))
+				continue
+			}
+			field.SetInt(int64(envValue))
+		case reflect.Float64:
+			envValue, err := util.GetEnvFloatWithFallback(envName, field.Float())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s is not a number: %w", envName, err))
+				continue
+			}
+			field.SetFloat(envValue)
+		case reflect.Bool:
+			envValue, err := util.GetEnvBoolWithFallback(envName, field.Bool())
+			if err != nil {
+				errs = append(errs, fmt.Errorf("environment variable %s is not a boolean: %w", envName, err))
+				continue
+			}
+			field.SetBool(envValue)
+		}
+	}
+	return errs
+}
+
+func (config *Config) validate() []error {
+	var errs []error
+	check := func(valid bool, format string, args ...any) {
+		if !valid {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	switch strings.ToLower(config.LogLevel) {
+	case "debug", "info", "warn", "warning":
+	default:
+		errs = append(errs, fmt.Errorf("log level %s is unknown. You can choose between debug, info, warn", config.LogLevel))
+	}
+	switch strings.ToLower(config.LogFormat) {
+	case LogFormatText, LogFormatJson:
+	default:
+		errs = append(errs, fmt.Errorf("log format %s is unknown. You can choose between %s, %s", config.LogFormat, LogFormatText, LogFormatJson))
+	}
+
+	check(config.Server.Port >= 0 && config.Server.Port <= 65535, "server port %d is out of range", config.Server.Port)
+	check(config.Server.ShutdownTimeout > 0, "server shutdown timeout has to be greater than 0")
+	if _, err := regexp.Compile(config.Server.CorrelationIdPattern); err != nil {
+		errs = append(errs, fmt.Errorf("correlation id pattern is invalid: %v", err))
+	}
+
+	switch strings.ToLower(config.Database.Type) {
+	case DatabasePostgres:
+		check(config.Database.Postgres.Password != "", "postgres password has to be set")
+		check(config.Database.Postgres.Port > 0 && config.Database.Postgres.Port <= 65535, "postgres port %d is out of range", config.Database.Postgres.Port)
+	default:
+		errs = append(errs, fmt.Errorf("no configuration for database %s found", config.Database.Type))
+	}
+
+	check(config.Token.PrivateKeyPath != "", "private key path has to be set")
+	check(config.Token.PublicKeyPath != "", "public key path has to be set")
+	check(config.Token.AccessTokenExpireTime > 0, "access token expire time has to be greater than 0")
+	check(config.Token.RefreshTokenExpireTime > 0, "refresh token expire time has to be greater than 0")
+
+	check(config.Login.HistorySize >= 0, "login history size must not be negative")
+	check(config.Login.Lockout.Threshold >= 0, "lockout threshold must not be negative")
+	if config.Login.Lockout.Threshold > 0 {
+		check(config.Login.Lockout.Duration > 0, "lockout duration has to be greater than 0")
+		check(config.Login.Lockout.MaxDuration >= config.Login.Lockout.Duration, "lockout max duration has to be at least the lockout duration")
+	}
+
+	switch strings.ToLower(config.RateLimit.Store) {
+	case RateLimiterStoreMemory, RateLimiterStoreDatabase:
+	default:
+		errs = append(errs, fmt.Errorf("no rate limiter store %s found", config.RateLimit.Store))
+	}
+	rateLimits := []struct {
+		name  string
+		limit RateLimit
+	}{{"login", config.RateLimit.Login}, {"create user id", config.RateLimit.CreateUserId}, {"default", config.RateLimit.Default}}
+	for _, rateLimit := range rateLimits {
+		check(rateLimit.limit.Rate > 0, "%s rate limit has to be greater than 0", rateLimit.name)
+		check(rateLimit.limit.Burst > 0, "%s rate limit burst has to be greater than 0", rateLimit.name)
+	}
+
+	check(config.Metrics.Port >= 0 && config.Metrics.Port <= 65535, "metrics port %d is out of range", config.Metrics.Port)
+	check(config.Metrics.Port == 0 || config.Metrics.Port != config.Server.Port, "metrics port has to differ from server port")
+
+	switch strings.ToLower(config.Tracing.Exporter) {
+	case TracingExporterNone, TracingExporterStdout:
+	case TracingExporterOtlp:
+		check(config.Tracing.Endpoint != "", "tracing endpoint has to be set for otlp exporter")
+	default:
+		errs = append(errs, fmt.Errorf("no tracing exporter %s found", config.Tracing.Exporter))
+	}
+
+	check(config.Admin.ApiKey == "" || len(config.Admin.ApiKey) >= minAdminApiKeyLength, "admin api key has to be at least %d characters long", minAdminApiKeyLength)
+
+	check(config.Webhook.MaxAttempts > 0, "webhook max attempts has to be greater than 0")
+	check(config.Webhook.Timeout > 0, "webhook timeout has to be greater than 0")
+	check(config.Webhook.PollInterval > 0, "webhook poll interval has to be greater than 0")
+	check(config.Webhook.Retention > 0, "webhook retention has to be greater than 0")
+	for i, subscription := range config.Webhook.Subscriptions {
+		subscriptionUrl, err := url.Parse(subscription.Url)
+		check(err == nil && (subscriptionUrl.Scheme == "http" || subscriptionUrl.Scheme == "https") && subscriptionUrl.Host != "", "webhook subscription %d has no valid http url", i)
+		// Webhooks are signed with the secret of their url, so every url can only have one subscription
+		check(!slices.ContainsFunc(config.Webhook.Subscriptions[:i], func(other WebhookSubscription) bool { return other.Url == subscription.Url }), "url of webhook subscription %d is already used by another subscription", i)
+		check(len(subscription.Secret) >= minWebhookSecretLength, "secret of webhook subscription %d has to be at least %d characters long", i, minWebhookSecretLength)
+		check(len(subscription.Events) > 0, "webhook subscription %d has no events", i)
+		for _, event := range subscription.Events {
+			check(slices.Contains(adapter.WebhookEvents, event), "event %s of webhook subscription %d is unknown. You can choose between %s", event, i, strings.Join(adapter.WebhookEvents, ", "))
+		}
+	}
+
+	switch strings.ToLower(config.Mail.Sender) {
+	case MailSenderLog:
+	case MailSenderFile:
+		check(config.Mail.FilePath != "", "mail file path has to be set for file sender")
+	case MailSenderSmtp:
+		check(config.Mail.Smtp.Host != "", "smtp host has to be set for smtp sender")
+		check(config.Mail.Smtp.Port > 0 && config.Mail.Smtp.Port <= 65535, "smtp port %d is out of range", config.Mail.Smtp.Port)
+	default:
+		errs = append(errs, fmt.Errorf("no mail sender %s found", config.Mail.Sender))
+	}
+	check(config.Mail.From != "", "mail sender address has to be set")
+	check(config.EmailVerification.ExpireTime > 0, "email verification expire time has to be greater than 0")
+	check(config.PasswordReset.ExpireTime > 0, "password reset expire time has to be greater than 0")
+	check(config.PasswordPolicy.MinLength > 0, "password min length has to be greater than 0")
+	check(config.PasswordPolicy.MaxLength >= config.PasswordPolicy.MinLength, "password max length has to be at least the min length %d", config.PasswordPolicy.MinLength)
+	check(config.PasswordPolicy.HistorySize >= 0, "password history size must not be negative")
+	check(config.PasswordPolicy.MaxAge >= 0, "password max age must not be negative")
+	switch strings.ToLower(config.PasswordPolicy.Breached.Format) {
+	case BreachedPasswordNone:
+	case BreachedPasswordHibp, BreachedPasswordBloom:
+		check(config.PasswordPolicy.Breached.File != "", "breached password file has to be set for format %s", config.PasswordPolicy.Breached.Format)
+	default:
+		errs = append(errs, fmt.Errorf("breached password format %s is unknown. You can choose between %s, %s, %s", config.PasswordPolicy.Breached.Format, BreachedPasswordNone, BreachedPasswordHibp, BreachedPasswordBloom))
+	}
+	check(config.Mfa.Issuer != "" && !strings.Contains(config.Mfa.Issuer, ":"), "mfa issuer has to be set and must not contain :")
+	check(config.Mfa.ChallengeExpireTime > 0, "mfa challenge expire time has to be greater than 0")
+	if config.Passkey.RpId != "" {
+		check(config.Passkey.RpName != "", "passkey relying party name has to be set")
+		check(len(config.Passkey.Origins()) > 0, "passkey origins have to be set")
+		for _, origin := range config.Passkey.Origins() {
+			originUrl, err := url.Parse(origin)
+			check(err == nil && (originUrl.Scheme == "http" || originUrl.Scheme == "https") && originUrl.Host != "", "passkey origin %s is no valid http url", origin)
+		}
+		check(config.Passkey.ChallengeExpireTime > 0, "passkey challenge expire time has to be greater than 0")
+	}
+
+	return errs
+}
+
+// Origins of the relying party without empty entries
+func (config *PasskeyConfig) Origins() []string {
+	var origins []string
+	for _, origin := range strings.Split(config.RpOrigins, ",") {
+		if origin = strings.TrimSpace(origin); origin != "" {
+			origins = append(origins, origin)
+		}
+	}
+	return origins
+}
@@ -0,0 +1,235 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad_Successfully(t *testing.T) {
+	t.Setenv(EnvConfigFile, "config_test.yml")
+
+	config, err := Load()
+	assert.Nil(t, err)
+
+	expectedConfig := Default()
+	expectedConfig.LogLevel = "debug"
+	expectedConfig.LogFormat = LogFormatJson
+	expectedConfig.Server.Port = 8080
+	expectedConfig.Database.Postgres.Password = "somePassword"
+	expectedConfig.Database.Postgres.Host = "localhost"
+	expectedConfig.Token.AccessTokenExpireTime = 15
+	expectedConfig.Login.UpdateLastLoginOnRefresh = true
+	expectedConfig.Login.Lockout.Threshold = 0
+	expectedConfig.RateLimit.Store = RateLimiterStoreDatabase
+	expectedConfig.RateLimit.Login = RateLimit{Rate: 2, Burst: 20}
+	assert.Equal(t, expectedConfig, config)
+}
+
+func TestLoad_EnvironmentOverridesFile(t *testing.T) {
+	t.Setenv(EnvConfigFile, "config_test.yml")
+	t.Setenv("PORT", "9090")
+	t.Setenv("POSTGRES_PASSWORD", "someOtherPassword")
+	t.Setenv("UPDATE_LAST_LOGIN_ON_REFRESH", "false")
+	t.Setenv("RATE_LIMIT_LOGIN_BURST", "5")
+	t.Setenv("RATE_LIMIT_DEFAULT_RATE", "0.5")
+
+	config, err := Load()
+	assert.Nil(t, err)
+	assert.Equal(t, 9090, config.Server.Port)
+	assert.Equal(t, "someOtherPassword", config.Database.Postgres.Password)
+	assert.False(t, config.Login.UpdateLastLoginOnRefresh)
+	assert.Equal(t, RateLimit{Rate: 2, Burst: 5}, config.RateLimit.Login)
+	assert.Equal(t, 0.5, config.RateLimit.Default.Rate)
+}
+
+func TestLoad_SecretFromFile(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "postgres_password")
+	assert.Nil(t, os.WriteFile(passwordFile, []byte("someSecretPassword\n"), 0600))
+	t.Setenv(EnvConfigFile, "config_test.yml")
+	t.Setenv("POSTGRES_PASSWORD_FILE", passwordFile)
+
+	config, err := Load()
+	assert.Nil(t, err)
+	assert.Equal(t, "someSecretPassword", config.Database.Postgres.Password)
+}
+
+func TestLoad_SecretFromFileNotFound(t *testing.T) {
+	t.Setenv(EnvConfigFile, "config_test.yml")
+	t.Setenv("POSTGRES_PASSWORD_FILE", filepath.Join(t.TempDir(), "some_random_file"))
+
+	config, err := Load()
+	assert.Nil(t, config)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "POSTGRES_PASSWORD_FILE")
+}
+
+func TestLoad_DefaultFileNotFound(t *testing.T) {
+	t.Setenv("POSTGRES_PASSWORD", "somePassword")
+
+	config, err := Load()
+	assert.Nil(t, err)
+
+	expectedConfig := Default()
+	expectedConfig.Database.Postgres.Password = "somePassword"
+	assert.Equal(t, expectedConfig, config)
+}
+
+func TestLoad_ConfigFileNotFound(t *testing.T) {
+	t.Setenv(EnvConfigFile, "some_random_file.yml")
+
+	config, err := Load()
+	assert.Nil(t, config)
+	assert.ErrorContains(t, err, "some_random_file.yml")
+}
+
+func TestLoad_WrongFormat(t *testing.T) {
+	t.Setenv(EnvConfigFile, "config_test_wrong_format.yml")
+
+	config, err := Load()
+	assert.Nil(t, config)
+	assert.ErrorContains(t, err, "error while parsing config file")
+}
+
+func TestLoad_AllErrorsReported(t *testing.T) {
+	t.Setenv(EnvConfigFile, "config_test.yml")
+	t.Setenv("PORT", "eighty")
+	t.Setenv("LOG_LEVEL", "trace")
+	t.Setenv("LOG_FORMAT", "xml")
+	t.Setenv("CORRELATION_ID_PATTERN", "[a-z")
+	t.Setenv("ADMIN_API_KEY", "tooShort")
+	t.Setenv("DATABASE", "mysql")
+	t.Setenv("RATE_LIMITER_STORE", "redis")
+	t.Setenv("RATE_LIMIT_DEFAULT_RATE", "0")
+
+	config, err := Load()
+	assert.Nil(t, config)
+	assert.ErrorIs(t, err, ErrInvalidConfig)
+	assert.ErrorContains(t, err, "environment variable PORT is not a number")
+	assert.ErrorContains(t, err, "log level trace is unknown")
+	assert.ErrorContains(t, err, "log format xml is unknown")
+	assert.ErrorContains(t, err, "correlation id pattern is invalid")
+	assert.ErrorContains(t, err, "admin api key has to be at least 32 characters long")
+	assert.ErrorContains(t, err, "no configuration for database mysql found")
+	assert.ErrorContains(t, err, "no rate limiter store redis found")
+	assert.ErrorContains(t, err, "default rate limit has to be greater than 0")
+}
+
+func TestValidate_Lockout(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.Login.Lockout = LockoutConfig{Threshold: 3, Duration: 10, MaxDuration: 5}
+
+	errs := config.validate()
+	assert.Len(t, errs, 1)
+	assert.ErrorContains(t, errs[0], "lockout max duration")
+}
+
+func TestValidate_WebhookSubscriptions(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.Webhook.Subscriptions = []WebhookSubscription{
+		{Url: "https://example.com/hook", Secret: "someSecretWith16Chars", Events: []string{"user.deleted"}},
+		{Url: "ftp://example.com", Secret: "short", Events: []string{"user.renamed"}},
+		{Url: "https://example.com/hook", Secret: "someSecretWith16Chars"},
+	}
+
+	errs := errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "webhook subscription 1 has no valid http url")
+	assert.ErrorContains(t, errs, "secret of webhook subscription 1 has to be at least 16 characters long")
+	assert.ErrorContains(t, errs, "event user.renamed of webhook subscription 1 is unknown")
+	assert.ErrorContains(t, errs, "webhook subscription 2 has no events")
+	assert.ErrorContains(t, errs, "url of webhook subscription 2 is already used by another subscription")
+	assert.NotContains(t, errs.Error(), "subscription 0")
+}
+
+func TestValidate_Mail(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.Mail.Sender = MailSenderSmtp
+	config.Mail.Smtp.Port = 0
+
+	errs := errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "smtp host has to be set")
+	assert.ErrorContains(t, errs, "smtp port 0 is out of range")
+
+	config.Mail = MailConfig{Sender: MailSenderFile, From: "authi@localhost"}
+	errs = errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "mail file path has to be set")
+}
+
+func TestValidate_PasswordPolicy(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.PasswordPolicy.MinLength = 0
+	config.PasswordPolicy.MaxLength = 0
+
+	errs := errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "password min length has to be greater than 0")
+
+	config.PasswordPolicy = PasswordPolicyConfig{MinLength: 12, MaxLength: 8, HistorySize: -1, MaxAge: -1}
+	errs = errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "password max length has to be at least the min length 12")
+	assert.ErrorContains(t, errs, "password history size must not be negative")
+	assert.ErrorContains(t, errs, "password max age must not be negative")
+}
+
+func TestDefault_PasswordNotNormalized(t *testing.T) {
+	// Existing password hashes stay valid after an upgrade
+	assert.False(t, Default().PasswordPolicy.Normalize)
+}
+
+func TestValidate_BreachedPassword(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.PasswordPolicy.Breached = BreachedPasswordConfig{Format: BreachedPasswordBloom}
+
+	errs := errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "breached password file has to be set for format bloom")
+
+	config.PasswordPolicy.Breached = BreachedPasswordConfig{Format: "csv", File: "/breached.csv"}
+	errs = errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "breached password format csv is unknown")
+}
+
+func TestValidate_Mfa(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.Mfa = MfaConfig{Issuer: "Authi:Test", ChallengeExpireTime: 0}
+
+	errs := errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "mfa issuer has to be set and must not contain :")
+	assert.ErrorContains(t, errs, "mfa challenge expire time has to be greater than 0")
+}
+
+func TestValidate_Passkey(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.Passkey = PasskeyConfig{RpId: "example.org", RpOrigins: "https://example.org, ftp://example.org", ChallengeExpireTime: 0}
+
+	errs := errors.Join(config.validate()...)
+	assert.ErrorContains(t, errs, "passkey relying party name has to be set")
+	assert.ErrorContains(t, errs, "passkey origin ftp://example.org is no valid http url")
+	assert.NotContains(t, errs.Error(), "passkey origin https://example.org")
+	assert.ErrorContains(t, errs, "passkey challenge expire time has to be greater than 0")
+
+	config.Passkey = PasskeyConfig{RpId: "example.org", RpName: "Example", RpOrigins: " , ", ChallengeExpireTime: 5}
+	assert.ErrorContains(t, errors.Join(config.validate()...), "passkey origins have to be set")
+}
+
+func TestValidate_PasskeyDisabled(t *testing.T) {
+	config := Default()
+	config.Database.Postgres.Password = "somePassword"
+	config.Passkey = PasskeyConfig{}
+
+	assert.Empty(t, config.validate())
+}
+
+func TestPasskeyConfig_Origins(t *testing.T) {
+	config := &PasskeyConfig{RpOrigins: "https://example.org, https://login.example.org,,"}
+
+	assert.Equal(t, []string{"https://example.org", "https://login.example.org"}, config.Origins())
+}
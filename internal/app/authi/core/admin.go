@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+)
+
+var (
+	// Audit event that is recorded when a user gets the status
+	statusAuditEvents = map[string]string{
+		adapter.UserStatusActive:   AuditUserEnabled,
+		adapter.UserStatusDisabled: AuditUserDisabled,
+		adapter.UserStatusLocked:   AuditUserLocked,
+		adapter.UserStatusPending:  AuditUserPending,
+	}
+)
+
+type (
+	// Filter of users. Unset fields don't filter
+	UserFilter struct {
+		Search string
+		Status string
+		Limit  int
+		Offset int
+	}
+)
+
+func (userFacade *UserFacade) GetUsers(ctx context.Context, filter *UserFilter) ([]*adapter.UserDTO, error) {
+	dbUsers, err := userFacade.dbConnection.GetUsers(ctx, &db.UserFilterDB{Search: filter.Search, Status: filter.Status, Limit: filter.Limit, Offset: filter.Offset})
+	if err != nil {
+		return nil, fmt.Errorf("error while loading users: %v", err)
+	}
+
+	users := make([]*adapter.UserDTO, len(dbUsers))
+	for i, dbUser := range dbUsers {
+		users[i] = mapToUserDTO(dbUser)
+	}
+	return users, nil
+}
+
+func (userFacade *UserFacade) GetUser(ctx context.Context, userId uuid.UUID) (*adapter.UserDTO, error) {
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		return nil, adminError("error while loading user", err)
+	}
+	return mapToUserDTO(dbUser), nil
+}
+
+// Only active users can log in or refresh their tokens. Every other status revokes all sessions immediately.
+// The reason is stored with the user and recorded in the audit log
+func (userFacade *UserFacade) SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string) error {
+	eventType, ok := statusAuditEvents[status]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownUserStatus, status)
+	}
+
+	var webhooks []*db.WebhookOutboxDB
+	if status != adapter.UserStatusActive {
+		webhooks = userFacade.webhookOutbox(ctx, adapter.WebhookSessionRevoked, userId)
+	}
+	err := userFacade.dbConnection.SetUserStatus(ctx, userId, status, reason, webhooks...)
+	details := reason
+	if err != nil {
+		details = auditDetails(err)
+	}
+	userFacade.auditWithDetails(ctx, eventType, userId, nil, err == nil, details)
+	if err != nil {
+		return adminError("error while setting status of user", err)
+	}
+	return nil
+}
+
+// Error for users that aren't allowed to log in because of their status
+func statusError(status string) error {
+	switch status {
+	case adapter.UserStatusDisabled:
+		return ErrUserDisabled
+	case adapter.UserStatusLocked:
+		return ErrUserLocked
+	case adapter.UserStatusPending:
+		return ErrUserPending
+	default:
+		return nil
+	}
+}
+
+// Revokes the refresh token of the user. Access tokens stay valid until they expire
+func (userFacade *UserFacade) RevokeSessions(ctx context.Context, userId uuid.UUID) error {
+	err := userFacade.dbConnection.RevokeRefreshToken(ctx, userId, userFacade.webhookOutbox(ctx, adapter.WebhookSessionRevoked, userId)...)
+	userFacade.audit(ctx, AuditSessionRevoked, userId, nil, err)
+	if err != nil {
+		return adminError("error while revoking sessions of user", err)
+	}
+	return nil
+}
+
+// Sets a new password for the user and revokes all sessions
+func (userFacade *UserFacade) ResetPassword(ctx context.Context, userId uuid.UUID, password string) error {
+	err := userFacade.resetPassword(ctx, userId, password)
+	userFacade.audit(ctx, AuditPasswordReset, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) resetPassword(ctx context.Context, userId uuid.UUID, password string) error {
+	password, err := userFacade.checkNewPassword(ctx, userId, password)
+	if err != nil {
+		return err
+	}
+	// The password is set and the sessions are revoked in one transaction with both webhooks, so subscribers are only notified of both together
+	webhooks := append(userFacade.webhookOutbox(ctx, adapter.WebhookSessionRevoked, userId), userFacade.webhookOutbox(ctx, adapter.WebhookPasswordChanged, userId)...)
+	if err := userFacade.dbConnection.UpdatePassword(ctx, userId, password, randomString(), userFacade.passwordPolicy.recentPasswords(), webhooks...); err != nil {
+		return adminError("error while resetting password of user", err)
+	}
+	return nil
+}
+
+// Reports unknown users with ErrUserNotFound, so the api can answer with not found
+func adminError(message string, err error) error {
+	if errors.Is(err, db.ErrUserNotFound) {
+		return ErrUserNotFound
+	}
+	return fmt.Errorf("%s: %v", message, err)
+}
+
+func mapToUserDTO(dbUser *db.UserDB) *adapter.UserDTO {
+	return &adapter.UserDTO{
+		Id:                dbUser.ID,
+		CreatedOn:         dbUser.CreatedOn,
+		LastLogin:         dbUser.LastLogin,
+		InitUser:          dbUser.InitUser,
+		Username:          dbUser.Username,
+		Email:             dbUser.Email,
+		Admin:             dbUser.Admin,
+		Status:            dbUser.Status,
+		StatusReason:      dbUser.StatusReason,
+		StatusChangedOn:   dbUser.StatusChangedOn,
+		LockedUntil:       dbUser.LockedUntil,
+		EmailVerified:     dbUser.EmailVerified,
+		PasswordChangedAt: dbUser.PasswordChangedAt,
+		MfaEnabled:        dbUser.TotpEnabled,
+	}
+}
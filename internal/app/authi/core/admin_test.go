@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitDefaultUser_Admin(t *testing.T) {
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}, DeleteInitUsersResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.initDefaultUser("user_test.yml")
+
+	assert.Nil(t, err)
+	assert.False(t, dbConnection.CreateUserRecordArray[0].User.Admin)
+	assert.True(t, dbConnection.CreateUserRecordArray[1].User.Admin)
+	assert.True(t, dbConnection.CreateUserRecordArray[1].User.InitUser)
+}
+
+func TestCreateJWTToken_AdminRole(t *testing.T) {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	for _, admin := range []bool{true, false} {
+		dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+		userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
+
+		token, err := userFacade.createJWTToken(context.Background(), &db.UserDB{ID: userId, Admin: admin})
+		assert.Nil(t, err)
+
+		claims := new(adapter.Claims)
+		_, err = jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
+			return signKey.Public(), nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, userId, claims.UserId)
+		assert.Equal(t, admin, claims.HasRole(adapter.RoleAdmin))
+	}
+}
+
+func TestGetUsers_Successfully(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	statusChangedOn := time.Now()
+	passwordChangedAt := time.Now().Add(-time.Hour)
+	dbUsers := []*db.UserDB{{ID: userId, Admin: true, Status: adapter.UserStatusDisabled, StatusReason: "fraud", StatusChangedOn: &statusChangedOn, LockedUntil: &lockedUntil, PasswordChangedAt: passwordChangedAt}}
+	dbConnection := &db.DBMock{GetUsersResponseArray: []*db.GetUsersResponse{{Users: dbUsers}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	users, err := userFacade.GetUsers(context.Background(), &UserFilter{Search: "c5ff", Status: adapter.UserStatusDisabled, Limit: 10, Offset: 20})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*adapter.UserDTO{{Id: userId, Admin: true, Status: adapter.UserStatusDisabled, StatusReason: "fraud", StatusChangedOn: &statusChangedOn, LockedUntil: &lockedUntil, PasswordChangedAt: passwordChangedAt}}, users)
+	assert.Equal(t, &db.UserFilterDB{Search: "c5ff", Status: adapter.UserStatusDisabled, Limit: 10, Offset: 20}, dbConnection.GetUsersRecordArray[0].Filter)
+}
+
+func TestGetUser_NotFound(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{Err: db.ErrUserNotFound}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	_, err := userFacade.GetUser(context.Background(), userId)
+
+	assert.True(t, errors.Is(err, ErrUserNotFound))
+}
+
+func TestSetUserStatus_Disabled(t *testing.T) {
+	dbConnection := &db.DBMock{SetUserStatusResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	subscriptions := []config.WebhookSubscription{{Url: "https://example.com/hook", Secret: "some-secret-1234", Events: []string{adapter.WebhookSessionRevoked}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, auditEnabled: true, webhooks: subscriptions}
+
+	err := userFacade.SetUserStatus(ContextWithActor(context.Background(), ActorAdmin), userId, adapter.UserStatusDisabled, "fraud")
+
+	assert.Nil(t, err)
+	assert.Equal(t, userId, dbConnection.SetUserStatusRecordArray[0].UserId)
+	assert.Equal(t, adapter.UserStatusDisabled, dbConnection.SetUserStatusRecordArray[0].Status)
+	assert.Equal(t, "fraud", dbConnection.SetUserStatusRecordArray[0].Reason)
+	assert.Equal(t, 1, len(dbConnection.SetUserStatusRecordArray[0].Webhooks))
+	assert.Equal(t, adapter.WebhookSessionRevoked, dbConnection.SetUserStatusRecordArray[0].Webhooks[0].EventType)
+	assert.Equal(t, AuditUserDisabled, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+	assert.Equal(t, ActorAdmin, dbConnection.AddAuditEventRecordArray[0].Event.Actor)
+	assert.Equal(t, "fraud", dbConnection.AddAuditEventRecordArray[0].Event.Details)
+}
+
+func TestSetUserStatus_ActiveDoesNotRevokeSessions(t *testing.T) {
+	dbConnection := &db.DBMock{SetUserStatusResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	subscriptions := []config.WebhookSubscription{{Url: "https://example.com/hook", Secret: "some-secret-1234", Events: []string{adapter.WebhookSessionRevoked}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, auditEnabled: true, webhooks: subscriptions}
+
+	err := userFacade.SetUserStatus(context.Background(), userId, adapter.UserStatusActive, "")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(dbConnection.SetUserStatusRecordArray[0].Webhooks))
+	assert.Equal(t, AuditUserEnabled, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+}
+
+func TestSetUserStatus_NotFound(t *testing.T) {
+	dbConnection := &db.DBMock{SetUserStatusResponseArray: []*db.ErrorResponse{{Err: db.ErrUserNotFound}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.SetUserStatus(context.Background(), userId, adapter.UserStatusPending, "")
+
+	assert.True(t, errors.Is(err, ErrUserNotFound))
+}
+
+func TestSetUserStatus_UnknownStatus(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.SetUserStatus(context.Background(), userId, "deleted", "")
+
+	assert.True(t, errors.Is(err, ErrUnknownUserStatus))
+	assert.Equal(t, 0, len(dbConnection.SetUserStatusRecordArray))
+}
+
+func TestRevokeSessions_UnknownError(t *testing.T) {
+	dbConnection := &db.DBMock{RevokeRefreshTokenResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.RevokeSessions(context.Background(), userId)
+
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrUserNotFound))
+	assert.Equal(t, userId, dbConnection.RevokeRefreshTokenRecordArray[0].UserId)
+}
+
+func TestResetPassword_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	subscriptions := []config.WebhookSubscription{{Url: "https://example.com/hook", Secret: "some-secret-1234", Events: []string{adapter.WebhookSessionRevoked, adapter.WebhookPasswordChanged}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, webhooks: subscriptions}
+
+	err := userFacade.ResetPassword(context.Background(), userId, password)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(dbConnection.RevokeRefreshTokenRecordArray))
+	update := dbConnection.UpdatePasswordRecordArray[0]
+	assert.Equal(t, userId, update.UserId)
+	assert.Equal(t, password, update.Password)
+	assert.Equal(t, 2, len(update.Webhooks))
+	assert.Equal(t, adapter.WebhookSessionRevoked, update.Webhooks[0].EventType)
+	assert.Equal(t, adapter.WebhookPasswordChanged, update.Webhooks[1].EventType)
+}
+
+func TestResetPassword_NotFound(t *testing.T) {
+	dbConnection := &db.DBMock{UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: db.ErrUserNotFound}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.ResetPassword(context.Background(), userId, password)
+
+	assert.True(t, errors.Is(err, ErrUserNotFound))
+}
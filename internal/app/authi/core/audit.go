@@ -0,0 +1,209 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+)
+
+const (
+	// Types of audit events
+	AuditUserCreated            = "user.created"
+	AuditUserLogin              = "user.login"
+	AuditUserLocked             = "user.locked"
+	AuditUserUnlocked           = "user.unlocked"
+	AuditUserDeleted            = "user.deleted"
+	AuditTokenRefreshed         = "token.refreshed"
+	AuditPasswordChanged        = "password.changed"
+	AuditPasswordReset          = "password.reset"
+	AuditPasswordResetRequested = "password.reset_requested"
+	AuditUserDisabled           = "user.disabled"
+	AuditUserEnabled            = "user.enabled"
+	AuditUserPending            = "user.pending"
+	AuditSessionRevoked         = "session.revoked"
+	AuditEmailVerificationSent  = "email.verification_sent"
+	AuditEmailVerified          = "email.verified"
+	AuditMfaChallenged          = "mfa.challenged"
+	AuditMfaEnabled             = "mfa.enabled"
+	AuditMfaDisabled            = "mfa.disabled"
+	AuditRecoveryCodeUsed       = "mfa.recovery_code_used"
+	AuditRecoveryCodesGenerated = "mfa.recovery_codes_generated"
+	AuditPasskeyRegistered      = "passkey.registered"
+
+	// Actor of events that are caused by authi itself, e.g. creating init users
+	ActorSystem = "system"
+	// Actor of events that are caused by requests with the admin api key. Requests of users with the admin role record the user id of the admin
+	ActorAdmin = "admin"
+
+	auditDetailsFailed = "failed"
+)
+
+type (
+	// Filter of audit events. Unset fields don't filter
+	AuditEventFilter struct {
+		UserId    *uuid.UUID
+		EventType string
+		Success   *bool
+		From      *time.Time
+		To        *time.Time
+		Limit     int
+		Offset    int
+		// Time and id of the last event of the previous page. If set, only older events are loaded
+		BeforeTime *time.Time
+		BeforeId   int64
+	}
+
+	clientInfoKey struct{}
+	actorKey      struct{}
+	amrKey        struct{}
+)
+
+// Returns a copy of ctx that carries the client of the request, so audit events can record it
+func ContextWithClientInfo(ctx context.Context, clientInfo *ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, clientInfo)
+}
+
+// Returns a copy of ctx that carries the actor of the request. Without actor the user itself is recorded as actor
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// Returns a copy of ctx that carries the methods the caller authenticated with, so tokens issued for the caller keep them
+func ContextWithAmr(ctx context.Context, amr []string) context.Context {
+	return context.WithValue(ctx, amrKey{}, amr)
+}
+
+func amrFromContext(ctx context.Context) []string {
+	amr, _ := ctx.Value(amrKey{}).([]string)
+	return amr
+}
+
+func clientInfoFromContext(ctx context.Context) *ClientInfo {
+	clientInfo, _ := ctx.Value(clientInfoKey{}).(*ClientInfo)
+	return clientInfo
+}
+
+func actorFromContext(ctx context.Context, userId uuid.UUID) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return userId.String()
+}
+
+// Records an audit event with the result of an action. Errors are only logged, so a failing audit log doesn't block users
+func (userFacade *UserFacade) audit(ctx context.Context, eventType string, userId uuid.UUID, clientInfo *ClientInfo, err error) {
+	userFacade.auditWithDetails(ctx, eventType, userId, clientInfo, err == nil, auditDetails(err))
+}
+
+func (userFacade *UserFacade) auditWithDetails(ctx context.Context, eventType string, userId uuid.UUID, clientInfo *ClientInfo, success bool, details string) {
+	if !userFacade.auditEnabled {
+		return
+	}
+
+	event := &db.AuditEventDB{
+		EventTime:     time.Now(),
+		EventType:     eventType,
+		UserId:        userId,
+		Actor:         actorFromContext(ctx, userId),
+		Success:       success,
+		Details:       details,
+		CorrelationId: adapter.CorrelationIdFromContext(ctx),
+	}
+	if clientInfo == nil {
+		clientInfo = clientInfoFromContext(ctx)
+	}
+	if clientInfo != nil {
+		event.IP = clientInfo.IP
+		event.UserAgent = clientInfo.UserAgent
+	}
+
+	if err := userFacade.dbConnection.AddAuditEvent(ctx, event); err != nil {
+		logging.FromContext(ctx).Errorf("Audit event %s of user %v couldn't be saved: %v", eventType, userId, err)
+	}
+}
+
+// Reason of a failed action without internal details like database errors
+func auditDetails(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrUserLocked):
+		return ErrUserLocked.Error()
+	case errors.Is(err, ErrUserDisabled):
+		return ErrUserDisabled.Error()
+	case errors.Is(err, ErrUserPending):
+		return ErrUserPending.Error()
+	case errors.Is(err, ErrIdentifierTaken):
+		return ErrIdentifierTaken.Error()
+	case errors.Is(err, ErrNoEmail):
+		return ErrNoEmail.Error()
+	case errors.Is(err, ErrEmailAlreadyVerified):
+		return ErrEmailAlreadyVerified.Error()
+	case errors.Is(err, ErrInvalidVerificationToken):
+		return ErrInvalidVerificationToken.Error()
+	case errors.Is(err, ErrInvalidResetToken):
+		return ErrInvalidResetToken.Error()
+	case errors.Is(err, ErrPasswordPolicy):
+		return ErrPasswordPolicy.Error()
+	case errors.Is(err, ErrPasswordExpired):
+		return ErrPasswordExpired.Error()
+	case errors.Is(err, ErrInvalidChangeToken):
+		return ErrInvalidChangeToken.Error()
+	case errors.Is(err, ErrInvalidMfaCode):
+		return ErrInvalidMfaCode.Error()
+	case errors.Is(err, ErrMfaAlreadyEnabled):
+		return ErrMfaAlreadyEnabled.Error()
+	case errors.Is(err, ErrMfaNotEnabled):
+		return ErrMfaNotEnabled.Error()
+	case errors.Is(err, ErrMfaNotEnrolled):
+		return ErrMfaNotEnrolled.Error()
+	case errors.Is(err, ErrInvalidPasskeyChallenge):
+		return ErrInvalidPasskeyChallenge.Error()
+	case errors.Is(err, ErrInvalidPasskey):
+		return ErrInvalidPasskey.Error()
+	case errors.Is(err, ErrPasskeyAlreadyRegistered):
+		return ErrPasskeyAlreadyRegistered.Error()
+	default:
+		return auditDetailsFailed
+	}
+}
+
+func (userFacade *UserFacade) GetAuditEvents(ctx context.Context, filter *AuditEventFilter) ([]*adapter.AuditEventDTO, error) {
+	events, err := userFacade.dbConnection.GetAuditEvents(ctx, &db.AuditEventFilterDB{
+		UserId:     filter.UserId,
+		EventType:  filter.EventType,
+		Success:    filter.Success,
+		From:       filter.From,
+		To:         filter.To,
+		BeforeTime: filter.BeforeTime,
+		BeforeId:   filter.BeforeId,
+		Limit:      filter.Limit,
+		Offset:     filter.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while loading audit events: %v", err)
+	}
+
+	auditEvents := make([]*adapter.AuditEventDTO, len(events))
+	for i, event := range events {
+		auditEvents[i] = &adapter.AuditEventDTO{
+			Id:            event.Id,
+			EventTime:     event.EventTime,
+			EventType:     event.EventType,
+			UserId:        event.UserId,
+			Actor:         event.Actor,
+			Success:       event.Success,
+			Details:       event.Details,
+			IP:            event.IP,
+			UserAgent:     event.UserAgent,
+			CorrelationId: event.CorrelationId,
+		}
+	}
+	return auditEvents, nil
+}
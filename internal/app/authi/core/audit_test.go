@@ -0,0 +1,137 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit_LoginUserSuccessfully(t *testing.T) {
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, auditEnabled: true}
+	ctx := adapter.ContextWithCorrelationId(context.Background(), "someCorrelationId")
+
+	_, err = userFacade.LoginUser(ctx, userId, password, clientInfo)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.AddAuditEventRecordArray))
+	event := dbConnection.AddAuditEventRecordArray[0].Event
+	assert.Equal(t, AuditUserLogin, event.EventType)
+	assert.Equal(t, userId, event.UserId)
+	assert.Equal(t, userId.String(), event.Actor)
+	assert.True(t, event.Success)
+	assert.Empty(t, event.Details)
+	assert.Equal(t, clientInfo.IP, event.IP)
+	assert.Equal(t, clientInfo.UserAgent, event.UserAgent)
+	assert.Equal(t, "someCorrelationId", event.CorrelationId)
+	assert.WithinDuration(t, time.Now(), event.EventTime, time.Second)
+}
+
+func TestAudit_LoginUserLocked(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{LockedUntil: &lockedUntil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: &lockoutConfig{threshold: 3, duration: time.Minute, maxDuration: time.Hour}, auditEnabled: true}
+
+	_, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.ErrorIs(t, err, ErrUserLocked)
+	assert.Equal(t, 1, len(dbConnection.AddAuditEventRecordArray))
+	assert.False(t, dbConnection.AddAuditEventRecordArray[0].Event.Success)
+	assert.Equal(t, ErrUserLocked.Error(), dbConnection.AddAuditEventRecordArray[0].Event.Details)
+}
+
+func TestAudit_FailedLoginLocksUser(t *testing.T) {
+	dbConnection := &db.DBMock{
+		GetLockedUntilResponseArray:       []*db.GetLockedUntilResponse{{LockedUntil: nil}},
+		LoginUserResponseArray:            []*db.CheckUserResponse{{Err: errUnknown}},
+		IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 3}},
+		LockUserResponseArray:             []*db.ErrorResponse{{Err: nil}},
+		AddAuditEventResponseArray:        []*db.ErrorResponse{{Err: nil}, {Err: nil}},
+	}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: &lockoutConfig{threshold: 3, duration: time.Minute, maxDuration: time.Hour}, auditEnabled: true}
+
+	_, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 2, len(dbConnection.AddAuditEventRecordArray))
+	assert.Equal(t, AuditUserLocked, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+	assert.Contains(t, dbConnection.AddAuditEventRecordArray[0].Event.Details, "after 3 failed logins")
+	assert.Equal(t, AuditUserLogin, dbConnection.AddAuditEventRecordArray[1].Event.EventType)
+	assert.False(t, dbConnection.AddAuditEventRecordArray[1].Event.Success)
+	assert.Equal(t, auditDetailsFailed, dbConnection.AddAuditEventRecordArray[1].Event.Details)
+}
+
+func TestAudit_DeleteUserWithClientInfoOfContext(t *testing.T) {
+	dbConnection := &db.DBMock{DeleteUserResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, auditEnabled: true}
+	ctx := ContextWithClientInfo(context.Background(), clientInfo)
+
+	err := userFacade.DeleteUser(ctx, userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.AddAuditEventRecordArray))
+	assert.Equal(t, AuditUserDeleted, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+	assert.Equal(t, clientInfo.IP, dbConnection.AddAuditEventRecordArray[0].Event.IP)
+}
+
+func TestAudit_ActorOfContext(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, auditEnabled: true}
+
+	_, err = userFacade.UpdatePassword(ContextWithActor(context.Background(), ActorSystem), userId, password, "some new password")
+
+	assert.Nil(t, err)
+	assert.Equal(t, AuditPasswordChanged, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+	assert.Equal(t, ActorSystem, dbConnection.AddAuditEventRecordArray[0].Event.Actor)
+}
+
+func TestAudit_ErrorDoesNotFailAction(t *testing.T) {
+	dbConnection := &db.DBMock{DeleteUserResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, auditEnabled: true}
+
+	err := userFacade.DeleteUser(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.AddAuditEventRecordArray))
+}
+
+func TestAudit_Disabled(t *testing.T) {
+	dbConnection := &db.DBMock{DeleteUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.DeleteUser(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(dbConnection.AddAuditEventRecordArray))
+}
+
+func TestGetAuditEvents_Successfully(t *testing.T) {
+	success := true
+	event := &db.AuditEventDB{Id: 1, EventTime: time.Now(), EventType: AuditUserCreated, UserId: userId, Actor: ActorSystem, Success: true}
+	dbConnection := &db.DBMock{GetAuditEventsResponseArray: []*db.GetAuditEventsResponse{{Events: []*db.AuditEventDB{event}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	auditEvents, err := userFacade.GetAuditEvents(context.Background(), &AuditEventFilter{UserId: &userId, Success: &success, Limit: 10, Offset: 5})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []*adapter.AuditEventDTO{{Id: 1, EventTime: event.EventTime, EventType: AuditUserCreated, UserId: userId, Actor: ActorSystem, Success: true}}, auditEvents)
+	assert.Equal(t, &db.AuditEventFilterDB{UserId: &userId, Success: &success, Limit: 10, Offset: 5}, dbConnection.GetAuditEventsRecordArray[0].Filter)
+}
+
+func TestGetAuditEvents_UnknownError(t *testing.T) {
+	dbConnection := &db.DBMock{GetAuditEventsResponseArray: []*db.GetAuditEventsResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	auditEvents, err := userFacade.GetAuditEvents(context.Background(), &AuditEventFilter{Limit: 10})
+
+	assert.NotNil(t, err)
+	assert.Nil(t, auditEvents)
+}
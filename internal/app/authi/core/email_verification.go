@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/mail"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+const (
+	// Audience of verification tokens
+	emailVerificationAudience = "authi:email-verification"
+	emailVerificationSubject  = "Verify your email"
+)
+
+type (
+	emailVerificationConfig struct {
+		expireTime time.Duration
+		url        string
+	}
+	// Claims of the token in verification mails. The id is stored with the user, so only the latest token can be used and only once
+	emailVerificationClaims struct {
+		Email string `json:"email"`
+		jwt.StandardClaims
+	}
+)
+
+// Sends a mail with a signed verification token to the email of the user. Every mail replaces the token of the previous one
+func (userFacade *UserFacade) SendEmailVerification(ctx context.Context, userId uuid.UUID) error {
+	err := userFacade.sendEmailVerification(ctx, userId)
+	userFacade.audit(ctx, AuditEmailVerificationSent, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) sendEmailVerification(ctx context.Context, userId uuid.UUID) error {
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		return adminError("error while loading user for email verification", err)
+	}
+	if dbUser.Email == "" {
+		return ErrNoEmail
+	}
+	if dbUser.EmailVerified {
+		return ErrEmailAlreadyVerified
+	}
+
+	verificationId := randomString()
+	if err := userFacade.dbConnection.SetEmailVerificationId(ctx, userId, verificationId); err != nil {
+		return adminError("error while storing email verification", err)
+	}
+	token, err := userFacade.createEmailVerificationToken(userId, dbUser.Email, verificationId)
+	if err != nil {
+		return err
+	}
+
+	message := &mail.Message{To: dbUser.Email, Subject: emailVerificationSubject, Body: userFacade.emailVerificationBody(userId, token)}
+	if err := userFacade.mailSender.Send(ctx, message); err != nil {
+		return fmt.Errorf("error while sending verification mail: %v", err)
+	}
+	return nil
+}
+
+func (userFacade *UserFacade) createEmailVerificationToken(userId uuid.UUID, email string, verificationId string) (string, error) {
+	claims := &emailVerificationClaims{
+		Email: email,
+		StandardClaims: jwt.StandardClaims{
+			Id:        verificationId,
+			Subject:   userId.String(),
+			Audience:  emailVerificationAudience,
+			ExpiresAt: time.Now().Add(userFacade.emailVerification.expireTime).Unix(),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(userFacade.signKey)
+	if err != nil {
+		return "", fmt.Errorf("verification token creation failed: %v", err)
+	}
+	return token, nil
+}
+
+func (userFacade *UserFacade) emailVerificationBody(userId uuid.UUID, token string) string {
+	if userFacade.emailVerification.url == "" {
+		return "Please confirm your email with the following token:\n\n" + token
+	}
+	url := strings.NewReplacer("{userId}", userId.String(), "{token}", token).Replace(userFacade.emailVerification.url)
+	return "Please confirm your email by opening the following link:\n\n" + url
+}
+
+// Marks the email of the user as verified, if the token is valid, belongs to the user and its current email and wasn't used yet
+func (userFacade *UserFacade) VerifyEmail(ctx context.Context, userId uuid.UUID, token string) error {
+	err := userFacade.verifyEmail(ctx, userId, token)
+	userFacade.audit(ctx, AuditEmailVerified, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) verifyEmail(ctx context.Context, userId uuid.UUID, token string) error {
+	claims := &emailVerificationClaims{}
+	if err := userFacade.parseAudienceToken(token, emailVerificationAudience, claims); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidVerificationToken, err)
+	}
+	if claims.Subject != userId.String() {
+		return fmt.Errorf("%w: token doesn't belong to user %v", ErrInvalidVerificationToken, userId)
+	}
+
+	if err := userFacade.dbConnection.VerifyEmail(ctx, userId, claims.Id, claims.Email); err != nil {
+		if errors.Is(err, db.ErrVerificationUsed) {
+			return fmt.Errorf("%w: %v", ErrInvalidVerificationToken, err)
+		}
+		return fmt.Errorf("error while verifying email: %v", err)
+	}
+	return nil
+}
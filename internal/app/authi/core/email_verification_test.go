@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/mail"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	emailVerification = &emailVerificationConfig{expireTime: time.Hour, url: "https://example.org/verify?user={userId}&token={token}"}
+)
+
+func newEmailVerificationFacade(t *testing.T, dbConnection db.Connection, mailSender mail.Sender) *UserFacade {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	return &UserFacade{dbConnection: dbConnection, signKey: signKey, mailSender: mailSender, emailVerification: emailVerification}
+}
+
+func TestSendEmailVerification_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Email: "max@example.org"}}}, SetEmailVerificationIdResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	mailSender := &mail.SenderMock{SendResponseArray: []*mail.SendResponse{{Err: nil}}}
+	userFacade := newEmailVerificationFacade(t, dbConnection, mailSender)
+
+	err := userFacade.SendEmailVerification(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, userId, dbConnection.SetEmailVerificationIdRecordArray[0].UserId)
+	assert.Len(t, dbConnection.SetEmailVerificationIdRecordArray[0].VerificationId, 32)
+	message := mailSender.SendRecordArray[0].Message
+	assert.Equal(t, "max@example.org", message.To)
+	assert.Contains(t, message.Body, "https://example.org/verify?user="+userId.String()+"&token=")
+
+	token := message.Body[strings.Index(message.Body, "&token=")+len("&token="):]
+	claims := &emailVerificationClaims{}
+	_, err = jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return &userFacade.signKey.PublicKey, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, userId.String(), claims.Subject)
+	assert.Equal(t, emailVerificationAudience, claims.Audience)
+	assert.Equal(t, "max@example.org", claims.Email)
+	assert.Equal(t, dbConnection.SetEmailVerificationIdRecordArray[0].VerificationId, claims.Id)
+}
+
+func TestSendEmailVerification_WithoutEmail(t *testing.T) {
+	for user, expectedErr := range map[*db.UserDB]error{
+		{ID: userId}: ErrNoEmail,
+		{ID: userId, Email: "max@example.org", EmailVerified: true}: ErrEmailAlreadyVerified,
+	} {
+		dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{User: user}}}
+		mailSender := &mail.SenderMock{}
+		userFacade := newEmailVerificationFacade(t, dbConnection, mailSender)
+
+		err := userFacade.SendEmailVerification(context.Background(), userId)
+
+		assert.ErrorIs(t, err, expectedErr)
+		assert.Equal(t, 0, len(dbConnection.SetEmailVerificationIdRecordArray))
+		assert.Equal(t, 0, len(mailSender.SendRecordArray))
+	}
+}
+
+func TestSendEmailVerification_UserNotFound(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{Err: db.ErrUserNotFound}}}
+	userFacade := newEmailVerificationFacade(t, dbConnection, &mail.SenderMock{})
+
+	err := userFacade.SendEmailVerification(context.Background(), userId)
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestVerifyEmail_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{VerifyEmailResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newEmailVerificationFacade(t, dbConnection, &mail.SenderMock{})
+	token, err := userFacade.createEmailVerificationToken(userId, "max@example.org", "someVerificationId")
+	assert.Nil(t, err)
+
+	err = userFacade.VerifyEmail(context.Background(), userId, token)
+
+	assert.Nil(t, err)
+	assert.Equal(t, &db.VerifyEmailRecord{UserId: userId, VerificationId: "someVerificationId", Email: "max@example.org"}, dbConnection.VerifyEmailRecordArray[0])
+}
+
+func TestVerifyEmail_AlreadyUsed(t *testing.T) {
+	dbConnection := &db.DBMock{VerifyEmailResponseArray: []*db.ErrorResponse{{Err: db.ErrVerificationUsed}}}
+	userFacade := newEmailVerificationFacade(t, dbConnection, &mail.SenderMock{})
+	token, err := userFacade.createEmailVerificationToken(userId, "max@example.org", "someVerificationId")
+	assert.Nil(t, err)
+
+	err = userFacade.VerifyEmail(context.Background(), userId, token)
+
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+}
+
+func TestVerifyEmail_InvalidToken(t *testing.T) {
+	userFacade := newEmailVerificationFacade(t, &db.DBMock{}, &mail.SenderMock{})
+	otherUserToken, err := userFacade.createEmailVerificationToken(uuid.New(), "max@example.org", "someVerificationId")
+	assert.Nil(t, err)
+	expiredFacade := newEmailVerificationFacade(t, &db.DBMock{}, &mail.SenderMock{})
+	expiredFacade.emailVerification = &emailVerificationConfig{expireTime: -time.Minute}
+	expiredToken, err := expiredFacade.createEmailVerificationToken(userId, "max@example.org", "someVerificationId")
+	assert.Nil(t, err)
+	userFacade.dbConnection = &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	accessToken, err := userFacade.createJWTToken(context.Background(), &db.UserDB{ID: userId})
+	assert.Nil(t, err)
+
+	for _, token := range []string{"someToken", otherUserToken, expiredToken, accessToken.AccessToken} {
+		dbConnection := &db.DBMock{}
+		userFacade.dbConnection = dbConnection
+
+		err := userFacade.VerifyEmail(context.Background(), userId, token)
+
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+		assert.Equal(t, 0, len(dbConnection.VerifyEmailRecordArray))
+	}
+}
+
+func TestCreateJWTToken_EmailVerifiedClaim(t *testing.T) {
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newEmailVerificationFacade(t, dbConnection, &mail.SenderMock{})
+
+	token, err := userFacade.createJWTToken(context.Background(), &db.UserDB{ID: userId, EmailVerified: true})
+	assert.Nil(t, err)
+
+	claims := &adapter.Claims{}
+	_, err = jwt.ParseWithClaims(token.AccessToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return &userFacade.signKey.PublicKey, nil
+	})
+	assert.Nil(t, err)
+	assert.True(t, claims.EmailVerified)
+}
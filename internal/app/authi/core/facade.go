@@ -1,7 +1,9 @@
 package core
 
 import (
+	"context"
 	"crypto/rand"
+	"errors"
 
 	"github.com/BeanCodeDe/authi/pkg/adapter"
 	"github.com/google/uuid"
@@ -9,12 +11,67 @@ import (
 
 type (
 	Facade interface {
-		CreateUser(userId uuid.UUID, password string, initUser bool) error
-		LoginUser(userId uuid.UUID, password string) (*adapter.TokenResponseDTO, error)
-		RefreshToken(userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error)
-		UpdatePassword(userId uuid.UUID, password string) error
-		DeleteUser(userId uuid.UUID) error
+		CreateUser(ctx context.Context, userId uuid.UUID, password string, identifiers *UserIdentifiers, initUser bool) error
+		LoginUser(ctx context.Context, userId uuid.UUID, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error)
+		LoginUserByIdentifier(ctx context.Context, identifier string, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error)
+		RefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error)
+		UpdatePassword(ctx context.Context, userId uuid.UUID, currentPassword string, password string) (*adapter.TokenResponseDTO, error)
+		ChangeExpiredPassword(ctx context.Context, userId uuid.UUID, changeToken string, currentPassword string, password string) (*adapter.TokenResponseDTO, error)
+		LoginWithMfa(ctx context.Context, challengeToken string, code string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error)
+		EnrollTotp(ctx context.Context, userId uuid.UUID) (*adapter.TotpEnrollmentDTO, error)
+		ConfirmTotp(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error)
+		DisableTotp(ctx context.Context, userId uuid.UUID, code string) error
+		RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error)
+		BeginPasskeyRegistration(ctx context.Context, userId uuid.UUID, password string) (*adapter.PasskeyOptionsDTO, error)
+		FinishPasskeyRegistration(ctx context.Context, userId uuid.UUID, challengeToken string, credential []byte) error
+		BeginPasskeyLogin(ctx context.Context) (*adapter.PasskeyOptionsDTO, error)
+		LoginWithPasskey(ctx context.Context, challengeToken string, credential []byte, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error)
+		DeleteUser(ctx context.Context, userId uuid.UUID) error
+		GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*adapter.LoginHistoryDTO, error)
+		UnlockUser(ctx context.Context, userId uuid.UUID) error
+		GetAuditEvents(ctx context.Context, filter *AuditEventFilter) ([]*adapter.AuditEventDTO, error)
+		GetUsers(ctx context.Context, filter *UserFilter) ([]*adapter.UserDTO, error)
+		GetUser(ctx context.Context, userId uuid.UUID) (*adapter.UserDTO, error)
+		SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string) error
+		RevokeSessions(ctx context.Context, userId uuid.UUID) error
+		ResetPassword(ctx context.Context, userId uuid.UUID, password string) error
+		SendEmailVerification(ctx context.Context, userId uuid.UUID) error
+		VerifyEmail(ctx context.Context, userId uuid.UUID, token string) error
+		RequestPasswordReset(ctx context.Context, identifier string)
+		ConfirmPasswordReset(ctx context.Context, token string, password string) error
+		CheckSignKey() error
 	}
+
+	ClientInfo struct {
+		IP        string
+		UserAgent string
+	}
+)
+
+var (
+	ErrUserLocked               = errors.New("user is locked")
+	ErrUserDisabled             = errors.New("user is disabled")
+	ErrUserPending              = errors.New("user is not activated yet")
+	ErrUserNotFound             = errors.New("user not found")
+	ErrUnknownUserStatus        = errors.New("unknown user status")
+	ErrIdentifierTaken          = errors.New("username or email already taken")
+	ErrInvalidIdentifier        = errors.New("invalid username or email")
+	ErrNoEmail                  = errors.New("user has no email")
+	ErrEmailAlreadyVerified     = errors.New("email is already verified")
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+	ErrInvalidResetToken        = errors.New("invalid password reset token")
+	ErrPasswordExpired          = errors.New("password expired")
+	ErrInvalidChangeToken       = errors.New("invalid password change token")
+	ErrMfaRequired              = errors.New("mfa required")
+	ErrInvalidMfaChallenge      = errors.New("invalid mfa challenge token")
+	ErrInvalidMfaCode           = errors.New("invalid mfa code")
+	ErrMfaAlreadyEnabled        = errors.New("mfa is already enabled")
+	ErrMfaNotEnabled            = errors.New("mfa is not enabled")
+	ErrMfaNotEnrolled           = errors.New("mfa is not enrolled")
+	ErrPasskeysDisabled         = errors.New("passkeys are not configured")
+	ErrInvalidPasskeyChallenge  = errors.New("invalid passkey challenge token")
+	ErrInvalidPasskey           = errors.New("invalid passkey credential")
+	ErrPasskeyAlreadyRegistered = errors.New("passkey is already registered")
 )
 
 const alphaNum = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
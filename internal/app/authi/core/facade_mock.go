@@ -1,6 +1,7 @@
 package core
 
 import (
+	"context"
 	"github.com/BeanCodeDe/authi/pkg/adapter"
 	"github.com/google/uuid"
 )
@@ -10,9 +11,103 @@ type (
 	}
 
 	AuthenticateRecord struct {
-		UserId   uuid.UUID
+		UserId          uuid.UUID
+		Identifier      string
+		CurrentPassword string
+		ChangeToken     string
+		Password        string
+		Identifiers     *UserIdentifiers
+		InitUser        bool
+		ClientInfo      *ClientInfo
+	}
+
+	UnlockUserRecord struct {
+		UserId uuid.UUID
+	}
+
+	GetLoginHistoryRecord struct {
+		UserId uuid.UUID
+	}
+
+	GetLoginHistoryResponse struct {
+		LoginHistory []*adapter.LoginHistoryDTO
+		Err          error
+	}
+
+	GetAuditEventsRecord struct {
+		Filter *AuditEventFilter
+	}
+
+	GetAuditEventsResponse struct {
+		AuditEvents []*adapter.AuditEventDTO
+		Err         error
+	}
+
+	GetUsersRecord struct {
+		Filter *UserFilter
+	}
+
+	GetUsersResponse struct {
+		Users []*adapter.UserDTO
+		Err   error
+	}
+
+	UserIdRecord struct {
+		UserId uuid.UUID
+	}
+
+	SetUserStatusRecord struct {
+		UserId uuid.UUID
+		Status string
+		Reason string
+	}
+
+	VerifyEmailRecord struct {
+		UserId uuid.UUID
+		Token  string
+	}
+
+	RequestPasswordResetRecord struct {
+		Identifier string
+	}
+
+	ConfirmPasswordResetRecord struct {
+		Token    string
 		Password string
-		InitUser bool
+	}
+
+	MfaCodeRecord struct {
+		UserId         uuid.UUID
+		ChallengeToken string
+		Code           string
+		ClientInfo     *ClientInfo
+	}
+
+	EnrollTotpResponse struct {
+		Enrollment *adapter.TotpEnrollmentDTO
+		Err        error
+	}
+
+	PasskeyCredentialRecord struct {
+		UserId         uuid.UUID
+		ChallengeToken string
+		Credential     []byte
+		ClientInfo     *ClientInfo
+	}
+
+	PasskeyOptionsResponse struct {
+		Options *adapter.PasskeyOptionsDTO
+		Err     error
+	}
+
+	RecoveryCodesResponse struct {
+		RecoveryCodes *adapter.RecoveryCodesDTO
+		Err           error
+	}
+
+	GetUserResponse struct {
+		User *adapter.UserDTO
+		Err  error
 	}
 
 	RefreshTokenRecord struct {
@@ -33,50 +128,174 @@ type (
 	}
 
 	CoreMock struct {
-		CreateUserRecordArray        []*AuthenticateRecord
-		LoginUserRecordArray         []*AuthenticateRecord
-		RefreshTokenRecordArray      []*RefreshTokenRecord
-		UpdatePasswordRecordArray    []*AuthenticateRecord
-		DeleteUserRecordArray        []*DeleteUserRecord
-		DeleteInitUsersRecordArray   []*EmptyRecord
-		CreateUserResponseArray      []*ErrorResponse
-		LoginUserResponseArray       []*AuthenticateResponse
-		RefreshTokenResponseArray    []*AuthenticateResponse
-		UpdatePasswordResponseArray  []*ErrorResponse
-		DeleteUserResponseArray      []*ErrorResponse
-		DeleteInitUsersResponseArray []*ErrorResponse
+		CreateUserRecordArray                  []*AuthenticateRecord
+		LoginUserRecordArray                   []*AuthenticateRecord
+		RefreshTokenRecordArray                []*RefreshTokenRecord
+		UpdatePasswordRecordArray              []*AuthenticateRecord
+		DeleteUserRecordArray                  []*DeleteUserRecord
+		DeleteInitUsersRecordArray             []*EmptyRecord
+		CreateUserResponseArray                []*ErrorResponse
+		LoginUserResponseArray                 []*AuthenticateResponse
+		RefreshTokenResponseArray              []*AuthenticateResponse
+		UpdatePasswordResponseArray            []*AuthenticateResponse
+		ChangeExpiredPasswordRecordArray       []*AuthenticateRecord
+		ChangeExpiredPasswordResponseArray     []*AuthenticateResponse
+		DeleteUserResponseArray                []*ErrorResponse
+		DeleteInitUsersResponseArray           []*ErrorResponse
+		GetLoginHistoryRecordArray             []*GetLoginHistoryRecord
+		GetLoginHistoryResponseArray           []*GetLoginHistoryResponse
+		UnlockUserRecordArray                  []*UnlockUserRecord
+		UnlockUserResponseArray                []*ErrorResponse
+		CheckSignKeyRecordArray                []*EmptyRecord
+		CheckSignKeyResponseArray              []*ErrorResponse
+		GetAuditEventsRecordArray              []*GetAuditEventsRecord
+		GetAuditEventsResponseArray            []*GetAuditEventsResponse
+		GetUsersRecordArray                    []*GetUsersRecord
+		GetUsersResponseArray                  []*GetUsersResponse
+		GetUserRecordArray                     []*UserIdRecord
+		GetUserResponseArray                   []*GetUserResponse
+		SetUserStatusRecordArray               []*SetUserStatusRecord
+		SetUserStatusResponseArray             []*ErrorResponse
+		RevokeSessionsRecordArray              []*UserIdRecord
+		RevokeSessionsResponseArray            []*ErrorResponse
+		ResetPasswordRecordArray               []*AuthenticateRecord
+		ResetPasswordResponseArray             []*ErrorResponse
+		LoginUserByIdentifierRecordArray       []*AuthenticateRecord
+		LoginUserByIdentifierResponseArray     []*AuthenticateResponse
+		SendEmailVerificationRecordArray       []*UserIdRecord
+		SendEmailVerificationResponseArray     []*ErrorResponse
+		VerifyEmailRecordArray                 []*VerifyEmailRecord
+		VerifyEmailResponseArray               []*ErrorResponse
+		RequestPasswordResetRecordArray        []*RequestPasswordResetRecord
+		ConfirmPasswordResetRecordArray        []*ConfirmPasswordResetRecord
+		ConfirmPasswordResetResponseArray      []*ErrorResponse
+		LoginWithMfaRecordArray                []*MfaCodeRecord
+		LoginWithMfaResponseArray              []*AuthenticateResponse
+		EnrollTotpRecordArray                  []*UserIdRecord
+		EnrollTotpResponseArray                []*EnrollTotpResponse
+		ConfirmTotpRecordArray                 []*MfaCodeRecord
+		ConfirmTotpResponseArray               []*RecoveryCodesResponse
+		DisableTotpRecordArray                 []*MfaCodeRecord
+		DisableTotpResponseArray               []*ErrorResponse
+		RegenerateRecoveryCodesRecordArray     []*MfaCodeRecord
+		RegenerateRecoveryCodesResponseArray   []*RecoveryCodesResponse
+		BeginPasskeyRegistrationRecordArray    []*AuthenticateRecord
+		BeginPasskeyRegistrationResponseArray  []*PasskeyOptionsResponse
+		FinishPasskeyRegistrationRecordArray   []*PasskeyCredentialRecord
+		FinishPasskeyRegistrationResponseArray []*ErrorResponse
+		BeginPasskeyLoginRecordArray           []*EmptyRecord
+		BeginPasskeyLoginResponseArray         []*PasskeyOptionsResponse
+		LoginWithPasskeyRecordArray            []*PasskeyCredentialRecord
+		LoginWithPasskeyResponseArray          []*AuthenticateResponse
 	}
 )
 
-func (mock *CoreMock) CreateUser(userId uuid.UUID, password string, initUser bool) error {
-	record := &AuthenticateRecord{UserId: userId, Password: password, InitUser: initUser}
+func (mock *CoreMock) CreateUser(ctx context.Context, userId uuid.UUID, password string, identifiers *UserIdentifiers, initUser bool) error {
+	record := &AuthenticateRecord{UserId: userId, Password: password, Identifiers: identifiers, InitUser: initUser}
 	mock.CreateUserRecordArray = append(mock.CreateUserRecordArray, record)
 	response := mock.CreateUserResponseArray[len(mock.CreateUserRecordArray)-1]
 	return response.Err
 }
 
-func (mock *CoreMock) LoginUser(userId uuid.UUID, password string) (*adapter.TokenResponseDTO, error) {
-	record := &AuthenticateRecord{UserId: userId, Password: password}
+func (mock *CoreMock) LoginUser(ctx context.Context, userId uuid.UUID, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	record := &AuthenticateRecord{UserId: userId, Password: password, ClientInfo: clientInfo}
 	mock.LoginUserRecordArray = append(mock.LoginUserRecordArray, record)
 	response := mock.LoginUserResponseArray[len(mock.LoginUserRecordArray)-1]
 	return response.TokenResponse, response.Err
 }
 
-func (mock *CoreMock) RefreshToken(userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error) {
+func (mock *CoreMock) LoginUserByIdentifier(ctx context.Context, identifier string, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	record := &AuthenticateRecord{Identifier: identifier, Password: password, ClientInfo: clientInfo}
+	mock.LoginUserByIdentifierRecordArray = append(mock.LoginUserByIdentifierRecordArray, record)
+	response := mock.LoginUserByIdentifierResponseArray[len(mock.LoginUserByIdentifierRecordArray)-1]
+	return response.TokenResponse, response.Err
+}
+
+func (mock *CoreMock) RefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error) {
 	record := &RefreshTokenRecord{UserId: userId, RefreshToken: refreshToken}
 	mock.RefreshTokenRecordArray = append(mock.RefreshTokenRecordArray, record)
 	response := mock.RefreshTokenResponseArray[len(mock.RefreshTokenRecordArray)-1]
 	return response.TokenResponse, response.Err
 }
 
-func (mock *CoreMock) UpdatePassword(userId uuid.UUID, password string) error {
-	record := &AuthenticateRecord{UserId: userId, Password: password}
+func (mock *CoreMock) UpdatePassword(ctx context.Context, userId uuid.UUID, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	record := &AuthenticateRecord{UserId: userId, CurrentPassword: currentPassword, Password: password}
 	mock.UpdatePasswordRecordArray = append(mock.UpdatePasswordRecordArray, record)
 	response := mock.UpdatePasswordResponseArray[len(mock.UpdatePasswordRecordArray)-1]
+	return response.TokenResponse, response.Err
+}
+
+func (mock *CoreMock) ChangeExpiredPassword(ctx context.Context, userId uuid.UUID, changeToken string, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	record := &AuthenticateRecord{UserId: userId, ChangeToken: changeToken, CurrentPassword: currentPassword, Password: password}
+	mock.ChangeExpiredPasswordRecordArray = append(mock.ChangeExpiredPasswordRecordArray, record)
+	response := mock.ChangeExpiredPasswordResponseArray[len(mock.ChangeExpiredPasswordRecordArray)-1]
+	return response.TokenResponse, response.Err
+}
+
+func (mock *CoreMock) LoginWithMfa(ctx context.Context, challengeToken string, code string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	record := &MfaCodeRecord{ChallengeToken: challengeToken, Code: code, ClientInfo: clientInfo}
+	mock.LoginWithMfaRecordArray = append(mock.LoginWithMfaRecordArray, record)
+	response := mock.LoginWithMfaResponseArray[len(mock.LoginWithMfaRecordArray)-1]
+	return response.TokenResponse, response.Err
+}
+
+func (mock *CoreMock) EnrollTotp(ctx context.Context, userId uuid.UUID) (*adapter.TotpEnrollmentDTO, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.EnrollTotpRecordArray = append(mock.EnrollTotpRecordArray, record)
+	response := mock.EnrollTotpResponseArray[len(mock.EnrollTotpRecordArray)-1]
+	return response.Enrollment, response.Err
+}
+
+func (mock *CoreMock) ConfirmTotp(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	record := &MfaCodeRecord{UserId: userId, Code: code}
+	mock.ConfirmTotpRecordArray = append(mock.ConfirmTotpRecordArray, record)
+	response := mock.ConfirmTotpResponseArray[len(mock.ConfirmTotpRecordArray)-1]
+	return response.RecoveryCodes, response.Err
+}
+
+func (mock *CoreMock) DisableTotp(ctx context.Context, userId uuid.UUID, code string) error {
+	record := &MfaCodeRecord{UserId: userId, Code: code}
+	mock.DisableTotpRecordArray = append(mock.DisableTotpRecordArray, record)
+	response := mock.DisableTotpResponseArray[len(mock.DisableTotpRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	record := &MfaCodeRecord{UserId: userId, Code: code}
+	mock.RegenerateRecoveryCodesRecordArray = append(mock.RegenerateRecoveryCodesRecordArray, record)
+	response := mock.RegenerateRecoveryCodesResponseArray[len(mock.RegenerateRecoveryCodesRecordArray)-1]
+	return response.RecoveryCodes, response.Err
+}
+
+func (mock *CoreMock) BeginPasskeyRegistration(ctx context.Context, userId uuid.UUID, password string) (*adapter.PasskeyOptionsDTO, error) {
+	record := &AuthenticateRecord{UserId: userId, Password: password}
+	mock.BeginPasskeyRegistrationRecordArray = append(mock.BeginPasskeyRegistrationRecordArray, record)
+	response := mock.BeginPasskeyRegistrationResponseArray[len(mock.BeginPasskeyRegistrationRecordArray)-1]
+	return response.Options, response.Err
+}
+
+func (mock *CoreMock) FinishPasskeyRegistration(ctx context.Context, userId uuid.UUID, challengeToken string, credential []byte) error {
+	record := &PasskeyCredentialRecord{UserId: userId, ChallengeToken: challengeToken, Credential: credential}
+	mock.FinishPasskeyRegistrationRecordArray = append(mock.FinishPasskeyRegistrationRecordArray, record)
+	response := mock.FinishPasskeyRegistrationResponseArray[len(mock.FinishPasskeyRegistrationRecordArray)-1]
 	return response.Err
 }
 
-func (mock *CoreMock) DeleteUser(userId uuid.UUID) error {
+func (mock *CoreMock) BeginPasskeyLogin(ctx context.Context) (*adapter.PasskeyOptionsDTO, error) {
+	record := &EmptyRecord{}
+	mock.BeginPasskeyLoginRecordArray = append(mock.BeginPasskeyLoginRecordArray, record)
+	response := mock.BeginPasskeyLoginResponseArray[len(mock.BeginPasskeyLoginRecordArray)-1]
+	return response.Options, response.Err
+}
+
+func (mock *CoreMock) LoginWithPasskey(ctx context.Context, challengeToken string, credential []byte, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	record := &PasskeyCredentialRecord{ChallengeToken: challengeToken, Credential: credential, ClientInfo: clientInfo}
+	mock.LoginWithPasskeyRecordArray = append(mock.LoginWithPasskeyRecordArray, record)
+	response := mock.LoginWithPasskeyResponseArray[len(mock.LoginWithPasskeyRecordArray)-1]
+	return response.TokenResponse, response.Err
+}
+
+func (mock *CoreMock) DeleteUser(ctx context.Context, userId uuid.UUID) error {
 	record := &DeleteUserRecord{UserId: userId}
 	mock.DeleteUserRecordArray = append(mock.DeleteUserRecordArray, record)
 	response := mock.DeleteUserResponseArray[len(mock.DeleteUserRecordArray)-1]
@@ -89,3 +308,92 @@ func (mock *CoreMock) DeleteInitUsers() error {
 	response := mock.DeleteInitUsersResponseArray[len(mock.DeleteInitUsersRecordArray)-1]
 	return response.Err
 }
+
+func (mock *CoreMock) GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*adapter.LoginHistoryDTO, error) {
+	record := &GetLoginHistoryRecord{UserId: userId}
+	mock.GetLoginHistoryRecordArray = append(mock.GetLoginHistoryRecordArray, record)
+	response := mock.GetLoginHistoryResponseArray[len(mock.GetLoginHistoryRecordArray)-1]
+	return response.LoginHistory, response.Err
+}
+
+func (mock *CoreMock) UnlockUser(ctx context.Context, userId uuid.UUID) error {
+	record := &UnlockUserRecord{UserId: userId}
+	mock.UnlockUserRecordArray = append(mock.UnlockUserRecordArray, record)
+	response := mock.UnlockUserResponseArray[len(mock.UnlockUserRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) GetAuditEvents(ctx context.Context, filter *AuditEventFilter) ([]*adapter.AuditEventDTO, error) {
+	record := &GetAuditEventsRecord{Filter: filter}
+	mock.GetAuditEventsRecordArray = append(mock.GetAuditEventsRecordArray, record)
+	response := mock.GetAuditEventsResponseArray[len(mock.GetAuditEventsRecordArray)-1]
+	return response.AuditEvents, response.Err
+}
+
+func (mock *CoreMock) GetUsers(ctx context.Context, filter *UserFilter) ([]*adapter.UserDTO, error) {
+	record := &GetUsersRecord{Filter: filter}
+	mock.GetUsersRecordArray = append(mock.GetUsersRecordArray, record)
+	response := mock.GetUsersResponseArray[len(mock.GetUsersRecordArray)-1]
+	return response.Users, response.Err
+}
+
+func (mock *CoreMock) GetUser(ctx context.Context, userId uuid.UUID) (*adapter.UserDTO, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.GetUserRecordArray = append(mock.GetUserRecordArray, record)
+	response := mock.GetUserResponseArray[len(mock.GetUserRecordArray)-1]
+	return response.User, response.Err
+}
+
+func (mock *CoreMock) SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string) error {
+	record := &SetUserStatusRecord{UserId: userId, Status: status, Reason: reason}
+	mock.SetUserStatusRecordArray = append(mock.SetUserStatusRecordArray, record)
+	response := mock.SetUserStatusResponseArray[len(mock.SetUserStatusRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) RevokeSessions(ctx context.Context, userId uuid.UUID) error {
+	record := &UserIdRecord{UserId: userId}
+	mock.RevokeSessionsRecordArray = append(mock.RevokeSessionsRecordArray, record)
+	response := mock.RevokeSessionsResponseArray[len(mock.RevokeSessionsRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) ResetPassword(ctx context.Context, userId uuid.UUID, password string) error {
+	record := &AuthenticateRecord{UserId: userId, Password: password}
+	mock.ResetPasswordRecordArray = append(mock.ResetPasswordRecordArray, record)
+	response := mock.ResetPasswordResponseArray[len(mock.ResetPasswordRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) SendEmailVerification(ctx context.Context, userId uuid.UUID) error {
+	record := &UserIdRecord{UserId: userId}
+	mock.SendEmailVerificationRecordArray = append(mock.SendEmailVerificationRecordArray, record)
+	response := mock.SendEmailVerificationResponseArray[len(mock.SendEmailVerificationRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) VerifyEmail(ctx context.Context, userId uuid.UUID, token string) error {
+	record := &VerifyEmailRecord{UserId: userId, Token: token}
+	mock.VerifyEmailRecordArray = append(mock.VerifyEmailRecordArray, record)
+	response := mock.VerifyEmailResponseArray[len(mock.VerifyEmailRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) RequestPasswordReset(ctx context.Context, identifier string) {
+	record := &RequestPasswordResetRecord{Identifier: identifier}
+	mock.RequestPasswordResetRecordArray = append(mock.RequestPasswordResetRecordArray, record)
+}
+
+func (mock *CoreMock) ConfirmPasswordReset(ctx context.Context, token string, password string) error {
+	record := &ConfirmPasswordResetRecord{Token: token, Password: password}
+	mock.ConfirmPasswordResetRecordArray = append(mock.ConfirmPasswordResetRecordArray, record)
+	response := mock.ConfirmPasswordResetResponseArray[len(mock.ConfirmPasswordResetRecordArray)-1]
+	return response.Err
+}
+
+func (mock *CoreMock) CheckSignKey() error {
+	record := &EmptyRecord{}
+	mock.CheckSignKeyRecordArray = append(mock.CheckSignKeyRecordArray, record)
+	response := mock.CheckSignKeyResponseArray[len(mock.CheckSignKeyRecordArray)-1]
+	return response.Err
+}
@@ -3,6 +3,7 @@ package core
 import (
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/BeanCodeDe/authi/pkg/adapter"
 	"github.com/google/uuid"
@@ -14,6 +15,8 @@ var (
 	refreshToken   = "someRefreshToken"
 	password       = "some password"
 	authenticate   = &adapter.AuthenticateDTO{Password: password}
+	clientInfo     = &ClientInfo{IP: "192.0.2.1", UserAgent: "some agent"}
+	lockout        = &lockoutConfig{threshold: 3, duration: time.Minute, maxDuration: 10 * time.Minute}
 	privateKeyPath = "../../../../deployments/data/token/jwtRS256.key"
 	errUnknown     = errors.New("some error")
 )
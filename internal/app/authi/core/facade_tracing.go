@@ -0,0 +1,245 @@
+package core
+
+import (
+	"context"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/tracing"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	userIdAttribute = attribute.Key("authi.user_id")
+)
+
+type (
+	// Facade that records a span for every call of the wrapped facade
+	TracedFacade struct {
+		facade Facade
+	}
+)
+
+func NewTracedFacade(facade Facade) *TracedFacade {
+	return &TracedFacade{facade: facade}
+}
+
+func startSpan(ctx context.Context, name string, userId uuid.UUID) (context.Context, trace.Span) {
+	if userId == uuid.Nil {
+		return tracing.Tracer().Start(ctx, "Facade."+name)
+	}
+	return tracing.Tracer().Start(ctx, "Facade."+name, trace.WithAttributes(userIdAttribute.String(userId.String())))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (tracedFacade *TracedFacade) CreateUser(ctx context.Context, userId uuid.UUID, password string, identifiers *UserIdentifiers, initUser bool) error {
+	ctx, span := startSpan(ctx, "CreateUser", userId)
+	err := tracedFacade.facade.CreateUser(ctx, userId, password, identifiers, initUser)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) LoginUser(ctx context.Context, userId uuid.UUID, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "LoginUser", userId)
+	token, err := tracedFacade.facade.LoginUser(ctx, userId, password, clientInfo)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) LoginUserByIdentifier(ctx context.Context, identifier string, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "LoginUserByIdentifier", uuid.Nil)
+	token, err := tracedFacade.facade.LoginUserByIdentifier(ctx, identifier, password, clientInfo)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) RefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "RefreshToken", userId)
+	token, err := tracedFacade.facade.RefreshToken(ctx, userId, refreshToken)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) UpdatePassword(ctx context.Context, userId uuid.UUID, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "UpdatePassword", userId)
+	token, err := tracedFacade.facade.UpdatePassword(ctx, userId, currentPassword, password)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) ChangeExpiredPassword(ctx context.Context, userId uuid.UUID, changeToken string, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "ChangeExpiredPassword", userId)
+	token, err := tracedFacade.facade.ChangeExpiredPassword(ctx, userId, changeToken, currentPassword, password)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) LoginWithMfa(ctx context.Context, challengeToken string, code string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "LoginWithMfa", uuid.Nil)
+	token, err := tracedFacade.facade.LoginWithMfa(ctx, challengeToken, code, clientInfo)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) EnrollTotp(ctx context.Context, userId uuid.UUID) (*adapter.TotpEnrollmentDTO, error) {
+	ctx, span := startSpan(ctx, "EnrollTotp", userId)
+	enrollment, err := tracedFacade.facade.EnrollTotp(ctx, userId)
+	endSpan(span, err)
+	return enrollment, err
+}
+
+func (tracedFacade *TracedFacade) ConfirmTotp(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	ctx, span := startSpan(ctx, "ConfirmTotp", userId)
+	recoveryCodes, err := tracedFacade.facade.ConfirmTotp(ctx, userId, code)
+	endSpan(span, err)
+	return recoveryCodes, err
+}
+
+func (tracedFacade *TracedFacade) DisableTotp(ctx context.Context, userId uuid.UUID, code string) error {
+	ctx, span := startSpan(ctx, "DisableTotp", userId)
+	err := tracedFacade.facade.DisableTotp(ctx, userId, code)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	ctx, span := startSpan(ctx, "RegenerateRecoveryCodes", userId)
+	recoveryCodes, err := tracedFacade.facade.RegenerateRecoveryCodes(ctx, userId, code)
+	endSpan(span, err)
+	return recoveryCodes, err
+}
+
+func (tracedFacade *TracedFacade) BeginPasskeyRegistration(ctx context.Context, userId uuid.UUID, password string) (*adapter.PasskeyOptionsDTO, error) {
+	ctx, span := startSpan(ctx, "BeginPasskeyRegistration", userId)
+	options, err := tracedFacade.facade.BeginPasskeyRegistration(ctx, userId, password)
+	endSpan(span, err)
+	return options, err
+}
+
+func (tracedFacade *TracedFacade) FinishPasskeyRegistration(ctx context.Context, userId uuid.UUID, challengeToken string, credential []byte) error {
+	ctx, span := startSpan(ctx, "FinishPasskeyRegistration", userId)
+	err := tracedFacade.facade.FinishPasskeyRegistration(ctx, userId, challengeToken, credential)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) BeginPasskeyLogin(ctx context.Context) (*adapter.PasskeyOptionsDTO, error) {
+	ctx, span := startSpan(ctx, "BeginPasskeyLogin", uuid.Nil)
+	options, err := tracedFacade.facade.BeginPasskeyLogin(ctx)
+	endSpan(span, err)
+	return options, err
+}
+
+func (tracedFacade *TracedFacade) LoginWithPasskey(ctx context.Context, challengeToken string, credential []byte, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	ctx, span := startSpan(ctx, "LoginWithPasskey", uuid.Nil)
+	token, err := tracedFacade.facade.LoginWithPasskey(ctx, challengeToken, credential, clientInfo)
+	endSpan(span, err)
+	return token, err
+}
+
+func (tracedFacade *TracedFacade) DeleteUser(ctx context.Context, userId uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DeleteUser", userId)
+	err := tracedFacade.facade.DeleteUser(ctx, userId)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*adapter.LoginHistoryDTO, error) {
+	ctx, span := startSpan(ctx, "GetLoginHistory", userId)
+	loginHistory, err := tracedFacade.facade.GetLoginHistory(ctx, userId)
+	endSpan(span, err)
+	return loginHistory, err
+}
+
+func (tracedFacade *TracedFacade) UnlockUser(ctx context.Context, userId uuid.UUID) error {
+	ctx, span := startSpan(ctx, "UnlockUser", userId)
+	err := tracedFacade.facade.UnlockUser(ctx, userId)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) GetAuditEvents(ctx context.Context, filter *AuditEventFilter) ([]*adapter.AuditEventDTO, error) {
+	userId := uuid.Nil
+	if filter.UserId != nil {
+		userId = *filter.UserId
+	}
+	ctx, span := startSpan(ctx, "GetAuditEvents", userId)
+	auditEvents, err := tracedFacade.facade.GetAuditEvents(ctx, filter)
+	endSpan(span, err)
+	return auditEvents, err
+}
+
+func (tracedFacade *TracedFacade) GetUsers(ctx context.Context, filter *UserFilter) ([]*adapter.UserDTO, error) {
+	ctx, span := startSpan(ctx, "GetUsers", uuid.Nil)
+	users, err := tracedFacade.facade.GetUsers(ctx, filter)
+	endSpan(span, err)
+	return users, err
+}
+
+func (tracedFacade *TracedFacade) GetUser(ctx context.Context, userId uuid.UUID) (*adapter.UserDTO, error) {
+	ctx, span := startSpan(ctx, "GetUser", userId)
+	user, err := tracedFacade.facade.GetUser(ctx, userId)
+	endSpan(span, err)
+	return user, err
+}
+
+func (tracedFacade *TracedFacade) SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string) error {
+	ctx, span := startSpan(ctx, "SetUserStatus", userId)
+	err := tracedFacade.facade.SetUserStatus(ctx, userId, status, reason)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) RevokeSessions(ctx context.Context, userId uuid.UUID) error {
+	ctx, span := startSpan(ctx, "RevokeSessions", userId)
+	err := tracedFacade.facade.RevokeSessions(ctx, userId)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) ResetPassword(ctx context.Context, userId uuid.UUID, password string) error {
+	ctx, span := startSpan(ctx, "ResetPassword", userId)
+	err := tracedFacade.facade.ResetPassword(ctx, userId, password)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) RequestPasswordReset(ctx context.Context, identifier string) {
+	ctx, span := startSpan(ctx, "RequestPasswordReset", uuid.Nil)
+	tracedFacade.facade.RequestPasswordReset(ctx, identifier)
+	endSpan(span, nil)
+}
+
+func (tracedFacade *TracedFacade) ConfirmPasswordReset(ctx context.Context, token string, password string) error {
+	ctx, span := startSpan(ctx, "ConfirmPasswordReset", uuid.Nil)
+	err := tracedFacade.facade.ConfirmPasswordReset(ctx, token, password)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) SendEmailVerification(ctx context.Context, userId uuid.UUID) error {
+	ctx, span := startSpan(ctx, "SendEmailVerification", userId)
+	err := tracedFacade.facade.SendEmailVerification(ctx, userId)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) VerifyEmail(ctx context.Context, userId uuid.UUID, token string) error {
+	ctx, span := startSpan(ctx, "VerifyEmail", userId)
+	err := tracedFacade.facade.VerifyEmail(ctx, userId, token)
+	endSpan(span, err)
+	return err
+}
+
+func (tracedFacade *TracedFacade) CheckSignKey() error {
+	return tracedFacade.facade.CheckSignKey()
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setTestTracerProvider(t *testing.T) *tracetest.SpanRecorder {
+	spanRecorder := tracetest.NewSpanRecorder()
+	previousProvider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previousProvider) })
+	return spanRecorder
+}
+
+func TestTracedFacade_Successfully(t *testing.T) {
+	spanRecorder := setTestTracerProvider(t)
+	userId := uuid.New()
+	coreMock := &CoreMock{DeleteUserResponseArray: []*ErrorResponse{{Err: nil}}}
+	tracedFacade := NewTracedFacade(coreMock)
+
+	err := tracedFacade.DeleteUser(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(coreMock.DeleteUserRecordArray))
+	spans := spanRecorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "Facade.DeleteUser", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.String("authi.user_id", userId.String()))
+}
+
+func TestTracedFacade_Error(t *testing.T) {
+	spanRecorder := setTestTracerProvider(t)
+	coreMock := &CoreMock{UpdatePasswordResponseArray: []*AuthenticateResponse{{Err: errors.New("some error")}}}
+	tracedFacade := NewTracedFacade(coreMock)
+
+	_, err := tracedFacade.UpdatePassword(context.Background(), uuid.New(), "somePassword", "someNewPassword")
+
+	assert.NotNil(t, err)
+	spans := spanRecorder.Ended()
+	assert.Equal(t, 1, len(spans))
+	assert.Equal(t, "Facade.UpdatePassword", spans[0].Name())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Equal(t, "some error", spans[0].Status().Description)
+}
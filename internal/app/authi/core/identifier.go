@@ -0,0 +1,50 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+)
+
+type (
+	// Optional identifiers to log in with instead of the user id. Both are unique ignoring the case
+	UserIdentifiers struct {
+		Username string
+		Email    string
+	}
+)
+
+// Trims the identifiers and checks that a username can't be mistaken for an email and the other way around
+func normalizeIdentifiers(identifiers *UserIdentifiers) (*UserIdentifiers, error) {
+	if identifiers == nil {
+		return &UserIdentifiers{}, nil
+	}
+
+	normalized := &UserIdentifiers{Username: strings.TrimSpace(identifiers.Username), Email: strings.TrimSpace(identifiers.Email)}
+	if strings.Contains(normalized.Username, "@") {
+		return nil, fmt.Errorf("%w: username must not contain @", ErrInvalidIdentifier)
+	}
+	if normalized.Email != "" && !strings.Contains(normalized.Email, "@") {
+		return nil, fmt.Errorf("%w: email must contain @", ErrInvalidIdentifier)
+	}
+	return normalized, nil
+}
+
+// Logs in the user whose username or email matches the identifier, ignoring the case.
+// Unknown identifiers fail like wrong passwords, so it can't be found out which identifiers exist
+func (userFacade *UserFacade) LoginUserByIdentifier(ctx context.Context, identifier string, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	userId, err := userFacade.dbConnection.GetUserIdByIdentifier(ctx, strings.TrimSpace(identifier))
+	if err != nil {
+		metrics.CountLogin(false)
+		if errors.Is(err, db.ErrUserNotFound) {
+			return nil, errors.New("no user with matching identifier and password found")
+		}
+		return nil, fmt.Errorf("error while loading user by identifier: %v", err)
+	}
+	return userFacade.LoginUser(ctx, userId, password, clientInfo)
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeIdentifiers(t *testing.T) {
+	identifiers, err := normalizeIdentifiers(&UserIdentifiers{Username: " Max ", Email: " Max@Example.org "})
+	assert.Nil(t, err)
+	assert.Equal(t, &UserIdentifiers{Username: "Max", Email: "Max@Example.org"}, identifiers)
+
+	identifiers, err = normalizeIdentifiers(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, &UserIdentifiers{}, identifiers)
+}
+
+func TestNormalizeIdentifiers_Invalid(t *testing.T) {
+	for _, identifiers := range []*UserIdentifiers{{Username: "max@example.org"}, {Email: "max"}} {
+		_, err := normalizeIdentifiers(identifiers)
+		assert.ErrorIs(t, err, ErrInvalidIdentifier)
+	}
+}
+
+func TestCreateUser_WithIdentifiers(t *testing.T) {
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.CreateUser(context.Background(), userId, password, &UserIdentifiers{Username: "Max ", Email: "max@example.org"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "Max", dbConnection.CreateUserRecordArray[0].User.Username)
+	assert.Equal(t, "max@example.org", dbConnection.CreateUserRecordArray[0].User.Email)
+}
+
+func TestCreateUser_IdentifierTaken(t *testing.T) {
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: db.ErrIdentifierTaken}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.CreateUser(context.Background(), userId, password, &UserIdentifiers{Username: "max"}, false)
+	assert.ErrorIs(t, err, ErrIdentifierTaken)
+	assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+}
+
+func TestCreateUser_InvalidIdentifier(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.CreateUser(context.Background(), userId, password, &UserIdentifiers{Username: "max@example.org"}, false)
+	assert.ErrorIs(t, err, ErrInvalidIdentifier)
+	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
+}
+
+func TestLoginUserByIdentifier_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{UserId: userId}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}}
+
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
+
+	tokenResponseDTO, err := userFacade.LoginUserByIdentifier(context.Background(), " Max@Example.org ", password, clientInfo)
+	assert.Nil(t, err)
+	assert.NotNil(t, tokenResponseDTO)
+	assert.Equal(t, "Max@Example.org", dbConnection.GetUserIdByIdentifierRecordArray[0].Identifier)
+	assert.Equal(t, userId, dbConnection.LoginUserRecordArray[0].User.ID)
+	assert.Equal(t, password, dbConnection.LoginUserRecordArray[0].User.Password)
+}
+
+func TestLoginUserByIdentifier_UnknownIdentifier(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{Err: db.ErrUserNotFound}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, loginHistorySize: 3}
+
+	tokenResponseDTO, err := userFacade.LoginUserByIdentifier(context.Background(), "max", password, clientInfo)
+	assert.NotNil(t, err)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+	assert.Equal(t, 0, len(dbConnection.AddLoginHistoryRecordArray))
+}
@@ -0,0 +1,283 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+const (
+	// Audience of mfa challenge tokens
+	mfaChallengeAudience = "authi:mfa-challenge"
+)
+
+type (
+	mfaConfig struct {
+		issuer              string
+		challengeExpireTime time.Duration
+	}
+
+	// Error of a login with correct password if the user enabled mfa. The login is completed with the token and a code by LoginWithMfa
+	MfaRequiredError struct {
+		MfaChallengeToken string
+		ExpiresAt         int64
+	}
+)
+
+func (err *MfaRequiredError) Error() string {
+	return ErrMfaRequired.Error()
+}
+
+func (err *MfaRequiredError) Is(target error) bool {
+	return target == ErrMfaRequired
+}
+
+func (userFacade *UserFacade) mfaRequiredError(userId uuid.UUID) error {
+	expiresAt := time.Now().Add(userFacade.mfa.challengeExpireTime).Unix()
+	claims := &jwt.StandardClaims{
+		Subject:   userId.String(),
+		Audience:  mfaChallengeAudience,
+		ExpiresAt: expiresAt,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(userFacade.signKey)
+	if err != nil {
+		return fmt.Errorf("mfa challenge token creation failed: %v", err)
+	}
+	return &MfaRequiredError{MfaChallengeToken: token, ExpiresAt: expiresAt}
+}
+
+func (userFacade *UserFacade) parseMfaChallengeToken(challengeToken string) (uuid.UUID, error) {
+	claims := &jwt.StandardClaims{}
+	if err := userFacade.parseAudienceToken(challengeToken, mfaChallengeAudience, claims); err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidMfaChallenge, err)
+	}
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidMfaChallenge, err)
+	}
+	return userId, nil
+}
+
+// Completes a login that was answered with a mfa challenge. Wrong codes count as failed login like wrong passwords
+func (userFacade *UserFacade) LoginWithMfa(ctx context.Context, challengeToken string, code string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	userId, err := userFacade.parseMfaChallengeToken(challengeToken)
+	if err != nil {
+		metrics.CountLogin(false)
+		return nil, err
+	}
+
+	token, err := userFacade.loginWithMfa(ctx, userId, code, clientInfo)
+	metrics.CountLogin(err == nil)
+	userFacade.audit(ctx, AuditUserLogin, userId, clientInfo, err)
+	return token, err
+}
+
+func (userFacade *UserFacade) loginWithMfa(ctx context.Context, userId uuid.UUID, code string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	if err := userFacade.checkLockout(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	totp, err := userFacade.dbConnection.GetTotp(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading totp of user %v: %v", userId, err)
+	}
+	if !totp.Enabled {
+		return nil, fmt.Errorf("user %v can't complete login: %w", userId, ErrMfaNotEnabled)
+	}
+	if err := userFacade.useMfaCode(ctx, userId, totp, code); err != nil {
+		userFacade.addLoginHistory(ctx, userId, time.Now(), clientInfo, false)
+		return nil, err
+	}
+
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading user %v: %v", userId, err)
+	}
+	if err := statusError(dbUser.Status); err != nil {
+		return nil, fmt.Errorf("user %v is not allowed to log in: %w", userId, err)
+	}
+	dbUser.Amr = []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}
+	if userFacade.passwordPolicy.expired(dbUser.PasswordChangedAt) {
+		userFacade.addLoginHistory(ctx, userId, time.Now(), clientInfo, false)
+		return nil, userFacade.passwordExpiredError(userId, dbUser.Amr)
+	}
+	return userFacade.completeLogin(ctx, dbUser, clientInfo)
+}
+
+// Checks the code against the enabled totp or the recovery codes of the user and marks it as used. Wrong and reused codes count as failed login
+func (userFacade *UserFacade) useMfaCode(ctx context.Context, userId uuid.UUID, totp *db.TotpDB, code string) error {
+	if isRecoveryCode(code) {
+		return userFacade.useRecoveryCode(ctx, userId, code)
+	}
+	return userFacade.useTotpCode(ctx, userId, totp, code)
+}
+
+func (userFacade *UserFacade) useTotpCode(ctx context.Context, userId uuid.UUID, totp *db.TotpDB, code string) error {
+	step, ok := matchTotp(totp.Secret, code, time.Now())
+	if !ok || step <= totp.LastStep {
+		userFacade.registerFailedLogin(ctx, userId)
+		return fmt.Errorf("%w of user %v", ErrInvalidMfaCode, userId)
+	}
+	if err := userFacade.dbConnection.UseTotpStep(ctx, userId, step); err != nil {
+		if errors.Is(err, db.ErrTotpStepUsed) {
+			userFacade.registerFailedLogin(ctx, userId)
+			return fmt.Errorf("%w of user %v: code was already used", ErrInvalidMfaCode, userId)
+		}
+		return fmt.Errorf("error while using totp of user %v: %v", userId, err)
+	}
+	return nil
+}
+
+// Consumes the recovery code. Each use is audited with the number of codes left, so users can be warned before they run out
+func (userFacade *UserFacade) useRecoveryCode(ctx context.Context, userId uuid.UUID, code string) error {
+	remaining, err := userFacade.dbConnection.UseRecoveryCode(ctx, userId, hashRecoveryCode(code))
+	if err != nil {
+		if errors.Is(err, db.ErrRecoveryCodeUsed) {
+			userFacade.registerFailedLogin(ctx, userId)
+			return fmt.Errorf("%w of user %v: recovery code not found or already used", ErrInvalidMfaCode, userId)
+		}
+		return fmt.Errorf("error while using recovery code of user %v: %v", userId, err)
+	}
+	userFacade.auditWithDetails(ctx, AuditRecoveryCodeUsed, userId, nil, true, fmt.Sprintf("%d recovery codes left", remaining))
+	return nil
+}
+
+// Generates a new totp secret for the user. The totp is enabled once a code of it is confirmed with ConfirmTotp
+func (userFacade *UserFacade) EnrollTotp(ctx context.Context, userId uuid.UUID) (*adapter.TotpEnrollmentDTO, error) {
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, db.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error while loading user %v: %v", userId, err)
+	}
+	if dbUser.TotpEnabled {
+		return nil, fmt.Errorf("user %v can't enroll totp: %w", userId, ErrMfaAlreadyEnabled)
+	}
+
+	secret := newTotpSecret()
+	if err := userFacade.dbConnection.SetTotpSecret(ctx, userId, secret); err != nil {
+		if errors.Is(err, db.ErrTotpEnabled) {
+			return nil, fmt.Errorf("user %v can't enroll totp: %w", userId, ErrMfaAlreadyEnabled)
+		}
+		return nil, fmt.Errorf("error while setting totp secret of user %v: %v", userId, err)
+	}
+	return &adapter.TotpEnrollmentDTO{Secret: secret, Uri: totpUri(userFacade.mfa.issuer, accountName(dbUser), secret)}, nil
+}
+
+// Name of the account in authenticator apps and passkey managers. Users without username and email are shown with their id
+func accountName(dbUser *db.UserDB) string {
+	switch {
+	case dbUser.Username != "":
+		return dbUser.Username
+	case dbUser.Email != "":
+		return dbUser.Email
+	default:
+		return dbUser.ID.String()
+	}
+}
+
+// Enables the enrolled totp if the code matches its secret. The returned recovery codes are shown only once
+func (userFacade *UserFacade) ConfirmTotp(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	recoveryCodes, err := userFacade.confirmTotp(ctx, userId, code)
+	userFacade.audit(ctx, AuditMfaEnabled, userId, nil, err)
+	if err == nil {
+		userFacade.audit(ctx, AuditRecoveryCodesGenerated, userId, nil, nil)
+	}
+	return recoveryCodes, err
+}
+
+func (userFacade *UserFacade) confirmTotp(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	totp, err := userFacade.dbConnection.GetTotp(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading totp of user %v: %v", userId, err)
+	}
+	if totp.Enabled {
+		return nil, fmt.Errorf("user %v can't confirm totp: %w", userId, ErrMfaAlreadyEnabled)
+	}
+	if totp.Secret == "" {
+		return nil, fmt.Errorf("user %v can't confirm totp: %w", userId, ErrMfaNotEnrolled)
+	}
+
+	step, ok := matchTotp(totp.Secret, code, time.Now())
+	if !ok {
+		return nil, fmt.Errorf("%w of user %v", ErrInvalidMfaCode, userId)
+	}
+	codes, hashes := newRecoveryCodes()
+	if err := userFacade.dbConnection.EnableTotp(ctx, userId, step, hashes); err != nil {
+		if errors.Is(err, db.ErrTotpEnabled) {
+			return nil, fmt.Errorf("user %v can't confirm totp: %w", userId, ErrMfaAlreadyEnabled)
+		}
+		return nil, fmt.Errorf("error while enabling totp of user %v: %v", userId, err)
+	}
+	return &adapter.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// Replaces the recovery codes of the user with new ones. A current code is required like for disabling the totp
+func (userFacade *UserFacade) RegenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	recoveryCodes, err := userFacade.regenerateRecoveryCodes(ctx, userId, code)
+	userFacade.audit(ctx, AuditRecoveryCodesGenerated, userId, nil, err)
+	return recoveryCodes, err
+}
+
+func (userFacade *UserFacade) regenerateRecoveryCodes(ctx context.Context, userId uuid.UUID, code string) (*adapter.RecoveryCodesDTO, error) {
+	if err := userFacade.checkLockout(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	totp, err := userFacade.dbConnection.GetTotp(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading totp of user %v: %v", userId, err)
+	}
+	if !totp.Enabled {
+		return nil, fmt.Errorf("user %v can't regenerate recovery codes: %w", userId, ErrMfaNotEnabled)
+	}
+	if err := userFacade.useMfaCode(ctx, userId, totp, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes := newRecoveryCodes()
+	if err := userFacade.dbConnection.ReplaceRecoveryCodes(ctx, userId, hashes); err != nil {
+		if errors.Is(err, db.ErrTotpNotEnabled) {
+			return nil, fmt.Errorf("user %v can't regenerate recovery codes: %w", userId, ErrMfaNotEnabled)
+		}
+		return nil, fmt.Errorf("error while replacing recovery codes of user %v: %v", userId, err)
+	}
+	return &adapter.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+// Disables the totp of the user. A current code is required, so a stolen access token isn't enough to turn off mfa
+func (userFacade *UserFacade) DisableTotp(ctx context.Context, userId uuid.UUID, code string) error {
+	err := userFacade.disableTotp(ctx, userId, code)
+	userFacade.audit(ctx, AuditMfaDisabled, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) disableTotp(ctx context.Context, userId uuid.UUID, code string) error {
+	if err := userFacade.checkLockout(ctx, userId); err != nil {
+		return err
+	}
+
+	totp, err := userFacade.dbConnection.GetTotp(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("error while loading totp of user %v: %v", userId, err)
+	}
+	if !totp.Enabled {
+		return fmt.Errorf("user %v can't disable totp: %w", userId, ErrMfaNotEnabled)
+	}
+	if err := userFacade.useMfaCode(ctx, userId, totp, code); err != nil {
+		return err
+	}
+
+	if err := userFacade.dbConnection.DisableTotp(ctx, userId); err != nil {
+		return fmt.Errorf("error while disabling totp of user %v: %v", userId, err)
+	}
+	return nil
+}
@@ -0,0 +1,414 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	mfa = &mfaConfig{issuer: "Authi", challengeExpireTime: 5 * time.Minute}
+)
+
+func currentTotpCode(secret string) (string, int64) {
+	key, _ := totpEncoding.DecodeString(secret)
+	step := totpStep(time.Now())
+	return totpCode(key, step), step
+}
+
+func parseAccessToken(t *testing.T, userFacade *UserFacade, token string) *adapter.Claims {
+	claims := &adapter.Claims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return &userFacade.signKey.PublicKey, nil
+	})
+	assert.Nil(t, err)
+	return claims
+}
+
+func newMfaUserFacade(t *testing.T, dbConnection db.Connection) *UserFacade {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	return &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, loginHistorySize: 10, lockout: lockout, mfa: mfa}
+}
+
+func TestLoginUser_PasswordOnly_Amr(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.Nil(t, err)
+	claims := parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken)
+	assert.Equal(t, []string{adapter.AmrPassword}, claims.Amr)
+	assert.False(t, claims.HasAuthenticationMethod(adapter.AmrOtp))
+	assert.Equal(t, []string{adapter.AmrPassword}, dbConnection.UpdateRefreshTokenRecordArray[0].Amr)
+}
+
+func TestLoginUser_MfaRequired(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{TotpEnabled: true}}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrMfaRequired)
+	var mfaErr *MfaRequiredError
+	assert.True(t, errors.As(err, &mfaErr))
+	assert.WithinDuration(t, time.Now().Add(5*time.Minute), time.Unix(mfaErr.ExpiresAt, 0), 2*time.Second)
+	challengeUserId, err := userFacade.parseMfaChallengeToken(mfaErr.MfaChallengeToken)
+	assert.Nil(t, err)
+	assert.Equal(t, userId, challengeUserId)
+
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.ResetFailedLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.AddLoginHistoryRecordArray))
+}
+
+func TestLoginUser_MfaRequired_BeforePasswordExpiry(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{TotpEnabled: true, PasswordChangedAt: expiredPasswordDay}}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	userFacade.lockout = nil
+	userFacade.passwordPolicy = expiringPolicy
+
+	_, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.ErrorIs(t, err, ErrMfaRequired)
+	assert.NotErrorIs(t, err, ErrPasswordExpired)
+}
+
+func TestLoginWithMfa_Successfully(t *testing.T) {
+	secret := newTotpSecret()
+	code, step := currentTotpCode(secret)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: secret, Enabled: true, LastStep: step - 2}}}, UseTotpStepResponseArray: []*db.ErrorResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Status: adapter.UserStatusActive, Admin: true}}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+	tokenResponseDTO, err := userFacade.LoginWithMfa(context.Background(), challengeToken, code, clientInfo)
+
+	assert.Nil(t, err)
+	claims := parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken)
+	assert.Equal(t, userId, claims.UserId)
+	assert.True(t, claims.HasRole(adapter.RoleAdmin))
+	assert.Equal(t, []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}, claims.Amr)
+	assert.Equal(t, claims.Amr, dbConnection.UpdateRefreshTokenRecordArray[0].Amr)
+	assert.Equal(t, step, dbConnection.UseTotpStepRecordArray[0].Step)
+	assert.Equal(t, 1, len(dbConnection.ResetFailedLoginRecordArray))
+	assert.True(t, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+}
+
+func TestLoginWithMfa_WrongCode(t *testing.T) {
+	secret := newTotpSecret()
+	code, _ := currentTotpCode(secret)
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: secret, Enabled: true}}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+	tokenResponseDTO, err := userFacade.LoginWithMfa(context.Background(), challengeToken, wrongCode, clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrInvalidMfaCode)
+	assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UseTotpStepRecordArray))
+	assert.False(t, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+}
+
+func TestLoginWithMfa_ReusedCode(t *testing.T) {
+	secret := newTotpSecret()
+	code, step := currentTotpCode(secret)
+	for _, totp := range []*db.TotpDB{{Secret: secret, Enabled: true, LastStep: step}, {Secret: secret, Enabled: true, LastStep: step - 1}} {
+		useTotpStepResponse := []*db.ErrorResponse{{Err: db.ErrTotpStepUsed}}
+		dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: totp}}, UseTotpStepResponseArray: useTotpStepResponse, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+		userFacade := newMfaUserFacade(t, dbConnection)
+		challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+		_, err := userFacade.LoginWithMfa(context.Background(), challengeToken, code, clientInfo)
+
+		assert.ErrorIs(t, err, ErrInvalidMfaCode)
+		assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+		assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+	}
+}
+
+func TestLoginWithMfa_InvalidChallenge(t *testing.T) {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	otherUserFacade := &UserFacade{signKey: signKey, accessTokenExpireTime: 5}
+	changeToken := otherUserFacade.passwordExpiredError(userId, nil).(*PasswordExpiredError).PasswordChangeToken
+	expiredToken, err := jwt.NewWithClaims(jwt.SigningMethodRS256, &jwt.StandardClaims{Subject: userId.String(), Audience: mfaChallengeAudience, ExpiresAt: time.Now().Add(-time.Minute).Unix()}).SignedString(signKey)
+	assert.Nil(t, err)
+
+	for _, challengeToken := range []string{"", "someToken", changeToken, expiredToken} {
+		dbConnection := &db.DBMock{}
+		userFacade := newMfaUserFacade(t, dbConnection)
+
+		tokenResponseDTO, err := userFacade.LoginWithMfa(context.Background(), challengeToken, "123456", clientInfo)
+
+		assert.Nil(t, tokenResponseDTO)
+		assert.ErrorIs(t, err, ErrInvalidMfaChallenge)
+		assert.Equal(t, 0, len(dbConnection.GetTotpRecordArray))
+	}
+}
+
+func TestLoginWithMfa_Locked(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{LockedUntil: &lockedUntil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+	_, err := userFacade.LoginWithMfa(context.Background(), challengeToken, "123456", clientInfo)
+
+	assert.ErrorIs(t, err, ErrUserLocked)
+	assert.Equal(t, 0, len(dbConnection.GetTotpRecordArray))
+}
+
+func TestLoginWithMfa_PasswordExpired(t *testing.T) {
+	secret := newTotpSecret()
+	code, _ := currentTotpCode(secret)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: secret, Enabled: true}}}, UseTotpStepResponseArray: []*db.ErrorResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Status: adapter.UserStatusActive, PasswordChangedAt: expiredPasswordDay}}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	userFacade.passwordPolicy = expiringPolicy
+	challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+	_, err := userFacade.LoginWithMfa(context.Background(), challengeToken, code, clientInfo)
+
+	assert.ErrorIs(t, err, ErrPasswordExpired)
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+}
+
+func TestRefreshToken_KeepsAmr(t *testing.T) {
+	amr := []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}
+	dbConnection := &db.DBMock{CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{Amr: amr}}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
+
+	assert.Nil(t, err)
+	assert.Equal(t, amr, parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken).Amr)
+	assert.Equal(t, amr, dbConnection.UpdateRefreshTokenRecordArray[0].Amr)
+}
+
+func TestUpdatePassword_KeepsSessionAmr(t *testing.T) {
+	amr := []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	tokenResponseDTO, err := userFacade.UpdatePassword(ContextWithAmr(context.Background(), amr), userId, password, "some new password")
+
+	assert.Nil(t, err)
+	assert.Equal(t, amr, parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken).Amr)
+	assert.Equal(t, amr, dbConnection.UpdateRefreshTokenRecordArray[0].Amr)
+}
+
+func TestChangeExpiredPassword_KeepsMfaAmr(t *testing.T) {
+	amr := []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, IsRecentPasswordResponseArray: []*db.IsRecentPasswordResponse{{Recent: false}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	userFacade.passwordPolicy = expiringPolicy
+	changeToken := userFacade.passwordExpiredError(userId, amr).(*PasswordExpiredError).PasswordChangeToken
+
+	tokenResponseDTO, err := userFacade.ChangeExpiredPassword(context.Background(), userId, changeToken, password, "some new password")
+
+	assert.Nil(t, err)
+	assert.Equal(t, amr, parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken).Amr)
+	assert.Equal(t, amr, dbConnection.UpdateRefreshTokenRecordArray[0].Amr)
+}
+
+func TestEnrollTotp_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Username: "max"}}}, SetTotpSecretResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	enrollment, err := userFacade.EnrollTotp(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, enrollment.Secret, dbConnection.SetTotpSecretRecordArray[0].Secret)
+	assert.Equal(t, totpUri("Authi", "max", enrollment.Secret), enrollment.Uri)
+}
+
+func TestEnrollTotp_AlreadyEnabled(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, TotpEnabled: true}}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	_, err := userFacade.EnrollTotp(context.Background(), userId)
+
+	assert.ErrorIs(t, err, ErrMfaAlreadyEnabled)
+	assert.Equal(t, 0, len(dbConnection.SetTotpSecretRecordArray))
+}
+
+func TestAccountName(t *testing.T) {
+	assert.Equal(t, "max", accountName(&db.UserDB{ID: userId, Username: "max", Email: "max@example.org"}))
+	assert.Equal(t, "max@example.org", accountName(&db.UserDB{ID: userId, Email: "max@example.org"}))
+	assert.Equal(t, userId.String(), accountName(&db.UserDB{ID: userId}))
+}
+
+func TestConfirmTotp_Successfully(t *testing.T) {
+	secret := newTotpSecret()
+	code, step := currentTotpCode(secret)
+	dbConnection := &db.DBMock{GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: secret}}}, EnableTotpResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	userFacade.auditEnabled = true
+
+	recoveryCodes, err := userFacade.ConfirmTotp(context.Background(), userId, code)
+
+	assert.Nil(t, err)
+	assert.Equal(t, step, dbConnection.EnableTotpRecordArray[0].Step)
+	assert.Equal(t, recoveryCodeCount, len(recoveryCodes.RecoveryCodes))
+	for i, recoveryCode := range recoveryCodes.RecoveryCodes {
+		assert.Equal(t, hashRecoveryCode(recoveryCode), dbConnection.EnableTotpRecordArray[0].RecoveryCodeHashes[i])
+	}
+	assert.Equal(t, AuditMfaEnabled, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+	assert.True(t, dbConnection.AddAuditEventRecordArray[0].Event.Success)
+	assert.Equal(t, AuditRecoveryCodesGenerated, dbConnection.AddAuditEventRecordArray[1].Event.EventType)
+}
+
+func TestConfirmTotp_Errors(t *testing.T) {
+	secret := newTotpSecret()
+	code, _ := currentTotpCode(secret)
+	wrongCode := "000000"
+	if code == wrongCode {
+		wrongCode = "111111"
+	}
+	for _, testCase := range []struct {
+		totp  *db.TotpDB
+		code  string
+		error error
+	}{
+		{&db.TotpDB{Secret: secret}, wrongCode, ErrInvalidMfaCode},
+		{&db.TotpDB{}, code, ErrMfaNotEnrolled},
+		{&db.TotpDB{Secret: secret, Enabled: true}, code, ErrMfaAlreadyEnabled},
+	} {
+		dbConnection := &db.DBMock{GetTotpResponseArray: []*db.GetTotpResponse{{Totp: testCase.totp}}}
+		userFacade := newMfaUserFacade(t, dbConnection)
+
+		recoveryCodes, err := userFacade.ConfirmTotp(context.Background(), userId, testCase.code)
+
+		assert.Nil(t, recoveryCodes)
+		assert.ErrorIs(t, err, testCase.error)
+		assert.Equal(t, 0, len(dbConnection.EnableTotpRecordArray))
+	}
+}
+
+func TestDisableTotp_Successfully(t *testing.T) {
+	secret := newTotpSecret()
+	code, step := currentTotpCode(secret)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: secret, Enabled: true}}}, UseTotpStepResponseArray: []*db.ErrorResponse{{Err: nil}}, DisableTotpResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	err := userFacade.DisableTotp(context.Background(), userId, code)
+
+	assert.Nil(t, err)
+	assert.Equal(t, step, dbConnection.UseTotpStepRecordArray[0].Step)
+	assert.Equal(t, userId, dbConnection.DisableTotpRecordArray[0].UserId)
+}
+
+func TestDisableTotp_WrongCode(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: rfcTotpSecret, Enabled: true}}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	err := userFacade.DisableTotp(context.Background(), uuid.New(), "081804")
+
+	assert.ErrorIs(t, err, ErrInvalidMfaCode)
+	assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.DisableTotpRecordArray))
+}
+
+func TestDisableTotp_NotEnabled(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{}}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	err := userFacade.DisableTotp(context.Background(), userId, "123456")
+
+	assert.ErrorIs(t, err, ErrMfaNotEnabled)
+}
+
+func TestLoginWithMfa_RecoveryCode(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: rfcTotpSecret, Enabled: true}}}, UseRecoveryCodeResponseArray: []*db.UseRecoveryCodeResponse{{Remaining: 9}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Status: adapter.UserStatusActive}}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	userFacade.auditEnabled = true
+	challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+	tokenResponseDTO, err := userFacade.LoginWithMfa(context.Background(), challengeToken, "ABCDE-FGH23", clientInfo)
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}, parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken).Amr)
+	assert.Equal(t, hashRecoveryCode("abcdefgh23"), dbConnection.UseRecoveryCodeRecordArray[0].RecoveryCodeHash)
+	assert.Equal(t, 0, len(dbConnection.UseTotpStepRecordArray))
+	assert.Equal(t, AuditRecoveryCodeUsed, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+	assert.Equal(t, "9 recovery codes left", dbConnection.AddAuditEventRecordArray[0].Event.Details)
+	assert.Equal(t, AuditUserLogin, dbConnection.AddAuditEventRecordArray[1].Event.EventType)
+}
+
+func TestLoginWithMfa_UsedRecoveryCode(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: rfcTotpSecret, Enabled: true}}}, UseRecoveryCodeResponseArray: []*db.UseRecoveryCodeResponse{{Err: db.ErrRecoveryCodeUsed}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	challengeToken := userFacade.mfaRequiredError(userId).(*MfaRequiredError).MfaChallengeToken
+
+	tokenResponseDTO, err := userFacade.LoginWithMfa(context.Background(), challengeToken, "abcde-fgh23", clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrInvalidMfaCode)
+	assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.False(t, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+}
+
+func TestDisableTotp_RecoveryCode(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: rfcTotpSecret, Enabled: true}}}, UseRecoveryCodeResponseArray: []*db.UseRecoveryCodeResponse{{Remaining: 0}}, DisableTotpResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	err := userFacade.DisableTotp(context.Background(), userId, "abcde-fgh23")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.UseRecoveryCodeRecordArray))
+	assert.Equal(t, userId, dbConnection.DisableTotpRecordArray[0].UserId)
+}
+
+func TestRegenerateRecoveryCodes_Successfully(t *testing.T) {
+	secret := newTotpSecret()
+	code, step := currentTotpCode(secret)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: &db.TotpDB{Secret: secret, Enabled: true}}}, UseTotpStepResponseArray: []*db.ErrorResponse{{Err: nil}}, ReplaceRecoveryCodesResponseArray: []*db.ErrorResponse{{Err: nil}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newMfaUserFacade(t, dbConnection)
+	userFacade.auditEnabled = true
+
+	recoveryCodes, err := userFacade.RegenerateRecoveryCodes(context.Background(), userId, code)
+
+	assert.Nil(t, err)
+	assert.Equal(t, step, dbConnection.UseTotpStepRecordArray[0].Step)
+	assert.Equal(t, recoveryCodeCount, len(dbConnection.ReplaceRecoveryCodesRecordArray[0].RecoveryCodeHashes))
+	assert.Equal(t, hashRecoveryCode(recoveryCodes.RecoveryCodes[0]), dbConnection.ReplaceRecoveryCodesRecordArray[0].RecoveryCodeHashes[0])
+	assert.Equal(t, AuditRecoveryCodesGenerated, dbConnection.AddAuditEventRecordArray[0].Event.EventType)
+}
+
+func TestRegenerateRecoveryCodes_Errors(t *testing.T) {
+	for _, testCase := range []struct {
+		totp                         *db.TotpDB
+		replaceRecoveryCodesResponse []*db.ErrorResponse
+		error                        error
+	}{
+		{&db.TotpDB{}, nil, ErrMfaNotEnabled},
+		{&db.TotpDB{Secret: rfcTotpSecret, Enabled: true}, []*db.ErrorResponse{{Err: db.ErrTotpNotEnabled}}, ErrMfaNotEnabled},
+		{&db.TotpDB{Secret: rfcTotpSecret, Enabled: true}, []*db.ErrorResponse{{Err: errors.New("some error")}}, nil},
+	} {
+		dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, GetTotpResponseArray: []*db.GetTotpResponse{{Totp: testCase.totp}}, UseRecoveryCodeResponseArray: []*db.UseRecoveryCodeResponse{{Remaining: 3}}, ReplaceRecoveryCodesResponseArray: testCase.replaceRecoveryCodesResponse}
+		userFacade := newMfaUserFacade(t, dbConnection)
+
+		recoveryCodes, err := userFacade.RegenerateRecoveryCodes(context.Background(), userId, "abcde-fgh23")
+
+		assert.Nil(t, recoveryCodes)
+		assert.NotNil(t, err)
+		if testCase.error != nil {
+			assert.ErrorIs(t, err, testCase.error)
+		}
+	}
+}
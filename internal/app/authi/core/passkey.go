@@ -0,0 +1,340 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+const (
+	// Audiences of passkey challenge tokens
+	passkeyRegistrationAudience = "authi:passkey-registration"
+	passkeyLoginAudience        = "authi:passkey-login"
+)
+
+type (
+	passkeyConfig struct {
+		webAuthn            *webauthn.WebAuthn
+		challengeExpireTime time.Duration
+	}
+
+	// Claims of a passkey challenge token. The token replaces the session of the ceremony, only used challenges are stored
+	passkeyChallengeClaims struct {
+		jwt.StandardClaims
+		Challenge string `json:"challenge"`
+	}
+
+	// User of a passkey ceremony. The user handle of the passkeys is the user id
+	passkeyUser struct {
+		id          uuid.UUID
+		name        string
+		credentials []webauthn.Credential
+	}
+)
+
+// Creates the relying party of the passkeys. Passkeys are disabled and nil is returned if no relying party id is configured
+func newPasskeyConfig(passkeyConfiguration *config.PasskeyConfig) (*passkeyConfig, error) {
+	if passkeyConfiguration.RpId == "" {
+		return nil, nil
+	}
+
+	challengeExpireTime := time.Duration(passkeyConfiguration.ChallengeExpireTime) * time.Minute
+	webAuthn, err := webauthn.New(&webauthn.Config{
+		RPID:          passkeyConfiguration.RpId,
+		RPDisplayName: passkeyConfiguration.RpName,
+		RPOrigins:     passkeyConfiguration.Origins(),
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			RequireResidentKey: protocol.ResidentKeyRequired(),
+			ResidentKey:        protocol.ResidentKeyRequirementRequired,
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Timeout: challengeExpireTime, TimeoutUVD: challengeExpireTime},
+			Registration: webauthn.TimeoutConfig{Timeout: challengeExpireTime, TimeoutUVD: challengeExpireTime},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error while creating passkey relying party: %v", err)
+	}
+	return &passkeyConfig{webAuthn: webAuthn, challengeExpireTime: challengeExpireTime}, nil
+}
+
+func (user *passkeyUser) WebAuthnID() []byte {
+	return user.id[:]
+}
+
+func (user *passkeyUser) WebAuthnName() string {
+	return user.name
+}
+
+func (user *passkeyUser) WebAuthnDisplayName() string {
+	return user.name
+}
+
+func (user *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	return user.credentials
+}
+
+func (user *passkeyUser) WebAuthnIcon() string {
+	return ""
+}
+
+func mapToWebauthnCredential(credential *db.PasskeyCredentialDB) webauthn.Credential {
+	transports := make([]protocol.AuthenticatorTransport, len(credential.Transports))
+	for i, transport := range credential.Transports {
+		transports[i] = protocol.AuthenticatorTransport(transport)
+	}
+	return webauthn.Credential{
+		ID:              credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transport:       transports,
+		Flags:           webauthn.CredentialFlags{BackupEligible: credential.BackupEligible, BackupState: credential.BackupState},
+		Authenticator:   webauthn.Authenticator{AAGUID: credential.AAGUID, SignCount: uint32(credential.SignCount)},
+	}
+}
+
+func mapToPasskeyCredentialDB(userId uuid.UUID, credential *webauthn.Credential) *db.PasskeyCredentialDB {
+	transports := make([]string, len(credential.Transport))
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+	return &db.PasskeyCredentialDB{
+		ID:              credential.ID,
+		UserId:          userId,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		AAGUID:          credential.Authenticator.AAGUID,
+		SignCount:       int64(credential.Authenticator.SignCount),
+		BackupEligible:  credential.Flags.BackupEligible,
+		BackupState:     credential.Flags.BackupState,
+		CreatedAt:       time.Now(),
+	}
+}
+
+// Signs the session of the ceremony into the challenge token that is returned with the options for the browser
+func (userFacade *UserFacade) passkeyOptions(session *webauthn.SessionData, subject string, audience string, options any) (*adapter.PasskeyOptionsDTO, error) {
+	publicKey, err := json.Marshal(options)
+	if err != nil {
+		return nil, fmt.Errorf("error while encoding passkey options: %v", err)
+	}
+
+	claims := &passkeyChallengeClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   subject,
+			Audience:  audience,
+			ExpiresAt: time.Now().Add(userFacade.passkey.challengeExpireTime).Unix(),
+		},
+		Challenge: session.Challenge,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(userFacade.signKey)
+	if err != nil {
+		return nil, fmt.Errorf("passkey challenge token creation failed: %v", err)
+	}
+	return &adapter.PasskeyOptionsDTO{PasskeyChallengeToken: token, PublicKey: publicKey}, nil
+}
+
+// Restores the session of the ceremony from the challenge token. The subject is the user id of registrations and empty for logins.
+// The challenge is marked as used, so a captured credential can't be sent again with the same challenge token
+func (userFacade *UserFacade) parsePasskeyChallengeToken(ctx context.Context, challengeToken string, audience string) (*webauthn.SessionData, string, error) {
+	claims := &passkeyChallengeClaims{}
+	if err := userFacade.parseAudienceToken(challengeToken, audience, claims); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidPasskeyChallenge, err)
+	}
+	if err := userFacade.dbConnection.UsePasskeyChallenge(ctx, claims.Challenge, time.Unix(claims.ExpiresAt, 0)); err != nil {
+		if errors.Is(err, db.ErrChallengeUsed) {
+			return nil, "", fmt.Errorf("%w: %v", ErrInvalidPasskeyChallenge, err)
+		}
+		return nil, "", fmt.Errorf("error while using passkey challenge: %v", err)
+	}
+	session := &webauthn.SessionData{Challenge: claims.Challenge, UserVerification: protocol.VerificationRequired, Expires: time.Unix(claims.ExpiresAt, 0)}
+	return session, claims.Subject, nil
+}
+
+func (userFacade *UserFacade) checkPasskeysEnabled() error {
+	if userFacade.passkey == nil {
+		return ErrPasskeysDisabled
+	}
+	return nil
+}
+
+// Starts the registration of a new passkey. The options are passed to navigator.credentials.create in the browser.
+// A passkey logs in without password and second factor, so the current password is required and users with enabled mfa
+// have to be logged in with it
+func (userFacade *UserFacade) BeginPasskeyRegistration(ctx context.Context, userId uuid.UUID, password string) (*adapter.PasskeyOptionsDTO, error) {
+	if err := userFacade.checkPasskeysEnabled(); err != nil {
+		return nil, err
+	}
+
+	passwordUser, err := userFacade.checkPassword(ctx, userId, password)
+	if err != nil {
+		return nil, err
+	}
+	if passwordUser.TotpEnabled && !slices.Contains(amrFromContext(ctx), adapter.AmrMfa) {
+		return nil, fmt.Errorf("user %v has to log in with mfa to register a passkey: %w", userId, ErrMfaRequired)
+	}
+
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		if errors.Is(err, db.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("error while loading user %v: %v", userId, err)
+	}
+	dbCredentials, err := userFacade.dbConnection.GetPasskeyCredentials(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading passkeys of user %v: %v", userId, err)
+	}
+
+	user := &passkeyUser{id: userId, name: accountName(dbUser)}
+	exclusions := make([]protocol.CredentialDescriptor, len(dbCredentials))
+	for i, dbCredential := range dbCredentials {
+		exclusions[i] = mapToWebauthnCredential(dbCredential).Descriptor()
+	}
+	creation, session, err := userFacade.passkey.webAuthn.BeginRegistration(user, webauthn.WithExclusions(exclusions))
+	if err != nil {
+		return nil, fmt.Errorf("error while beginning passkey registration of user %v: %v", userId, err)
+	}
+	return userFacade.passkeyOptions(session, userId.String(), passkeyRegistrationAudience, creation.Response)
+}
+
+// Verifies the credential that the authenticator created for the options of BeginPasskeyRegistration and stores it as passkey of the user
+func (userFacade *UserFacade) FinishPasskeyRegistration(ctx context.Context, userId uuid.UUID, challengeToken string, credential []byte) error {
+	err := userFacade.finishPasskeyRegistration(ctx, userId, challengeToken, credential)
+	userFacade.audit(ctx, AuditPasskeyRegistered, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) finishPasskeyRegistration(ctx context.Context, userId uuid.UUID, challengeToken string, credential []byte) error {
+	if err := userFacade.checkPasskeysEnabled(); err != nil {
+		return err
+	}
+
+	session, subject, err := userFacade.parsePasskeyChallengeToken(ctx, challengeToken, passkeyRegistrationAudience)
+	if err != nil {
+		return err
+	}
+	if subject != userId.String() {
+		return fmt.Errorf("%w: token was issued for another user", ErrInvalidPasskeyChallenge)
+	}
+	session.UserID = userId[:]
+
+	parsedCredential, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(credential))
+	if err != nil {
+		return fmt.Errorf("%w of user %v: %v", ErrInvalidPasskey, userId, err)
+	}
+	webauthnCredential, err := userFacade.passkey.webAuthn.CreateCredential(&passkeyUser{id: userId}, *session, parsedCredential)
+	if err != nil {
+		return fmt.Errorf("%w of user %v: %v", ErrInvalidPasskey, userId, err)
+	}
+
+	if err := userFacade.dbConnection.AddPasskeyCredential(ctx, mapToPasskeyCredentialDB(userId, webauthnCredential)); err != nil {
+		if errors.Is(err, db.ErrPasskeyExists) {
+			return fmt.Errorf("user %v can't register passkey: %w", userId, ErrPasskeyAlreadyRegistered)
+		}
+		return fmt.Errorf("error while adding passkey of user %v: %v", userId, err)
+	}
+	return nil
+}
+
+// Starts a login with a passkey. No user is given, the browser offers the passkeys that are stored for the relying party
+func (userFacade *UserFacade) BeginPasskeyLogin(ctx context.Context) (*adapter.PasskeyOptionsDTO, error) {
+	if err := userFacade.checkPasskeysEnabled(); err != nil {
+		return nil, err
+	}
+
+	assertion, session, err := userFacade.passkey.webAuthn.BeginDiscoverableLogin()
+	if err != nil {
+		return nil, fmt.Errorf("error while beginning passkey login: %v", err)
+	}
+	return userFacade.passkeyOptions(session, "", passkeyLoginAudience, assertion.Response)
+}
+
+// Logs in the owner of the passkey with the assertion that the authenticator signed for the options of BeginPasskeyLogin.
+// Passkeys require user verification, so the login counts as login with more than one factor
+func (userFacade *UserFacade) LoginWithPasskey(ctx context.Context, challengeToken string, credential []byte, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	if err := userFacade.checkPasskeysEnabled(); err != nil {
+		return nil, err
+	}
+
+	session, _, err := userFacade.parsePasskeyChallengeToken(ctx, challengeToken, passkeyLoginAudience)
+	if err != nil {
+		metrics.CountLogin(false)
+		return nil, err
+	}
+	parsedCredential, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(credential))
+	if err != nil {
+		metrics.CountLogin(false)
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+	}
+	dbCredential, err := userFacade.dbConnection.GetPasskeyCredential(ctx, parsedCredential.RawID)
+	if err != nil {
+		metrics.CountLogin(false)
+		if errors.Is(err, db.ErrPasskeyNotFound) {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPasskey, err)
+		}
+		return nil, fmt.Errorf("error while loading passkey: %v", err)
+	}
+
+	userId := dbCredential.UserId
+	token, err := userFacade.loginWithPasskey(ctx, session, parsedCredential, dbCredential, clientInfo)
+	metrics.CountLogin(err == nil)
+	userFacade.audit(ctx, AuditUserLogin, userId, clientInfo, err)
+	return token, err
+}
+
+func (userFacade *UserFacade) loginWithPasskey(ctx context.Context, session *webauthn.SessionData, parsedCredential *protocol.ParsedCredentialAssertionData, dbCredential *db.PasskeyCredentialDB, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	userId := dbCredential.UserId
+	if err := userFacade.checkLockout(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	owner := func(rawId []byte, userHandle []byte) (webauthn.User, error) {
+		if !bytes.Equal(userHandle, userId[:]) {
+			return nil, errors.New("user handle doesn't belong to the owner of the passkey")
+		}
+		return &passkeyUser{id: userId, credentials: []webauthn.Credential{mapToWebauthnCredential(dbCredential)}}, nil
+	}
+	webauthnCredential, err := userFacade.passkey.webAuthn.ValidateDiscoverableLogin(owner, *session, parsedCredential)
+	if err == nil && webauthnCredential.Authenticator.CloneWarning {
+		err = errors.New("sign count didn't increase, the passkey may be cloned")
+	}
+	if err != nil {
+		userFacade.registerFailedLogin(ctx, userId)
+		userFacade.addLoginHistory(ctx, userId, time.Now(), clientInfo, false)
+		return nil, fmt.Errorf("%w of user %v: %v", ErrInvalidPasskey, userId, err)
+	}
+
+	lastUsedAt := time.Now()
+	dbCredential.SignCount = int64(webauthnCredential.Authenticator.SignCount)
+	dbCredential.BackupState = webauthnCredential.Flags.BackupState
+	dbCredential.LastUsedAt = &lastUsedAt
+	if err := userFacade.dbConnection.UpdatePasskeyCredentialUsage(ctx, dbCredential); err != nil {
+		return nil, fmt.Errorf("error while updating passkey of user %v: %v", userId, err)
+	}
+
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading user %v: %v", userId, err)
+	}
+	if err := statusError(dbUser.Status); err != nil {
+		return nil, fmt.Errorf("user %v is not allowed to log in: %w", userId, err)
+	}
+
+	dbUser.Amr = []string{adapter.AmrHardwareKey, adapter.AmrMfa}
+	return userFacade.completeLogin(ctx, dbUser, clientInfo)
+}
@@ -0,0 +1,501 @@
+package core
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/protocol/webauthncose"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	passkeyOrigin = "https://localhost"
+
+	// Flags of the authenticator data
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+)
+
+var (
+	passkeyConfiguration = &config.PasskeyConfig{RpId: "localhost", RpName: "Authi", RpOrigins: passkeyOrigin, ChallengeExpireTime: 5}
+)
+
+// Authenticator that creates passkeys in memory and answers the ceremonies like a browser with a platform authenticator would
+type softwareAuthenticator struct {
+	credentialId []byte
+	privateKey   *ecdsa.PrivateKey
+	userHandle   []byte
+	signCount    uint32
+	origin       string
+}
+
+func newSoftwareAuthenticator(t *testing.T) *softwareAuthenticator {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	credentialId := make([]byte, 16)
+	rand.Read(credentialId)
+	return &softwareAuthenticator{credentialId: credentialId, privateKey: privateKey, origin: passkeyOrigin}
+}
+
+func encodeBase64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func (authenticator *softwareAuthenticator) clientData(t *testing.T, ceremonyType string, challenge []byte) []byte {
+	clientData, err := json.Marshal(map[string]string{"type": ceremonyType, "challenge": encodeBase64(challenge), "origin": authenticator.origin})
+	assert.Nil(t, err)
+	return clientData
+}
+
+func (authenticator *softwareAuthenticator) authenticatorData(rpId string, flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	authData := append(rpIdHash[:], flags)
+	return binary.BigEndian.AppendUint32(authData, authenticator.signCount)
+}
+
+func (authenticator *softwareAuthenticator) publicKey(t *testing.T) []byte {
+	ecdhKey, err := authenticator.privateKey.PublicKey.ECDH()
+	assert.Nil(t, err)
+	point := ecdhKey.Bytes()
+	publicKey, err := webauthncbor.Marshal(&webauthncose.EC2PublicKeyData{
+		PublicKeyData: webauthncose.PublicKeyData{KeyType: int64(webauthncose.EllipticKey), Algorithm: int64(webauthncose.AlgES256)},
+		Curve:         1,
+		XCoord:        point[1:33],
+		YCoord:        point[33:],
+	})
+	assert.Nil(t, err)
+	return publicKey
+}
+
+// Answers the options of a registration like navigator.credentials.create with none attestation
+func (authenticator *softwareAuthenticator) create(t *testing.T, options *adapter.PasskeyOptionsDTO) []byte {
+	creationOptions := &protocol.PublicKeyCredentialCreationOptions{}
+	assert.Nil(t, json.Unmarshal(options.PublicKey, creationOptions))
+	userHandle, err := base64.RawURLEncoding.DecodeString(creationOptions.User.ID.(string))
+	assert.Nil(t, err)
+	authenticator.userHandle = userHandle
+
+	authData := authenticator.authenticatorData(creationOptions.RelyingParty.ID, flagUserPresent|flagUserVerified|flagAttestedCredData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(authenticator.credentialId)))
+	authData = append(authData, authenticator.credentialId...)
+	authData = append(authData, authenticator.publicKey(t)...)
+	attestationObject, err := webauthncbor.Marshal(&struct {
+		Format       string         `cbor:"fmt"`
+		AttStatement map[string]any `cbor:"attStmt"`
+		AuthData     []byte         `cbor:"authData"`
+	}{Format: "none", AttStatement: map[string]any{}, AuthData: authData})
+	assert.Nil(t, err)
+
+	credential, err := json.Marshal(map[string]any{
+		"id":    encodeBase64(authenticator.credentialId),
+		"rawId": encodeBase64(authenticator.credentialId),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    encodeBase64(authenticator.clientData(t, "webauthn.create", creationOptions.Challenge)),
+			"attestationObject": encodeBase64(attestationObject),
+			"transports":        []string{"internal"},
+		},
+	})
+	assert.Nil(t, err)
+	return credential
+}
+
+// Answers the options of a login like navigator.credentials.get. Every assertion increases the sign count
+func (authenticator *softwareAuthenticator) get(t *testing.T, options *adapter.PasskeyOptionsDTO) []byte {
+	requestOptions := &protocol.PublicKeyCredentialRequestOptions{}
+	assert.Nil(t, json.Unmarshal(options.PublicKey, requestOptions))
+
+	authenticator.signCount++
+	authData := authenticator.authenticatorData(requestOptions.RelyingPartyID, flagUserPresent|flagUserVerified)
+	clientData := authenticator.clientData(t, "webauthn.get", requestOptions.Challenge)
+	clientDataHash := sha256.Sum256(clientData)
+	signedDataHash := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, authenticator.privateKey, signedDataHash[:])
+	assert.Nil(t, err)
+
+	credential, err := json.Marshal(map[string]any{
+		"id":    encodeBase64(authenticator.credentialId),
+		"rawId": encodeBase64(authenticator.credentialId),
+		"type":  "public-key",
+		"response": map[string]any{
+			"clientDataJSON":    encodeBase64(clientData),
+			"authenticatorData": encodeBase64(authData),
+			"signature":         encodeBase64(signature),
+			"userHandle":        encodeBase64(authenticator.userHandle),
+		},
+	})
+	assert.Nil(t, err)
+	return credential
+}
+
+func newPasskeyUserFacade(t *testing.T, dbConnection db.Connection) *UserFacade {
+	userFacade := newMfaUserFacade(t, dbConnection)
+	passkey, err := newPasskeyConfig(passkeyConfiguration)
+	assert.Nil(t, err)
+	userFacade.passkey = passkey
+	return userFacade
+}
+
+// Registers a passkey of the authenticator for the user and returns the stored credential
+func registerPasskey(t *testing.T, authenticator *softwareAuthenticator, userId uuid.UUID) *db.PasskeyCredentialDB {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Username: "max"}}}, GetPasskeyCredentialsResponseArray: []*db.GetPasskeyCredentialsResponse{{}}, UsePasskeyChallengeResponseArray: []*db.ErrorResponse{{Err: nil}}, AddPasskeyCredentialResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+
+	options, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+	assert.Nil(t, err)
+	err = userFacade.FinishPasskeyRegistration(context.Background(), userId, options.PasskeyChallengeToken, authenticator.create(t, options))
+	assert.Nil(t, err)
+	return dbConnection.AddPasskeyCredentialRecordArray[0].Credential
+}
+
+func newPasskeyLoginMock(credential *db.PasskeyCredentialDB, user *db.UserDB) *db.DBMock {
+	return &db.DBMock{UsePasskeyChallengeResponseArray: []*db.ErrorResponse{{Err: nil}}, GetPasskeyCredentialResponseArray: []*db.GetPasskeyCredentialResponse{{Credential: credential}}, GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}, UpdatePasskeyCredentialUsageResponseArray: []*db.ErrorResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: user}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+}
+
+func TestNewPasskeyConfig_Disabled(t *testing.T) {
+	passkey, err := newPasskeyConfig(&config.PasskeyConfig{RpName: "Authi", ChallengeExpireTime: 5})
+
+	assert.Nil(t, err)
+	assert.Nil(t, passkey)
+}
+
+func TestPasskeys_Disabled(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	userFacade := newMfaUserFacade(t, dbConnection)
+
+	_, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+	assert.ErrorIs(t, err, ErrPasskeysDisabled)
+	_, err = userFacade.BeginPasskeyLogin(context.Background())
+	assert.ErrorIs(t, err, ErrPasskeysDisabled)
+	_, err = userFacade.LoginWithPasskey(context.Background(), "someToken", []byte("{}"), clientInfo)
+	assert.ErrorIs(t, err, ErrPasskeysDisabled)
+}
+
+func TestBeginPasskeyRegistration_Options(t *testing.T) {
+	excludedId := []byte("excludedCredential")
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Email: "max@example.org"}}}, GetPasskeyCredentialsResponseArray: []*db.GetPasskeyCredentialsResponse{{Credentials: []*db.PasskeyCredentialDB{{ID: excludedId, Transports: []string{"usb"}}}}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+
+	options, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+
+	assert.Nil(t, err)
+	creationOptions := &protocol.PublicKeyCredentialCreationOptions{}
+	assert.Nil(t, json.Unmarshal(options.PublicKey, creationOptions))
+	assert.Equal(t, "localhost", creationOptions.RelyingParty.ID)
+	assert.Equal(t, "Authi", creationOptions.RelyingParty.Name)
+	assert.Equal(t, "max@example.org", creationOptions.User.Name)
+	assert.Equal(t, encodeBase64(userId[:]), creationOptions.User.ID)
+	assert.Equal(t, protocol.ResidentKeyRequirementRequired, creationOptions.AuthenticatorSelection.ResidentKey)
+	assert.Equal(t, protocol.VerificationRequired, creationOptions.AuthenticatorSelection.UserVerification)
+	assert.Equal(t, []byte(excludedId), []byte(creationOptions.CredentialExcludeList[0].CredentialID))
+	assert.Equal(t, userId, dbConnection.GetPasskeyCredentialsRecordArray[0].UserId)
+	assert.Equal(t, password, dbConnection.LoginUserRecordArray[0].User.Password)
+}
+
+func TestBeginPasskeyRegistration_WrongPassword(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: errors.New("wrong password")}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+
+	options, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, "wrong password")
+
+	assert.Nil(t, options)
+	assert.NotNil(t, err)
+	assert.Equal(t, userId, dbConnection.IncrementFailedLoginRecordArray[0].UserId)
+	assert.Equal(t, 0, len(dbConnection.GetUserRecordArray))
+}
+
+func TestBeginPasskeyRegistration_MfaRequired(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}, {}}, LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{TotpEnabled: true}}, {User: &db.UserDB{TotpEnabled: true}}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId}}}, GetPasskeyCredentialsResponseArray: []*db.GetPasskeyCredentialsResponse{{}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+
+	options, err := userFacade.BeginPasskeyRegistration(ContextWithAmr(context.Background(), []string{adapter.AmrPassword}), userId, password)
+
+	assert.Nil(t, options)
+	assert.ErrorIs(t, err, ErrMfaRequired)
+	assert.Equal(t, 0, len(dbConnection.GetUserRecordArray))
+
+	options, err = userFacade.BeginPasskeyRegistration(ContextWithAmr(context.Background(), []string{adapter.AmrPassword, adapter.AmrOtp, adapter.AmrMfa}), userId, password)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, options)
+}
+
+func TestBeginPasskeyRegistration_UserNotFound(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{Err: db.ErrUserNotFound}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+
+	_, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestFinishPasskeyRegistration_Successfully(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+
+	credential := registerPasskey(t, authenticator, userId)
+
+	assert.Equal(t, authenticator.credentialId, credential.ID)
+	assert.Equal(t, userId, credential.UserId)
+	assert.Equal(t, authenticator.publicKey(t), credential.PublicKey)
+	assert.Equal(t, "none", credential.AttestationType)
+	assert.Equal(t, []string{"internal"}, credential.Transports)
+	assert.Equal(t, int64(0), credential.SignCount)
+	assert.False(t, credential.CreatedAt.IsZero())
+}
+
+func TestFinishPasskeyRegistration_Audit(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId}}}, GetPasskeyCredentialsResponseArray: []*db.GetPasskeyCredentialsResponse{{}}, UsePasskeyChallengeResponseArray: []*db.ErrorResponse{{Err: nil}}, AddPasskeyCredentialResponseArray: []*db.ErrorResponse{{Err: db.ErrPasskeyExists}}, AddAuditEventResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	userFacade.auditEnabled = true
+	options, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+	assert.Nil(t, err)
+
+	err = userFacade.FinishPasskeyRegistration(context.Background(), userId, options.PasskeyChallengeToken, authenticator.create(t, options))
+
+	assert.ErrorIs(t, err, ErrPasskeyAlreadyRegistered)
+	event := dbConnection.AddAuditEventRecordArray[0].Event
+	assert.Equal(t, AuditPasskeyRegistered, event.EventType)
+	assert.False(t, event.Success)
+	assert.Equal(t, ErrPasskeyAlreadyRegistered.Error(), event.Details)
+}
+
+func TestFinishPasskeyRegistration_Errors(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}, {}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}, {Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId}}, {User: &db.UserDB{ID: userId}}}, GetPasskeyCredentialsResponseArray: []*db.GetPasskeyCredentialsResponse{{}, {}}, UsePasskeyChallengeResponseArray: []*db.ErrorResponse{{}, {}, {}, {}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	options, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+	assert.Nil(t, err)
+	loginOptions, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	wrongOrigin := newSoftwareAuthenticator(t)
+	wrongOrigin.origin = "https://attacker.example.org"
+	otherChallenge, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+	assert.Nil(t, err)
+
+	for _, testCase := range []struct {
+		userId         uuid.UUID
+		challengeToken string
+		credential     []byte
+		error          error
+	}{
+		{uuid.New(), options.PasskeyChallengeToken, newSoftwareAuthenticator(t).create(t, options), ErrInvalidPasskeyChallenge},
+		{userId, loginOptions.PasskeyChallengeToken, newSoftwareAuthenticator(t).create(t, options), ErrInvalidPasskeyChallenge},
+		{userId, "someToken", newSoftwareAuthenticator(t).create(t, options), ErrInvalidPasskeyChallenge},
+		{userId, options.PasskeyChallengeToken, wrongOrigin.create(t, options), ErrInvalidPasskey},
+		{userId, options.PasskeyChallengeToken, newSoftwareAuthenticator(t).create(t, otherChallenge), ErrInvalidPasskey},
+		{userId, options.PasskeyChallengeToken, []byte("{}"), ErrInvalidPasskey},
+	} {
+		err := userFacade.FinishPasskeyRegistration(context.Background(), testCase.userId, testCase.challengeToken, testCase.credential)
+
+		assert.ErrorIs(t, err, testCase.error)
+		assert.Equal(t, 0, len(dbConnection.AddPasskeyCredentialRecordArray))
+	}
+}
+
+func TestLoginWithPasskey_Successfully(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := registerPasskey(t, authenticator, userId)
+	dbConnection := newPasskeyLoginMock(credential, &db.UserDB{ID: userId, Status: adapter.UserStatusActive, Admin: true, TotpEnabled: true})
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	options, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	tokenResponseDTO, err := userFacade.LoginWithPasskey(context.Background(), options.PasskeyChallengeToken, authenticator.get(t, options), clientInfo)
+
+	assert.Nil(t, err)
+	claims := parseAccessToken(t, userFacade, tokenResponseDTO.AccessToken)
+	assert.Equal(t, userId, claims.UserId)
+	assert.True(t, claims.HasRole(adapter.RoleAdmin))
+	assert.Equal(t, []string{adapter.AmrHardwareKey, adapter.AmrMfa}, claims.Amr)
+	assert.Equal(t, claims.Amr, dbConnection.UpdateRefreshTokenRecordArray[0].Amr)
+	assert.Equal(t, authenticator.credentialId, dbConnection.GetPasskeyCredentialRecordArray[0].CredentialId)
+	challenge := dbConnection.UsePasskeyChallengeRecordArray[0]
+	assert.NotEmpty(t, challenge.Challenge)
+	assert.True(t, challenge.ExpireAt.After(time.Now()))
+	usage := dbConnection.UpdatePasskeyCredentialUsageRecordArray[0].Credential
+	assert.Equal(t, int64(1), usage.SignCount)
+	assert.NotNil(t, usage.LastUsedAt)
+	assert.Equal(t, 1, len(dbConnection.ResetFailedLoginRecordArray))
+	assert.True(t, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+}
+
+func TestBeginPasskeyLogin_Options(t *testing.T) {
+	userFacade := newPasskeyUserFacade(t, &db.DBMock{})
+
+	options, err := userFacade.BeginPasskeyLogin(context.Background())
+
+	assert.Nil(t, err)
+	requestOptions := &protocol.PublicKeyCredentialRequestOptions{}
+	assert.Nil(t, json.Unmarshal(options.PublicKey, requestOptions))
+	assert.Equal(t, "localhost", requestOptions.RelyingPartyID)
+	assert.Equal(t, protocol.VerificationRequired, requestOptions.UserVerification)
+	assert.Empty(t, requestOptions.AllowedCredentials)
+	assert.Len(t, requestOptions.Challenge, 32)
+}
+
+// Logs in with a passkey of the authenticator that is stored as given credential
+func loginWithPasskey(t *testing.T, authenticator *softwareAuthenticator, credential *db.PasskeyCredentialDB, user *db.UserDB) (*db.DBMock, *adapter.TokenResponseDTO, error) {
+	dbConnection := newPasskeyLoginMock(credential, user)
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	options, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	tokenResponseDTO, err := userFacade.LoginWithPasskey(context.Background(), options.PasskeyChallengeToken, authenticator.get(t, options), clientInfo)
+	return dbConnection, tokenResponseDTO, err
+}
+
+func TestLoginWithPasskey_InvalidAssertion(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := registerPasskey(t, authenticator, userId)
+	otherKey := newSoftwareAuthenticator(t)
+	otherKey.credentialId = authenticator.credentialId
+	otherKey.userHandle = authenticator.userHandle
+	otherUserHandle := *authenticator
+	otherUserHandle.userHandle = []byte("otherUser")
+	otherOrigin := *authenticator
+	otherOrigin.origin = "https://attacker.example.org"
+	clonedCredential := *credential
+	clonedCredential.SignCount = 10
+
+	for _, testCase := range []struct {
+		authenticator *softwareAuthenticator
+		credential    *db.PasskeyCredentialDB
+	}{
+		{otherKey, credential},
+		{&otherUserHandle, credential},
+		{&otherOrigin, credential},
+		{authenticator, &clonedCredential},
+	} {
+		dbConnection, tokenResponseDTO, err := loginWithPasskey(t, testCase.authenticator, testCase.credential, &db.UserDB{ID: userId, Status: adapter.UserStatusActive})
+
+		assert.Nil(t, tokenResponseDTO)
+		assert.ErrorIs(t, err, ErrInvalidPasskey)
+		assert.Equal(t, userId, dbConnection.IncrementFailedLoginRecordArray[0].UserId)
+		assert.Equal(t, 0, len(dbConnection.UpdatePasskeyCredentialUsageRecordArray))
+		assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+		assert.False(t, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+	}
+}
+
+func TestLoginWithPasskey_UnknownPasskey(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	dbConnection := &db.DBMock{UsePasskeyChallengeResponseArray: []*db.ErrorResponse{{Err: nil}}, GetPasskeyCredentialResponseArray: []*db.GetPasskeyCredentialResponse{{Err: db.ErrPasskeyNotFound}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	options, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	tokenResponseDTO, err := userFacade.LoginWithPasskey(context.Background(), options.PasskeyChallengeToken, authenticator.get(t, options), clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrInvalidPasskey)
+	assert.Equal(t, 0, len(dbConnection.GetLockedUntilRecordArray))
+}
+
+func TestLoginWithPasskey_InvalidChallenge(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId}}}, GetPasskeyCredentialsResponseArray: []*db.GetPasskeyCredentialsResponse{{}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	registrationOptions, err := userFacade.BeginPasskeyRegistration(context.Background(), userId, password)
+	assert.Nil(t, err)
+	loginOptions, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	for _, challengeToken := range []string{"", "someToken", registrationOptions.PasskeyChallengeToken} {
+		tokenResponseDTO, err := userFacade.LoginWithPasskey(context.Background(), challengeToken, authenticator.get(t, loginOptions), clientInfo)
+
+		assert.Nil(t, tokenResponseDTO)
+		assert.ErrorIs(t, err, ErrInvalidPasskeyChallenge)
+		assert.Equal(t, 0, len(dbConnection.GetPasskeyCredentialRecordArray))
+	}
+}
+
+func TestLoginWithPasskey_ChallengeUsed(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	dbConnection := &db.DBMock{UsePasskeyChallengeResponseArray: []*db.ErrorResponse{{Err: db.ErrChallengeUsed}, {Err: errors.New("some error")}}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	options, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	tokenResponseDTO, err := userFacade.LoginWithPasskey(context.Background(), options.PasskeyChallengeToken, authenticator.get(t, options), clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrInvalidPasskeyChallenge)
+
+	tokenResponseDTO, err = userFacade.LoginWithPasskey(context.Background(), options.PasskeyChallengeToken, authenticator.get(t, options), clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.NotErrorIs(t, err, ErrInvalidPasskeyChallenge)
+	assert.Equal(t, 0, len(dbConnection.GetPasskeyCredentialRecordArray))
+}
+
+func TestLoginWithPasskey_Locked(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := registerPasskey(t, authenticator, userId)
+
+	for _, testCase := range []struct {
+		user  *db.UserDB
+		error error
+	}{
+		{&db.UserDB{ID: userId, Status: adapter.UserStatusDisabled}, ErrUserDisabled},
+		{&db.UserDB{ID: userId, Status: adapter.UserStatusPending}, ErrUserPending},
+	} {
+		dbConnection, tokenResponseDTO, err := loginWithPasskey(t, authenticator, credential, testCase.user)
+
+		assert.Nil(t, tokenResponseDTO)
+		assert.ErrorIs(t, err, testCase.error)
+		assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+	}
+}
+
+func TestLoginWithPasskey_Audit(t *testing.T) {
+	authenticator := newSoftwareAuthenticator(t)
+	credential := registerPasskey(t, authenticator, userId)
+	dbConnection := newPasskeyLoginMock(credential, &db.UserDB{ID: userId, Status: adapter.UserStatusActive})
+	dbConnection.AddAuditEventResponseArray = []*db.ErrorResponse{{Err: nil}}
+	userFacade := newPasskeyUserFacade(t, dbConnection)
+	userFacade.auditEnabled = true
+	options, err := userFacade.BeginPasskeyLogin(context.Background())
+	assert.Nil(t, err)
+
+	_, err = userFacade.LoginWithPasskey(context.Background(), options.PasskeyChallengeToken, authenticator.get(t, options), clientInfo)
+
+	assert.Nil(t, err)
+	event := dbConnection.AddAuditEventRecordArray[0].Event
+	assert.Equal(t, AuditUserLogin, event.EventType)
+	assert.Equal(t, userId, event.UserId)
+	assert.True(t, event.Success)
+}
+
+func TestPasskeyUser_Credentials(t *testing.T) {
+	credential := &db.PasskeyCredentialDB{ID: []byte("credential"), PublicKey: []byte("key"), AttestationType: "none", Transports: []string{"usb", "nfc"}, AAGUID: make([]byte, 16), SignCount: 3, BackupEligible: true, BackupState: true}
+
+	webauthnCredential := mapToWebauthnCredential(credential)
+	mapped := mapToPasskeyCredentialDB(userId, &webauthnCredential)
+
+	assert.Equal(t, credential.ID, mapped.ID)
+	assert.Equal(t, userId, mapped.UserId)
+	assert.Equal(t, credential.PublicKey, mapped.PublicKey)
+	assert.Equal(t, credential.Transports, mapped.Transports)
+	assert.Equal(t, credential.SignCount, mapped.SignCount)
+	assert.True(t, mapped.BackupEligible)
+	assert.True(t, mapped.BackupState)
+	assert.Equal(t, []byte(userId[:]), (&passkeyUser{id: userId}).WebAuthnID())
+}
@@ -0,0 +1,71 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+const (
+	// Audience of password change tokens
+	passwordChangeAudience = "authi:password-change"
+)
+
+type (
+	// Error of a login with an expired password. The token only allows to change the password with ChangeExpiredPassword
+	PasswordExpiredError struct {
+		PasswordChangeToken string
+	}
+
+	// Claims of password change tokens. The methods of the login are kept for the tokens after the change
+	passwordChangeClaims struct {
+		jwt.StandardClaims
+		Amr []string `json:"amr,omitempty"`
+	}
+)
+
+func (err *PasswordExpiredError) Error() string {
+	return ErrPasswordExpired.Error()
+}
+
+func (err *PasswordExpiredError) Is(target error) bool {
+	return target == ErrPasswordExpired
+}
+
+func (userFacade *UserFacade) passwordExpiredError(userId uuid.UUID, amr []string) error {
+	claims := &passwordChangeClaims{
+		StandardClaims: jwt.StandardClaims{
+			Subject:   userId.String(),
+			Audience:  passwordChangeAudience,
+			ExpiresAt: time.Now().Add(time.Duration(userFacade.accessTokenExpireTime) * time.Minute).Unix(),
+		},
+		Amr: amr,
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(userFacade.signKey)
+	if err != nil {
+		return fmt.Errorf("password change token creation failed: %v", err)
+	}
+	return &PasswordExpiredError{PasswordChangeToken: token}
+}
+
+// Sets a new password for a user whose password expired. Besides the token of the login the current password is checked like UpdatePassword does
+func (userFacade *UserFacade) ChangeExpiredPassword(ctx context.Context, userId uuid.UUID, changeToken string, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	token, err := userFacade.changeExpiredPassword(ctx, userId, changeToken, currentPassword, password)
+	userFacade.audit(ctx, AuditPasswordChanged, userId, nil, err)
+	return token, err
+}
+
+func (userFacade *UserFacade) changeExpiredPassword(ctx context.Context, userId uuid.UUID, changeToken string, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	claims := &passwordChangeClaims{}
+	if err := userFacade.parseAudienceToken(changeToken, passwordChangeAudience, claims); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidChangeToken, err)
+	}
+	if claims.Subject != userId.String() {
+		return nil, fmt.Errorf("%w: token doesn't belong to user %v", ErrInvalidChangeToken, userId)
+	}
+	return userFacade.updatePassword(ctx, userId, currentPassword, password, claims.Amr)
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	expiringPolicy     = newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 8, MaxLength: 128, HistorySize: 3, MaxAge: 90}, nil)
+	expiredPasswordDay = time.Now().Add(-91 * 24 * time.Hour)
+)
+
+func TestPasswordPolicy_Expired(t *testing.T) {
+	var policy *passwordPolicy
+
+	assert.True(t, expiringPolicy.expired(expiredPasswordDay))
+	assert.False(t, expiringPolicy.expired(time.Now().Add(-89*24*time.Hour)))
+	assert.False(t, policy.expired(expiredPasswordDay))
+	assert.False(t, newPasswordPolicy(&config.PasswordPolicyConfig{}, nil).expired(expiredPasswordDay))
+}
+
+func TestLoginUser_PasswordExpired(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{PasswordChangedAt: expiredPasswordDay}}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, loginHistorySize: 10, passwordPolicy: expiringPolicy}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrPasswordExpired)
+	var expiredErr *PasswordExpiredError
+	assert.True(t, errors.As(err, &expiredErr))
+	claims := &jwt.StandardClaims{}
+	_, err = jwt.ParseWithClaims(expiredErr.PasswordChangeToken, claims, func(token *jwt.Token) (interface{}, error) {
+		return &signKey.PublicKey, nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, passwordChangeAudience, claims.Audience)
+	assert.Equal(t, userId.String(), claims.Subject)
+
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.False(t, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+}
+
+func TestLoginUser_PasswordNotExpired(t *testing.T) {
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{PasswordChangedAt: time.Now()}}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, passwordPolicy: expiringPolicy}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, tokenResponseDTO)
+}
+
+func TestRefreshToken_PasswordExpired(t *testing.T) {
+	dbConnection := &db.DBMock{CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{PasswordChangedAt: expiredPasswordDay}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: expiringPolicy}
+
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
+
+	assert.Nil(t, tokenResponseDTO)
+	assert.ErrorIs(t, err, ErrPasswordExpired)
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+}
+
+func TestChangeExpiredPassword_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{PasswordChangedAt: expiredPasswordDay}}}, IsRecentPasswordResponseArray: []*db.IsRecentPasswordResponse{{Recent: false}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, passwordPolicy: expiringPolicy}
+	changeToken := userFacade.passwordExpiredError(userId, nil).(*PasswordExpiredError).PasswordChangeToken
+
+	tokenResponseDTO, err := userFacade.ChangeExpiredPassword(context.Background(), userId, changeToken, password, "some new password")
+
+	assert.Nil(t, err)
+	assert.Equal(t, dbConnection.UpdateRefreshTokenRecordArray[0].RefreshToken, tokenResponseDTO.RefreshToken)
+	assert.Equal(t, password, dbConnection.LoginUserRecordArray[0].User.Password)
+	assert.Equal(t, "some new password", dbConnection.UpdatePasswordRecordArray[0].Password)
+	assert.Equal(t, 3, dbConnection.UpdatePasswordRecordArray[0].HistorySize)
+}
+
+func TestChangeExpiredPassword_InvalidToken(t *testing.T) {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	otherUserFacade := &UserFacade{signKey: signKey, accessTokenExpireTime: 5, emailVerification: &emailVerificationConfig{expireTime: time.Hour}}
+	verificationToken, err := otherUserFacade.createEmailVerificationToken(userId, "max@example.org", "someId")
+	assert.Nil(t, err)
+
+	for _, changeToken := range []string{"", "someToken", verificationToken, otherUserFacade.passwordExpiredError(uuid.New(), nil).(*PasswordExpiredError).PasswordChangeToken} {
+		dbConnection := &db.DBMock{}
+		userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, passwordPolicy: expiringPolicy}
+
+		tokenResponseDTO, err := userFacade.ChangeExpiredPassword(context.Background(), userId, changeToken, password, "some new password")
+
+		assert.Nil(t, tokenResponseDTO)
+		assert.ErrorIs(t, err, ErrInvalidChangeToken)
+		assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+	}
+}
+
+func TestUpdatePassword_ReusedPassword(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, IsRecentPasswordResponseArray: []*db.IsRecentPasswordResponse{{Recent: true}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: expiringPolicy}
+
+	_, err := userFacade.UpdatePassword(context.Background(), userId, password, "short")
+
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, []string{adapter.PasswordTooShort, adapter.PasswordReused}, err.(*PasswordPolicyError).Violations)
+	assert.Equal(t, userId, dbConnection.IsRecentPasswordRecordArray[0].UserId)
+	assert.Equal(t, "short", dbConnection.IsRecentPasswordRecordArray[0].Password)
+	assert.Equal(t, 3, dbConnection.IsRecentPasswordRecordArray[0].HistorySize)
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+}
+
+func TestUpdatePassword_HistoryCheckFailed(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, IsRecentPasswordResponseArray: []*db.IsRecentPasswordResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: expiringPolicy}
+
+	_, err := userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
+
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+}
+
+func TestConfirmPasswordReset_PassesHistorySize(t *testing.T) {
+	dbConnection := &db.DBMock{GetPasswordResetUserIdResponseArray: []*db.GetPasswordResetUserIdResponse{{UserId: userId}}, IsRecentPasswordResponseArray: []*db.IsRecentPasswordResponse{{Recent: false}}, ResetPasswordWithTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: expiringPolicy}
+
+	err := userFacade.ConfirmPasswordReset(context.Background(), "someToken", "some new password")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 3, dbConnection.ResetPasswordWithTokenRecordArray[0].HistorySize)
+}
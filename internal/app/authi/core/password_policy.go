@@ -0,0 +1,181 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/breach"
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"golang.org/x/text/unicode/norm"
+)
+
+type (
+	passwordPolicy struct {
+		minLength        int
+		maxLength        int
+		requireUppercase bool
+		requireLowercase bool
+		requireDigit     bool
+		requireSpecial   bool
+		rejectUserData   bool
+		normalize        bool
+		breachChecker    breach.Checker
+		historySize      int
+		maxAge           time.Duration
+	}
+
+	// Error with the codes of all rules of the password policy that a new password violates
+	PasswordPolicyError struct {
+		Violations []string
+	}
+)
+
+var (
+	ErrPasswordPolicy = errors.New("password violates policy")
+)
+
+func newPasswordPolicy(policyConfig *config.PasswordPolicyConfig, breachChecker breach.Checker) *passwordPolicy {
+	return &passwordPolicy{
+		minLength:        policyConfig.MinLength,
+		maxLength:        policyConfig.MaxLength,
+		requireUppercase: policyConfig.RequireUppercase,
+		requireLowercase: policyConfig.RequireLowercase,
+		requireDigit:     policyConfig.RequireDigit,
+		requireSpecial:   policyConfig.RequireSpecial,
+		rejectUserData:   policyConfig.RejectUserData,
+		normalize:        policyConfig.Normalize,
+		breachChecker:    breachChecker,
+		historySize:      policyConfig.HistorySize,
+		maxAge:           time.Duration(policyConfig.MaxAge) * 24 * time.Hour,
+	}
+}
+
+func (err *PasswordPolicyError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrPasswordPolicy, strings.Join(err.Violations, ", "))
+}
+
+func (err *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// Normalizes the password with NFKC, so different unicode representations of the same characters result in the same password.
+// Passwords have to be normalized wherever they are hashed or checked. Without policy the password is kept as it is
+func (policy *passwordPolicy) normalizePassword(password string) string {
+	if policy == nil || !policy.normalize {
+		return password
+	}
+	return norm.NFKC.String(password)
+}
+
+// Checks a normalized password against all rules and reports every violated rule. Without policy every password is valid
+func (policy *passwordPolicy) check(password string, userId uuid.UUID, username string) error {
+	if policy == nil {
+		return nil
+	}
+
+	var violations []string
+	length := utf8.RuneCountInString(password)
+	if length < policy.minLength {
+		violations = append(violations, adapter.PasswordTooShort)
+	}
+	if length > policy.maxLength {
+		violations = append(violations, adapter.PasswordTooLong)
+	}
+	if policy.requireUppercase && !strings.ContainsFunc(password, unicode.IsUpper) {
+		violations = append(violations, adapter.PasswordMissingUppercase)
+	}
+	if policy.requireLowercase && !strings.ContainsFunc(password, unicode.IsLower) {
+		violations = append(violations, adapter.PasswordMissingLowercase)
+	}
+	if policy.requireDigit && !strings.ContainsFunc(password, unicode.IsDigit) {
+		violations = append(violations, adapter.PasswordMissingDigit)
+	}
+	if policy.requireSpecial && !strings.ContainsFunc(password, isSpecial) {
+		violations = append(violations, adapter.PasswordMissingSpecial)
+	}
+	if policy.rejectUserData {
+		lowerPassword := strings.ToLower(password)
+		if strings.Contains(lowerPassword, userId.String()) {
+			violations = append(violations, adapter.PasswordContainsUserId)
+		}
+		if username != "" && strings.Contains(lowerPassword, strings.ToLower(username)) {
+			violations = append(violations, adapter.PasswordContainsUsername)
+		}
+	}
+	if policy.breachChecker != nil {
+		breached, err := policy.breachChecker.Contains(password)
+		if err != nil {
+			return fmt.Errorf("error while checking password against breached passwords: %v", err)
+		}
+		if breached {
+			violations = append(violations, adapter.PasswordBreached)
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// Number of last passwords including the current one that can't be reused. Without policy passwords can always be reused
+func (policy *passwordPolicy) recentPasswords() int {
+	if policy == nil {
+		return 0
+	}
+	return policy.historySize
+}
+
+// Reports whether the password that was set at the given time has to be changed. Without policy passwords never expire
+func (policy *passwordPolicy) expired(changedAt time.Time) bool {
+	return policy != nil && policy.maxAge > 0 && time.Since(changedAt) > policy.maxAge
+}
+
+func isSpecial(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Normalizes the new password of an existing user and checks it against the policy and the last passwords of the user.
+// The username is only loaded if the policy needs it
+func (userFacade *UserFacade) checkNewPassword(ctx context.Context, userId uuid.UUID, password string) (string, error) {
+	password = userFacade.passwordPolicy.normalizePassword(password)
+	if userFacade.passwordPolicy == nil {
+		return password, nil
+	}
+
+	username := ""
+	if userFacade.passwordPolicy.rejectUserData {
+		dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+		if err != nil {
+			return "", adminError("error while loading user to check password", err)
+		}
+		username = dbUser.Username
+	}
+	err := userFacade.passwordPolicy.check(password, userId, username)
+	if err != nil && !errors.Is(err, ErrPasswordPolicy) {
+		return "", err
+	}
+
+	if userFacade.passwordPolicy.historySize > 0 {
+		recent, historyErr := userFacade.dbConnection.IsRecentPassword(ctx, userId, password, userFacade.passwordPolicy.historySize)
+		if historyErr != nil {
+			return "", fmt.Errorf("error while checking password history: %v", historyErr)
+		}
+		if recent {
+			violations := []string{adapter.PasswordReused}
+			var policyErr *PasswordPolicyError
+			if errors.As(err, &policyErr) {
+				violations = append(policyErr.Violations, violations...)
+			}
+			err = &PasswordPolicyError{Violations: violations}
+		}
+	}
+	return password, err
+}
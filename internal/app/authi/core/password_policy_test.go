@@ -0,0 +1,159 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/breach"
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	strictPolicy = newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 8, MaxLength: 16, RequireUppercase: true, RequireLowercase: true, RequireDigit: true, RequireSpecial: true, RejectUserData: true, Normalize: true}, nil)
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	for password, expectedViolations := range map[string][]string{
+		"Some-Pass1":                 nil,
+		"Äpfel-Birne2":               nil,
+		"S-p1":                       {adapter.PasswordTooShort},
+		"Some-Very-Long-Password1":   {adapter.PasswordTooLong},
+		"some-pass1":                 {adapter.PasswordMissingUppercase},
+		"SOME-PASS1":                 {adapter.PasswordMissingLowercase},
+		"Some-Pass":                  {adapter.PasswordMissingDigit},
+		"SomePass1":                  {adapter.PasswordMissingSpecial},
+		"Max-Power1":                 {adapter.PasswordContainsUsername},
+		"x" + userId.String() + "A1": {adapter.PasswordTooLong, adapter.PasswordContainsUserId},
+		"short":                      {adapter.PasswordTooShort, adapter.PasswordMissingUppercase, adapter.PasswordMissingDigit, adapter.PasswordMissingSpecial},
+	} {
+		err := strictPolicy.check(password, userId, "max")
+
+		if expectedViolations == nil {
+			assert.Nil(t, err, password)
+			continue
+		}
+		assert.ErrorIs(t, err, ErrPasswordPolicy, password)
+		assert.Equal(t, expectedViolations, err.(*PasswordPolicyError).Violations, password)
+	}
+}
+
+func TestPasswordPolicy_Breached(t *testing.T) {
+	breachChecker := &breach.CheckerMock{ContainsResponseArray: []*breach.ContainsResponse{{Contained: true}, {Contained: false}}}
+	policy := newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 8, MaxLength: 16}, breachChecker)
+
+	err := policy.check("password", userId, "")
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, []string{adapter.PasswordBreached}, err.(*PasswordPolicyError).Violations)
+
+	assert.Nil(t, policy.check("Some-Pass1", userId, ""))
+	assert.Equal(t, "Some-Pass1", breachChecker.ContainsRecordArray[1].Password)
+}
+
+func TestPasswordPolicy_BreachCheckFailed(t *testing.T) {
+	breachChecker := &breach.CheckerMock{ContainsResponseArray: []*breach.ContainsResponse{{Err: errUnknown}}}
+	policy := newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 8, MaxLength: 16}, breachChecker)
+
+	err := policy.check("Some-Pass1", userId, "")
+
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrPasswordPolicy)
+}
+
+func TestPasswordPolicy_CountsCharacters(t *testing.T) {
+	policy := newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 4, MaxLength: 4}, nil)
+
+	assert.Nil(t, policy.check("äöüß", userId, ""))
+}
+
+func TestPasswordPolicy_WithoutPolicy(t *testing.T) {
+	var policy *passwordPolicy
+
+	assert.Nil(t, policy.check("", userId, "max"))
+	assert.Equal(t, "ｐａｓｓ", policy.normalizePassword("ｐａｓｓ"))
+}
+
+func TestPasswordPolicy_Normalize(t *testing.T) {
+	assert.Equal(t, "pass", strictPolicy.normalizePassword("ｐａｓｓ"))
+	assert.Equal(t, "é", strictPolicy.normalizePassword("é"))
+
+	policy := newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 1, MaxLength: 10}, nil)
+	assert.Equal(t, "é", policy.normalizePassword("é"))
+}
+
+func TestCreateUser_PasswordPolicy(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: strictPolicy}
+
+	err := userFacade.CreateUser(context.Background(), userId, "some-max-Pass1", &UserIdentifiers{Username: "Max"}, false)
+
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, []string{adapter.PasswordContainsUsername}, err.(*PasswordPolicyError).Violations)
+	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
+}
+
+func TestCreateUser_NormalizesPassword(t *testing.T) {
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: strictPolicy}
+
+	err := userFacade.CreateUser(context.Background(), userId, "Ｓｏｍｅ-Pass1", nil, false)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Some-Pass1", dbConnection.CreateUserRecordArray[0].User.Password)
+}
+
+func TestLoginUser_NormalizesPassword(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: strictPolicy}
+
+	_, err := userFacade.LoginUser(context.Background(), userId, "Ｓｏｍｅ-Pass1", clientInfo)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, "Some-Pass1", dbConnection.LoginUserRecordArray[0].User.Password)
+}
+
+func TestUpdatePassword_BreachedPassword(t *testing.T) {
+	breachChecker := &breach.CheckerMock{ContainsResponseArray: []*breach.ContainsResponse{{Contained: true}}}
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: newPasswordPolicy(&config.PasswordPolicyConfig{MinLength: 8, MaxLength: 128}, breachChecker)}
+
+	_, err := userFacade.UpdatePassword(context.Background(), userId, password, "password")
+
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, []string{adapter.PasswordBreached}, err.(*PasswordPolicyError).Violations)
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+}
+
+func TestUpdatePassword_PasswordPolicy(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Username: "max"}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: strictPolicy}
+
+	_, err := userFacade.UpdatePassword(context.Background(), userId, password, "Max-Power1")
+
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, userId, dbConnection.GetUserRecordArray[0].UserId)
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+}
+
+func TestResetPassword_PasswordPolicy(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: strictPolicy}
+
+	err := userFacade.ResetPassword(context.Background(), userId, "short")
+
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, 0, len(dbConnection.RevokeRefreshTokenRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+}
+
+func TestConfirmPasswordReset_PasswordPolicy(t *testing.T) {
+	dbConnection := &db.DBMock{GetPasswordResetUserIdResponseArray: []*db.GetPasswordResetUserIdResponse{{UserId: userId}}, GetUserResponseArray: []*db.GetUserResponse{{User: &db.UserDB{ID: userId}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, passwordPolicy: strictPolicy}
+
+	err := userFacade.ConfirmPasswordReset(context.Background(), "someToken", "short")
+
+	assert.ErrorIs(t, err, ErrPasswordPolicy)
+	assert.Equal(t, 0, len(dbConnection.ResetPasswordWithTokenRecordArray))
+}
@@ -0,0 +1,130 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/BeanCodeDe/authi/internal/app/authi/mail"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+)
+
+const passwordResetSubject = "Reset your password"
+
+type (
+	passwordResetConfig struct {
+		expireTime time.Duration
+		url        string
+	}
+)
+
+// Sends a mail with a reset token to the verified email of the user with the identifier. Unknown identifiers, users without
+// verified email and inactive users are ignored. The request is handled in the background and errors are only logged,
+// so neither the result nor the response time reveals whether an account exists
+func (userFacade *UserFacade) RequestPasswordReset(ctx context.Context, identifier string) {
+	ctx = context.WithoutCancel(ctx)
+	userFacade.background.Add(1)
+	go func() {
+		defer userFacade.background.Done()
+		if err := userFacade.handlePasswordResetRequest(ctx, identifier); err != nil {
+			logging.FromContext(ctx).Errorf("Something went wrong while requesting password reset: %v", err)
+		}
+	}()
+}
+
+func (userFacade *UserFacade) handlePasswordResetRequest(ctx context.Context, identifier string) error {
+	userId, err := userFacade.dbConnection.GetUserIdByIdentifier(ctx, identifier)
+	if errors.Is(err, db.ErrUserNotFound) {
+		logging.FromContext(ctx).Debugf("No user found for password reset")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error while loading user for password reset: %v", err)
+	}
+
+	err = userFacade.requestPasswordReset(ctx, userId)
+	userFacade.audit(ctx, AuditPasswordResetRequested, userId, nil, err)
+	if errors.Is(err, ErrNoEmail) || errors.Is(err, ErrUserDisabled) || errors.Is(err, ErrUserPending) || errors.Is(err, ErrUserLocked) {
+		logging.FromContext(ctx).Debugf("Password reset of user %v ignored: %v", userId, err)
+		return nil
+	}
+	return err
+}
+
+func (userFacade *UserFacade) requestPasswordReset(ctx context.Context, userId uuid.UUID) error {
+	dbUser, err := userFacade.dbConnection.GetUser(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("error while loading user for password reset: %v", err)
+	}
+	if dbUser.Email == "" || !dbUser.EmailVerified {
+		return ErrNoEmail
+	}
+	if err := statusError(dbUser.Status); err != nil {
+		return err
+	}
+
+	token := randomString()
+	expireAt := time.Now().Add(userFacade.passwordReset.expireTime)
+	if err := userFacade.dbConnection.CreatePasswordReset(ctx, userId, hashResetToken(token), expireAt); err != nil {
+		return fmt.Errorf("error while storing password reset: %v", err)
+	}
+
+	message := &mail.Message{To: dbUser.Email, Subject: passwordResetSubject, Body: userFacade.passwordResetBody(token)}
+	if err := userFacade.mailSender.Send(ctx, message); err != nil {
+		return fmt.Errorf("error while sending password reset mail: %v", err)
+	}
+	return nil
+}
+
+func (userFacade *UserFacade) passwordResetBody(token string) string {
+	expireMinutes := int(userFacade.passwordReset.expireTime.Minutes())
+	if userFacade.passwordReset.url == "" {
+		return fmt.Sprintf("Please reset your password within %d minutes with the following token:\n\n%s", expireMinutes, token)
+	}
+	url := strings.ReplaceAll(userFacade.passwordReset.url, "{token}", token)
+	return fmt.Sprintf("Please reset your password within %d minutes by opening the following link:\n\n%s", expireMinutes, url)
+}
+
+// Sets the new password of the user the reset token was sent to and revokes all sessions of the user. The token can only be used once
+func (userFacade *UserFacade) ConfirmPasswordReset(ctx context.Context, token string, password string) error {
+	tokenHash := hashResetToken(token)
+	userId, err := userFacade.dbConnection.GetPasswordResetUserId(ctx, tokenHash)
+	if errors.Is(err, db.ErrResetNotFound) {
+		return fmt.Errorf("%w: %v", ErrInvalidResetToken, err)
+	}
+	if err != nil {
+		return fmt.Errorf("error while loading password reset: %v", err)
+	}
+
+	err = userFacade.confirmPasswordReset(ctx, userId, tokenHash, password)
+	userFacade.audit(ctx, AuditPasswordReset, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) confirmPasswordReset(ctx context.Context, userId uuid.UUID, tokenHash string, password string) error {
+	password, err := userFacade.checkNewPassword(ctx, userId, password)
+	if err != nil {
+		return err
+	}
+	webhooks := append(userFacade.webhookOutbox(ctx, adapter.WebhookSessionRevoked, userId), userFacade.webhookOutbox(ctx, adapter.WebhookPasswordChanged, userId)...)
+	if err := userFacade.dbConnection.ResetPasswordWithToken(ctx, userId, tokenHash, password, randomString(), userFacade.passwordPolicy.recentPasswords(), webhooks...); err != nil {
+		if errors.Is(err, db.ErrResetNotFound) {
+			return fmt.Errorf("%w: %v", ErrInvalidResetToken, err)
+		}
+		return fmt.Errorf("error while resetting password of user: %v", err)
+	}
+	return nil
+}
+
+// Only the hash of reset tokens is stored, so a leaked database can't be used to reset passwords
+func hashResetToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
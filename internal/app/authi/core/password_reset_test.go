@@ -0,0 +1,180 @@
+package core
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/mail"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	passwordReset = &passwordResetConfig{expireTime: 15 * time.Minute, url: "https://example.org/reset?token={token}"}
+)
+
+func newPasswordResetFacade(dbConnection db.Connection, mailSender mail.Sender) *UserFacade {
+	return &UserFacade{dbConnection: dbConnection, mailSender: mailSender, passwordReset: passwordReset}
+}
+
+func TestRequestPasswordReset_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{
+		GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{UserId: userId}},
+		GetUserResponseArray:               []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Email: "max@example.org", EmailVerified: true, Status: adapter.UserStatusActive}}},
+		CreatePasswordResetResponseArray:   []*db.ErrorResponse{{Err: nil}},
+	}
+	mailSender := &mail.SenderMock{SendResponseArray: []*mail.SendResponse{{Err: nil}}}
+	userFacade := newPasswordResetFacade(dbConnection, mailSender)
+
+	userFacade.RequestPasswordReset(context.Background(), "max@example.org")
+	userFacade.background.Wait()
+
+	assert.Equal(t, "max@example.org", dbConnection.GetUserIdByIdentifierRecordArray[0].Identifier)
+	message := mailSender.SendRecordArray[0].Message
+	assert.Equal(t, "max@example.org", message.To)
+	assert.Contains(t, message.Body, "15 minutes")
+	assert.Contains(t, message.Body, "https://example.org/reset?token=")
+
+	token := message.Body[strings.Index(message.Body, "?token=")+len("?token="):]
+	reset := dbConnection.CreatePasswordResetRecordArray[0]
+	assert.Equal(t, userId, reset.UserId)
+	assert.Equal(t, hashResetToken(token), reset.TokenHash)
+	assert.NotContains(t, reset.TokenHash, token)
+	assert.WithinDuration(t, time.Now().Add(15*time.Minute), reset.ExpireAt, time.Minute)
+}
+
+func TestRequestPasswordReset_UnknownIdentifier(t *testing.T) {
+	dbConnection := &db.DBMock{GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{Err: db.ErrUserNotFound}}}
+	mailSender := &mail.SenderMock{}
+	userFacade := newPasswordResetFacade(dbConnection, mailSender)
+
+	userFacade.RequestPasswordReset(context.Background(), "unknown")
+	userFacade.background.Wait()
+
+	assert.Equal(t, 0, len(dbConnection.GetUserRecordArray))
+	assert.Equal(t, 0, len(mailSender.SendRecordArray))
+}
+
+func TestRequestPasswordReset_IgnoredUsers(t *testing.T) {
+	for _, user := range []*db.UserDB{
+		{ID: userId, Status: adapter.UserStatusActive},
+		{ID: userId, Email: "max@example.org", Status: adapter.UserStatusActive},
+		{ID: userId, Email: "max@example.org", EmailVerified: true, Status: adapter.UserStatusDisabled},
+		{ID: userId, Email: "max@example.org", EmailVerified: true, Status: adapter.UserStatusLocked},
+	} {
+		dbConnection := &db.DBMock{
+			GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{UserId: userId}},
+			GetUserResponseArray:               []*db.GetUserResponse{{User: user}},
+		}
+		mailSender := &mail.SenderMock{}
+		userFacade := newPasswordResetFacade(dbConnection, mailSender)
+
+		userFacade.RequestPasswordReset(context.Background(), "max")
+		userFacade.background.Wait()
+
+		assert.Equal(t, 0, len(dbConnection.CreatePasswordResetRecordArray))
+		assert.Equal(t, 0, len(mailSender.SendRecordArray))
+	}
+}
+
+func TestRequestPasswordReset_SendError(t *testing.T) {
+	dbConnection := &db.DBMock{
+		GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{UserId: userId}},
+		GetUserResponseArray:               []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Email: "max@example.org", EmailVerified: true}}},
+		CreatePasswordResetResponseArray:   []*db.ErrorResponse{{Err: nil}},
+	}
+	mailSender := &mail.SenderMock{SendResponseArray: []*mail.SendResponse{{Err: errUnknown}}}
+	userFacade := newPasswordResetFacade(dbConnection, mailSender)
+
+	userFacade.RequestPasswordReset(context.Background(), "max")
+	userFacade.background.Wait()
+
+	assert.Equal(t, 1, len(mailSender.SendRecordArray))
+}
+
+// Sender that only sends once it is released and records whether the context was still active then
+type blockingSender struct {
+	release chan struct{}
+	err     error
+}
+
+func (sender *blockingSender) Send(ctx context.Context, message *mail.Message) error {
+	<-sender.release
+	sender.err = ctx.Err()
+	return sender.err
+}
+
+func TestRequestPasswordReset_DoesNotWaitForMail(t *testing.T) {
+	dbConnection := &db.DBMock{
+		GetUserIdByIdentifierResponseArray: []*db.GetUserIdByIdentifierResponse{{UserId: userId}},
+		GetUserResponseArray:               []*db.GetUserResponse{{User: &db.UserDB{ID: userId, Email: "max@example.org", EmailVerified: true}}},
+		CreatePasswordResetResponseArray:   []*db.ErrorResponse{{Err: nil}},
+	}
+	mailSender := &blockingSender{release: make(chan struct{})}
+	userFacade := newPasswordResetFacade(dbConnection, mailSender)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	userFacade.RequestPasswordReset(ctx, "max")
+	cancel()
+	close(mailSender.release)
+	userFacade.background.Wait()
+
+	assert.Nil(t, mailSender.err)
+	assert.Equal(t, 1, len(dbConnection.CreatePasswordResetRecordArray))
+}
+
+func TestConfirmPasswordReset_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{
+		GetPasswordResetUserIdResponseArray: []*db.GetPasswordResetUserIdResponse{{UserId: userId}},
+		ResetPasswordWithTokenResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+	userFacade := newPasswordResetFacade(dbConnection, &mail.SenderMock{})
+	userFacade.webhooks = []config.WebhookSubscription{{Url: "https://example.org/hook", Secret: "someSecretOfLength", Events: []string{adapter.WebhookPasswordChanged, adapter.WebhookSessionRevoked}}}
+
+	err := userFacade.ConfirmPasswordReset(context.Background(), "someToken", "newPassword")
+
+	assert.Nil(t, err)
+	assert.Equal(t, hashResetToken("someToken"), dbConnection.GetPasswordResetUserIdRecordArray[0].TokenHash)
+	reset := dbConnection.ResetPasswordWithTokenRecordArray[0]
+	assert.Equal(t, userId, reset.UserId)
+	assert.Equal(t, hashResetToken("someToken"), reset.TokenHash)
+	assert.Equal(t, "newPassword", reset.Password)
+	assert.Len(t, reset.Hash, 32)
+	assert.Equal(t, 2, len(reset.Webhooks))
+	assert.Equal(t, adapter.WebhookSessionRevoked, reset.Webhooks[0].EventType)
+	assert.Equal(t, adapter.WebhookPasswordChanged, reset.Webhooks[1].EventType)
+}
+
+func TestConfirmPasswordReset_InvalidToken(t *testing.T) {
+	dbConnection := &db.DBMock{GetPasswordResetUserIdResponseArray: []*db.GetPasswordResetUserIdResponse{{Err: db.ErrResetNotFound}}}
+	userFacade := newPasswordResetFacade(dbConnection, &mail.SenderMock{})
+
+	err := userFacade.ConfirmPasswordReset(context.Background(), "someToken", "newPassword")
+
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+	assert.Equal(t, 0, len(dbConnection.ResetPasswordWithTokenRecordArray))
+}
+
+func TestConfirmPasswordReset_AlreadyUsed(t *testing.T) {
+	dbConnection := &db.DBMock{
+		GetPasswordResetUserIdResponseArray: []*db.GetPasswordResetUserIdResponse{{UserId: userId}},
+		ResetPasswordWithTokenResponseArray: []*db.ErrorResponse{{Err: db.ErrResetNotFound}},
+	}
+	userFacade := newPasswordResetFacade(dbConnection, &mail.SenderMock{})
+
+	err := userFacade.ConfirmPasswordReset(context.Background(), "someToken", "newPassword")
+
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestPasswordResetBody_WithoutUrl(t *testing.T) {
+	userFacade := &UserFacade{passwordReset: &passwordResetConfig{expireTime: 30 * time.Minute}}
+
+	body := userFacade.passwordResetBody("someToken")
+
+	assert.Equal(t, "Please reset your password within 30 minutes with the following token:\n\nsomeToken", body)
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+const (
+	// Number of recovery codes generated at once. Generating new codes invalidates all old ones
+	recoveryCodeCount = 10
+	// Characters of a recovery code without its separator
+	recoveryCodeLength = 10
+	// Lowercase letters and digits without the easily confused 0, 1, i, l and o
+	recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+)
+
+// Generates a new set of recovery codes. The codes are shown to the user once, only their hashes are stored
+func newRecoveryCodes() ([]string, []string) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		codes[i] = newRecoveryCode()
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	return codes, hashes
+}
+
+func newRecoveryCode() string {
+	alphabetLength := big.NewInt(int64(len(recoveryCodeAlphabet)))
+	var code strings.Builder
+	for i := 0; i < recoveryCodeLength; i++ {
+		if i == recoveryCodeLength/2 {
+			code.WriteByte('-')
+		}
+		index, err := rand.Int(rand.Reader, alphabetLength)
+		if err != nil {
+			panic(err)
+		}
+		code.WriteByte(recoveryCodeAlphabet[index.Int64()])
+	}
+	return code.String()
+}
+
+// Recovery codes are accepted without separator, with spaces and in uppercase
+func normalizeRecoveryCode(code string) string {
+	return strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+}
+
+// Totp codes only have digits and are shorter, so the length is enough to tell both apart
+func isRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == recoveryCodeLength
+}
+
+func hashRecoveryCode(code string) string {
+	hash := sha256.Sum256([]byte(normalizeRecoveryCode(code)))
+	return hex.EncodeToString(hash[:])
+}
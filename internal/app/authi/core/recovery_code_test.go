@@ -0,0 +1,42 @@
+package core
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRecoveryCodes(t *testing.T) {
+	codes, hashes := newRecoveryCodes()
+
+	assert.Equal(t, recoveryCodeCount, len(codes))
+	assert.Equal(t, recoveryCodeCount, len(hashes))
+	format := regexp.MustCompile("^[" + recoveryCodeAlphabet + "]{5}-[" + recoveryCodeAlphabet + "]{5}$")
+	unique := make(map[string]bool)
+	for i, code := range codes {
+		assert.Regexp(t, format, code)
+		assert.True(t, isRecoveryCode(code))
+		assert.Equal(t, hashRecoveryCode(code), hashes[i])
+		assert.Len(t, hashes[i], 64)
+		unique[code] = true
+	}
+	assert.Equal(t, recoveryCodeCount, len(unique))
+}
+
+func TestHashRecoveryCode_Normalized(t *testing.T) {
+	hash := hashRecoveryCode("abcde-fgh23")
+
+	assert.Equal(t, hash, hashRecoveryCode("ABCDE-FGH23"))
+	assert.Equal(t, hash, hashRecoveryCode("abcdefgh23"))
+	assert.Equal(t, hash, hashRecoveryCode("abcde fgh23"))
+	assert.NotEqual(t, hash, hashRecoveryCode("abcde-fgh24"))
+}
+
+func TestIsRecoveryCode(t *testing.T) {
+	assert.True(t, isRecoveryCode("abcde-fgh23"))
+	assert.True(t, isRecoveryCode("ABCDEFGH23"))
+	assert.False(t, isRecoveryCode("123456"))
+	assert.False(t, isRecoveryCode("123 456"))
+	assert.False(t, isRecoveryCode(""))
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// Secret of the test vectors of RFC 6238 for HMAC-SHA1
+	rfcTotpSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+)
+
+func TestTotpCode_RfcTestVectors(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for unix, code := range map[int64]string{59: "287082", 1111111109: "081804", 1111111111: "050471", 1234567890: "005924", 2000000000: "279037", 20000000000: "353130"} {
+		assert.Equal(t, code, totpCode(key, totpStep(time.Unix(unix, 0))), unix)
+	}
+}
+
+func TestMatchTotp_Skew(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := matchTotp(rfcTotpSecret, "081804", now)
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now), step)
+
+	step, ok = matchTotp(rfcTotpSecret, "081 804", now.Add(totpPeriod*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, totpStep(now), step)
+
+	_, ok = matchTotp(rfcTotpSecret, "081804", now.Add(2*totpPeriod*time.Second))
+	assert.False(t, ok)
+}
+
+func TestMatchTotp_Invalid(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+	for _, code := range []string{"", "81804", "0818040", "abcdef", "000000"} {
+		_, ok := matchTotp(rfcTotpSecret, code, now)
+		assert.False(t, ok, code)
+	}
+	for _, secret := range []string{"", "not base32!"} {
+		_, ok := matchTotp(secret, "081804", now)
+		assert.False(t, ok, secret)
+	}
+}
+
+func TestNewTotpSecret(t *testing.T) {
+	secret := newTotpSecret()
+
+	key, err := totpEncoding.DecodeString(secret)
+	assert.Nil(t, err)
+	assert.Equal(t, totpSecretLength, len(key))
+	assert.NotEqual(t, secret, newTotpSecret())
+}
+
+func TestTotpUri(t *testing.T) {
+	uri, err := url.Parse(totpUri("My Authi", "max@example.org", "SOMESECRET"))
+
+	assert.Nil(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/My Authi:max@example.org", uri.Path)
+	assert.Equal(t, url.Values{"secret": {"SOMESECRET"}, "issuer": {"My Authi"}, "algorithm": {"SHA1"}, "digits": {"6"}, "period": {"30"}}, uri.Query())
+}
@@ -1,71 +1,110 @@
 package core
 
 import (
+	"context"
+	"crypto/md5"
 	"crypto/rsa"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sync"
 	"time"
 
+	"github.com/BeanCodeDe/authi/internal/app/authi/breach"
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
 	"github.com/BeanCodeDe/authi/internal/app/authi/db"
-	"github.com/BeanCodeDe/authi/internal/app/authi/util"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/BeanCodeDe/authi/internal/app/authi/mail"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
 	"github.com/BeanCodeDe/authi/pkg/adapter"
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
-	"github.com/labstack/gommon/log"
+	log "github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v3"
 )
 
 type (
 	UserFacade struct {
-		dbConnection           db.Connection
-		signKey                *rsa.PrivateKey
-		accessTokenExpireTime  int
-		refreshTokenExpireTime int
+		dbConnection             db.Connection
+		signKey                  *rsa.PrivateKey
+		accessTokenExpireTime    int
+		refreshTokenExpireTime   int
+		loginHistorySize         int
+		updateLastLoginOnRefresh bool
+		lockout                  *lockoutConfig
+		auditEnabled             bool
+		webhooks                 []config.WebhookSubscription
+		mailSender               mail.Sender
+		emailVerification        *emailVerificationConfig
+		passwordReset            *passwordResetConfig
+		passwordPolicy           *passwordPolicy
+		mfa                      *mfaConfig
+		passkey                  *passkeyConfig
+		// Work that outlives its request, e.g. sending password reset mails
+		background sync.WaitGroup
+	}
+	lockoutConfig struct {
+		threshold   int
+		duration    time.Duration
+		maxDuration time.Duration
 	}
 	initUser struct {
-		Id       uuid.UUID `yaml:"id" json:"id"`
-		Password string    `yaml:"password" json:"password"`
+		Id           uuid.UUID `yaml:"id" json:"id"`
+		Password     string    `yaml:"password" json:"password"`
+		PasswordHash string    `yaml:"password_hash" json:"password_hash"`
+		Salt         string    `yaml:"salt" json:"salt"`
+		Username     string    `yaml:"username" json:"username"`
+		Email        string    `yaml:"email" json:"email"`
+		Admin        bool      `yaml:"admin" json:"admin"`
 	}
 	configYml struct {
 		Users []*initUser `yaml:"users"`
 	}
 )
 
-const (
-	EnvPrivateKeyPath         = "PRIVATE_KEY_PATH"
-	EnvAccessTokenExpireTime  = "ACCESS_TOKEN_EXPIRE_TIME"
-	EnvRefreshTokenExpireTime = "REFRESH_TOKEN_EXPIRE_TIME"
-	EnvInitUserFile           = "INIT_USER_FILE"
-)
-
-func NewUserFacade() (*UserFacade, error) {
-	signKey, err := loadSignKey()
+func NewUserFacade(config *config.Config, dbConnection db.Connection) (*UserFacade, error) {
+	signKey, err := loadSignKey(config.Token.PrivateKeyPath)
 	if err != nil {
 		return nil, err
 	}
 
-	dbConnection, err := db.NewConnection()
+	lockout := &lockoutConfig{
+		threshold:   config.Login.Lockout.Threshold,
+		duration:    time.Duration(config.Login.Lockout.Duration) * time.Minute,
+		maxDuration: time.Duration(config.Login.Lockout.MaxDuration) * time.Minute,
+	}
+	mailSender, err := mail.NewSender(&config.Mail)
 	if err != nil {
-		return nil, fmt.Errorf("error while initializing database: %v", err)
+		return nil, err
 	}
-
-	accessTokenExpireTime, err := util.GetEnvIntWithFallback(EnvAccessTokenExpireTime, 5)
+	emailVerification := &emailVerificationConfig{
+		expireTime: time.Duration(config.EmailVerification.ExpireTime) * time.Minute,
+		url:        config.EmailVerification.Url,
+	}
+	breachChecker, err := breach.NewChecker(&config.PasswordPolicy.Breached)
 	if err != nil {
-		return nil, fmt.Errorf("error loading access token expire time from environment: %w", err)
+		return nil, err
+	}
+	passwordReset := &passwordResetConfig{
+		expireTime: time.Duration(config.PasswordReset.ExpireTime) * time.Minute,
+		url:        config.PasswordReset.Url,
 	}
-	refreshTokenExpireTime, err := util.GetEnvIntWithFallback(EnvRefreshTokenExpireTime, 10)
+	mfa := &mfaConfig{
+		issuer:              config.Mfa.Issuer,
+		challengeExpireTime: time.Duration(config.Mfa.ChallengeExpireTime) * time.Minute,
+	}
+	passkey, err := newPasskeyConfig(&config.Passkey)
 	if err != nil {
-		return nil, fmt.Errorf("error loading refresh token expire time from environment: %w", err)
+		return nil, err
 	}
-	userFacade := &UserFacade{dbConnection, signKey, accessTokenExpireTime, refreshTokenExpireTime}
-	userFacade.initDefaultUser()
+	userFacade := &UserFacade{dbConnection, signKey, config.Token.AccessTokenExpireTime, config.Token.RefreshTokenExpireTime, config.Login.HistorySize, config.Login.UpdateLastLoginOnRefresh, lockout, config.Audit.Enabled, config.Webhook.Subscriptions, mailSender, emailVerification, passwordReset, newPasswordPolicy(&config.PasswordPolicy, breachChecker), mfa, passkey, sync.WaitGroup{}}
+	userFacade.initDefaultUser(config.InitUserFile)
 	return userFacade, nil
 }
 
-func loadSignKey() (*rsa.PrivateKey, error) {
-	path := util.GetEnvWithFallback(EnvPrivateKeyPath, "/token/jwtRS256.key")
-
+func loadSignKey(path string) (*rsa.PrivateKey, error) {
 	signBytes, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("error while reading private Key: %v", err)
@@ -79,9 +118,7 @@ func loadSignKey() (*rsa.PrivateKey, error) {
 	return signKey, nil
 }
 
-func (userFacade *UserFacade) initDefaultUser() error {
-	initUserFile := util.GetEnvWithFallback(EnvInitUserFile, "/authi.conf")
-
+func (userFacade *UserFacade) initDefaultUser(initUserFile string) error {
 	_, err := os.Stat(initUserFile)
 
 	if os.IsNotExist(err) {
@@ -94,78 +131,382 @@ func (userFacade *UserFacade) initDefaultUser() error {
 		return fmt.Errorf("error while loading init user File [%s]: %v", initUserFile, err)
 	}
 
-	var config *configYml
+	var initUserConfig *configYml
 
-	err = yaml.Unmarshal(data, &config)
+	err = yaml.Unmarshal(data, &initUserConfig)
 	if err != nil {
 		return fmt.Errorf("error while parsing init user File [%s]: %v", initUserFile, err)
 	}
 
-	userFacade.dbConnection.DeleteInitUsers()
-	for _, user := range config.Users {
-		if err := userFacade.CreateUser(user.Id, user.Password, true); err != nil {
+	ctx := ContextWithActor(context.Background(), ActorSystem)
+	userFacade.dbConnection.DeleteInitUsers(ctx)
+	for _, user := range initUserConfig.Users {
+		if err := userFacade.createInitUser(ctx, user); err != nil {
 			return fmt.Errorf("error while creating init user [%v]: %v", user.Id, err)
 		}
 	}
 	return nil
 }
 
-func (userFacade *UserFacade) CreateUser(userId uuid.UUID, password string, initUser bool) error {
+// Init users can be configured with a plaintext password or with MD5(password + salt) as hex and the used salt
+func (userFacade *UserFacade) createInitUser(ctx context.Context, user *initUser) error {
+	identifiers, err := normalizeIdentifiers(&UserIdentifiers{Username: user.Username, Email: user.Email})
+	if err != nil {
+		return err
+	}
+
+	if user.PasswordHash == "" {
+		password := userFacade.passwordPolicy.normalizePassword(user.Password)
+		err := userFacade.createUser(ctx, &db.UserDB{ID: user.Id, Password: password, InitUser: true, Admin: user.Admin, Username: identifiers.Username, Email: identifiers.Email})
+		userFacade.audit(ctx, AuditUserCreated, user.Id, nil, err)
+		return err
+	}
+
+	if user.Password != "" {
+		return errors.New("password and password hash can't be set both")
+	}
+	if hash, err := hex.DecodeString(user.PasswordHash); err != nil || len(hash) != md5.Size {
+		return errors.New("password hash has to be a md5 hash in hex format")
+	}
+	if user.Salt == "" {
+		return errors.New("salt has to be set for password hash")
+	}
 
 	creationTime := time.Now()
+	dbUser := &db.UserDB{ID: user.Id, Password: user.PasswordHash, CreatedOn: creationTime, LastLogin: creationTime, InitUser: true, Admin: user.Admin, Username: identifiers.Username, Email: identifiers.Email}
+	err = userFacade.dbConnection.CreateUserWithPasswordHash(ctx, dbUser, user.Salt)
+	userFacade.audit(ctx, AuditUserCreated, user.Id, nil, err)
+	if err != nil {
+		return fmt.Errorf("error while creating user with password hash: %v", err)
+	}
+	return nil
+}
 
-	dbUser := &db.UserDB{ID: userId, Password: password, CreatedOn: creationTime, LastLogin: creationTime, InitUser: initUser}
+// Creates the user with optional username and email, that can be used to log in instead of the user id
+func (userFacade *UserFacade) CreateUser(ctx context.Context, userId uuid.UUID, password string, identifiers *UserIdentifiers, initUser bool) error {
+	password = userFacade.passwordPolicy.normalizePassword(password)
+	normalized, err := normalizeIdentifiers(identifiers)
+	if err == nil && !initUser {
+		err = userFacade.passwordPolicy.check(password, userId, normalized.Username)
+	}
+	if err == nil {
+		err = userFacade.createUser(ctx, &db.UserDB{ID: userId, Password: password, InitUser: initUser, Username: normalized.Username, Email: normalized.Email})
+	}
+	userFacade.audit(ctx, AuditUserCreated, userId, nil, err)
+	return err
+}
+
+func (userFacade *UserFacade) createUser(ctx context.Context, dbUser *db.UserDB) error {
+	userId := dbUser.ID
+	creationTime := time.Now()
+	dbUser.CreatedOn = creationTime
+	dbUser.LastLogin = creationTime
 
-	if err := userFacade.dbConnection.CreateUser(dbUser, randomString()); err != nil {
+	var webhooks []*db.WebhookOutboxDB
+	if !dbUser.InitUser {
+		webhooks = userFacade.webhookOutbox(ctx, adapter.WebhookUserCreated, userId)
+	}
+	if err := userFacade.dbConnection.CreateUser(ctx, dbUser, randomString(), webhooks...); err != nil {
 		if errors.Is(err, db.ErrUserAlreadyExists) {
-			if err := userFacade.dbConnection.LoginUser(dbUser); err != nil {
+			if err := userFacade.dbConnection.LoginUser(ctx, dbUser); err != nil {
 				return fmt.Errorf("something went wrong while checking credentials of already created user, %v: %w", userId, err)
 			}
 			return nil
 		}
+		if errors.Is(err, db.ErrIdentifierTaken) {
+			return fmt.Errorf("error while creating user %v: %w", userId, ErrIdentifierTaken)
+		}
 		return fmt.Errorf("error while creating user: %v", err)
 	}
 
 	return nil
 }
 
-func (userFacade *UserFacade) LoginUser(userId uuid.UUID, password string) (*adapter.TokenResponseDTO, error) {
-	dbUser := &db.UserDB{ID: userId, Password: password}
-	if err := userFacade.dbConnection.LoginUser(dbUser); err != nil {
+// Logs in the user with the password. Users with mfa get a MfaRequiredError instead of tokens and complete the login with LoginWithMfa
+func (userFacade *UserFacade) LoginUser(ctx context.Context, userId uuid.UUID, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	token, err := userFacade.loginUser(ctx, userId, password, clientInfo)
+	if errors.Is(err, ErrMfaRequired) {
+		userFacade.audit(ctx, AuditMfaChallenged, userId, clientInfo, nil)
+		return nil, err
+	}
+	metrics.CountLogin(err == nil)
+	userFacade.audit(ctx, AuditUserLogin, userId, clientInfo, err)
+	return token, err
+}
+
+func (userFacade *UserFacade) loginUser(ctx context.Context, userId uuid.UUID, password string, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	dbUser, err := userFacade.checkPassword(ctx, userId, password)
+	if err != nil {
+		userFacade.addLoginHistory(ctx, userId, time.Now(), clientInfo, false)
+		return nil, err
+	}
+	if dbUser.TotpEnabled {
+		return nil, userFacade.mfaRequiredError(userId)
+	}
+	if userFacade.passwordPolicy.expired(dbUser.PasswordChangedAt) {
+		userFacade.addLoginHistory(ctx, userId, time.Now(), clientInfo, false)
+		return nil, userFacade.passwordExpiredError(userId, dbUser.Amr)
+	}
+	return userFacade.completeLogin(ctx, dbUser, clientInfo)
+}
+
+// Issues the tokens of a login whose factors are checked and records the successful login
+func (userFacade *UserFacade) completeLogin(ctx context.Context, dbUser *db.UserDB, clientInfo *ClientInfo) (*adapter.TokenResponseDTO, error) {
+	userId := dbUser.ID
+	token, err := userFacade.createJWTToken(ctx, dbUser)
+	if err != nil {
+		return nil, err
+	}
+
+	loginTime := time.Now()
+	if err := userFacade.dbConnection.UpdateLastLogin(ctx, userId, loginTime); err != nil {
+		return nil, fmt.Errorf("error while updating last login of user %v: %v", userId, err)
+	}
+	if userFacade.lockoutEnabled() {
+		if err := userFacade.dbConnection.ResetFailedLoginAttempts(ctx, userId); err != nil {
+			return nil, fmt.Errorf("error while resetting failed login attempts of user %v: %v", userId, err)
+		}
+	}
+	userFacade.addLoginHistory(ctx, userId, loginTime, clientInfo, true)
+	return token, nil
+}
+
+// Checks the password of the user like every login does. Locked users are rejected and wrong passwords count as failed login
+func (userFacade *UserFacade) checkPassword(ctx context.Context, userId uuid.UUID, password string) (*db.UserDB, error) {
+	if err := userFacade.checkLockout(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	dbUser := &db.UserDB{ID: userId, Password: userFacade.passwordPolicy.normalizePassword(password)}
+	if err := userFacade.dbConnection.LoginUser(ctx, dbUser); err != nil {
+		userFacade.registerFailedLogin(ctx, userId)
 		return nil, fmt.Errorf("something went wrong when logging in user, %v: %v", userId, err)
 	}
-	return userFacade.createJWTToken(userId)
+	if err := statusError(dbUser.Status); err != nil {
+		return nil, fmt.Errorf("user %v is not allowed to log in: %w", userId, err)
+	}
+	dbUser.Amr = []string{adapter.AmrPassword}
+	return dbUser, nil
 }
 
-func (userFacade *UserFacade) RefreshToken(userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error) {
-	if err := userFacade.dbConnection.CheckRefreshToken(userId, refreshToken); err != nil {
+func (userFacade *UserFacade) RefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error) {
+	token, err := userFacade.refreshToken(ctx, userId, refreshToken)
+	metrics.CountRefresh(err == nil)
+	userFacade.audit(ctx, AuditTokenRefreshed, userId, nil, err)
+	return token, err
+}
+
+func (userFacade *UserFacade) refreshToken(ctx context.Context, userId uuid.UUID, refreshToken string) (*adapter.TokenResponseDTO, error) {
+	dbUser := &db.UserDB{ID: userId}
+	if err := userFacade.dbConnection.CheckRefreshToken(ctx, dbUser, refreshToken); err != nil {
 		return nil, fmt.Errorf("no user with refresh token was found: %v", err)
 	}
+	if err := statusError(dbUser.Status); err != nil {
+		return nil, fmt.Errorf("user %v is not allowed to refresh token: %w", userId, err)
+	}
+	if userFacade.passwordPolicy.expired(dbUser.PasswordChangedAt) {
+		return nil, fmt.Errorf("user %v is not allowed to refresh token: %w", userId, &PasswordExpiredError{})
+	}
 
-	return userFacade.createJWTToken(userId)
+	token, err := userFacade.createJWTToken(ctx, dbUser)
+	if err != nil {
+		return nil, err
+	}
+
+	if userFacade.updateLastLoginOnRefresh {
+		if err := userFacade.dbConnection.UpdateLastLogin(ctx, userId, time.Now()); err != nil {
+			return nil, fmt.Errorf("error while updating last login of user %v: %v", userId, err)
+		}
+	}
+	return token, nil
 }
 
-func (userFacade *UserFacade) UpdatePassword(userId uuid.UUID, password string) error {
-	if err := userFacade.dbConnection.UpdatePassword(userId, password, randomString()); err != nil {
-		return fmt.Errorf("error while updating password of user: %v", err)
+func (userFacade *UserFacade) GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*adapter.LoginHistoryDTO, error) {
+	entries, err := userFacade.dbConnection.GetLoginHistory(ctx, userId)
+	if err != nil {
+		return nil, fmt.Errorf("error while loading login history of user: %v", err)
+	}
+
+	loginHistory := make([]*adapter.LoginHistoryDTO, len(entries))
+	for i, entry := range entries {
+		loginHistory[i] = &adapter.LoginHistoryDTO{LoginTime: entry.LoginTime, IP: entry.IP, UserAgent: entry.UserAgent, Success: entry.Success}
+	}
+	return loginHistory, nil
+}
+
+func (userFacade *UserFacade) UnlockUser(ctx context.Context, userId uuid.UUID) error {
+	err := userFacade.dbConnection.ResetFailedLoginAttempts(ctx, userId)
+	userFacade.audit(ctx, AuditUserUnlocked, userId, nil, err)
+	if err != nil {
+		return fmt.Errorf("error while unlocking user: %v", err)
+	}
+	return nil
+}
+
+// Checks that a valid key to sign tokens is loaded
+func (userFacade *UserFacade) CheckSignKey() error {
+	if userFacade.signKey == nil {
+		return errors.New("no sign key loaded")
+	}
+	if err := userFacade.signKey.Validate(); err != nil {
+		return fmt.Errorf("sign key is invalid: %v", err)
+	}
+	return nil
+}
+
+func (userFacade *UserFacade) lockoutEnabled() bool {
+	return userFacade.lockout != nil && userFacade.lockout.threshold > 0
+}
+
+func (userFacade *UserFacade) checkLockout(ctx context.Context, userId uuid.UUID) error {
+	if !userFacade.lockoutEnabled() {
+		return nil
+	}
+
+	lockedUntil, err := userFacade.dbConnection.GetLockedUntil(ctx, userId)
+	if err != nil {
+		return fmt.Errorf("error while checking lock of user %v: %v", userId, err)
+	}
+
+	if lockedUntil != nil && lockedUntil.After(time.Now()) {
+		return fmt.Errorf("%w until %v", ErrUserLocked, lockedUntil)
 	}
 	return nil
 }
 
-func (userFacade *UserFacade) DeleteUser(userId uuid.UUID) error {
-	if err := userFacade.dbConnection.DeleteUser(userId); err != nil {
+func (userFacade *UserFacade) registerFailedLogin(ctx context.Context, userId uuid.UUID) {
+	if !userFacade.lockoutEnabled() {
+		return
+	}
+
+	failedLoginAttempts, err := userFacade.dbConnection.IncrementFailedLoginAttempts(ctx, userId)
+	if err != nil {
+		logging.FromContext(ctx).Warnf("Failed login of user %v couldn't be registered: %v", userId, err)
+		return
+	}
+
+	if failedLoginAttempts < userFacade.lockout.threshold {
+		return
+	}
+
+	lockedUntil := time.Now().Add(userFacade.lockout.lockDuration(failedLoginAttempts))
+	if err := userFacade.dbConnection.LockUser(ctx, userId, lockedUntil); err != nil {
+		logging.FromContext(ctx).Warnf("User %v couldn't be locked: %v", userId, err)
+		return
+	}
+	userFacade.auditWithDetails(ctx, AuditUserLocked, userId, nil, true, fmt.Sprintf("locked until %s after %d failed logins", lockedUntil.Format(time.RFC3339), failedLoginAttempts))
+}
+
+// Doubles the lock duration for every failed attempt above the threshold, capped at the max duration
+func (lockout *lockoutConfig) lockDuration(failedLoginAttempts int) time.Duration {
+	duration := lockout.duration
+	for i := lockout.threshold; i < failedLoginAttempts && duration < lockout.maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > lockout.maxDuration {
+		return lockout.maxDuration
+	}
+	return duration
+}
+
+func (userFacade *UserFacade) addLoginHistory(ctx context.Context, userId uuid.UUID, loginTime time.Time, clientInfo *ClientInfo, success bool) {
+	if userFacade.loginHistorySize <= 0 {
+		return
+	}
+
+	entry := &db.LoginHistoryDB{UserId: userId, LoginTime: loginTime, Success: success}
+	if clientInfo != nil {
+		entry.IP = clientInfo.IP
+		entry.UserAgent = clientInfo.UserAgent
+	}
+
+	if err := userFacade.dbConnection.AddLoginHistory(ctx, entry, userFacade.loginHistorySize); err != nil {
+		logging.FromContext(ctx).Warnf("Login history of user %v couldn't be saved: %v", userId, err)
+	}
+}
+
+// Sets the new password if the current password is correct. All sessions are revoked and the caller gets new tokens that keep
+// the authentication methods of its session, so only the caller stays logged in
+func (userFacade *UserFacade) UpdatePassword(ctx context.Context, userId uuid.UUID, currentPassword string, password string) (*adapter.TokenResponseDTO, error) {
+	token, err := userFacade.updatePassword(ctx, userId, currentPassword, password, amrFromContext(ctx))
+	userFacade.audit(ctx, AuditPasswordChanged, userId, nil, err)
+	return token, err
+}
+
+func (userFacade *UserFacade) updatePassword(ctx context.Context, userId uuid.UUID, currentPassword string, password string, sessionAmr []string) (*adapter.TokenResponseDTO, error) {
+	dbUser, err := userFacade.checkPassword(ctx, userId, currentPassword)
+	if err != nil {
+		return nil, err
+	}
+	password, err = userFacade.checkNewPassword(ctx, userId, password)
+	if err != nil {
+		return nil, err
+	}
+
+	webhooks := append(userFacade.webhookOutbox(ctx, adapter.WebhookSessionRevoked, userId), userFacade.webhookOutbox(ctx, adapter.WebhookPasswordChanged, userId)...)
+	if err := userFacade.dbConnection.UpdatePassword(ctx, userId, password, randomString(), userFacade.passwordPolicy.recentPasswords(), webhooks...); err != nil {
+		return nil, fmt.Errorf("error while updating password of user: %v", err)
+	}
+	// The current password was just checked, the other methods of the session stay valid
+	for _, method := range sessionAmr {
+		if !slices.Contains(dbUser.Amr, method) {
+			dbUser.Amr = append(dbUser.Amr, method)
+		}
+	}
+	return userFacade.createJWTToken(ctx, dbUser)
+}
+
+func (userFacade *UserFacade) DeleteUser(ctx context.Context, userId uuid.UUID) error {
+	err := userFacade.dbConnection.DeleteUser(ctx, userId, userFacade.webhookOutbox(ctx, adapter.WebhookUserDeleted, userId)...)
+	userFacade.audit(ctx, AuditUserDeleted, userId, nil, err)
+	if err != nil {
 		return fmt.Errorf("error while deleting user: %v", err)
 	}
 	return nil
 }
 
-func (userFacade *UserFacade) createJWTToken(userId uuid.UUID) (*adapter.TokenResponseDTO, error) {
+// Claims of tokens that only allow a single action, e.g. to verify an email
+type audienceClaims interface {
+	jwt.Claims
+	VerifyAudience(audience string, required bool) bool
+}
+
+// Parses a token that was signed by authi for the audience into claims. Tokens with audience are rejected as access tokens,
+// so they can't be used for anything else than the action of their audience
+func (userFacade *UserFacade) parseAudienceToken(token string, audience string, claims audienceClaims) error {
+	parsedToken, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return &userFacade.signKey.PublicKey, nil
+	})
+	if err != nil {
+		return err
+	}
+	if !parsedToken.Valid {
+		return errors.New("token is invalid")
+	}
+	if !claims.VerifyAudience(audience, true) {
+		return errors.New("token has wrong audience")
+	}
+	return nil
+}
+
+func (userFacade *UserFacade) createJWTToken(ctx context.Context, user *db.UserDB) (*adapter.TokenResponseDTO, error) {
+	userId := user.ID
 
 	tokenExpireAt := time.Now().Add(time.Duration(userFacade.accessTokenExpireTime) * time.Minute).Unix()
 	refreshTokenExpireAt := time.Now().Add(time.Duration(userFacade.refreshTokenExpireTime) * time.Minute)
 
+	var roles []string
+	if user.Admin {
+		roles = append(roles, adapter.RoleAdmin)
+	}
 	claimsToken := &adapter.Claims{
-		UserId: userId,
+		UserId:        userId,
+		Roles:         roles,
+		EmailVerified: user.EmailVerified,
+		Amr:           user.Amr,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: tokenExpireAt,
 		},
@@ -178,8 +519,9 @@ func (userFacade *UserFacade) createJWTToken(userId uuid.UUID) (*adapter.TokenRe
 	}
 
 	refreshToken := randomString()
-	if err = userFacade.dbConnection.UpdateRefreshToken(userId, refreshToken, refreshTokenExpireAt); err != nil {
+	if err = userFacade.dbConnection.UpdateRefreshToken(ctx, userId, refreshToken, refreshTokenExpireAt, user.Amr); err != nil {
 		return nil, fmt.Errorf("refresh token could not be saved into database: %v", err)
 	}
+	metrics.CountTokenIssued()
 	return &adapter.TokenResponseDTO{AccessToken: signedToken, ExpiresIn: int(tokenExpireAt), RefreshToken: refreshToken, RefreshExpiresIn: int(refreshTokenExpireAt.Unix())}, nil
 }
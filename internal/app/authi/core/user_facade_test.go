@@ -1,11 +1,15 @@
 package core
 
 import (
-	"os"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
 	"time"
 
 	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -14,8 +18,7 @@ func TestInitUser_YamlSuccessfully(t *testing.T) {
 	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}, DeleteInitUsersResponseArray: []*db.ErrorResponse{{Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	os.Setenv(EnvInitUserFile, "user_test.yml")
-	err := userFacade.initDefaultUser()
+	err := userFacade.initDefaultUser("user_test.yml")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -35,6 +38,8 @@ func TestInitUser_YamlSuccessfully(t *testing.T) {
 	assert.NotNil(t, dbConnection.CreateUserRecordArray[1].User)
 	assert.Equal(t, "5cc3621d-e5ac-4d81-93df-462b27e0cc2b", dbConnection.CreateUserRecordArray[1].User.ID.String())
 	assert.Equal(t, "someOtherPassword", dbConnection.CreateUserRecordArray[1].User.Password)
+	assert.Equal(t, "admin", dbConnection.CreateUserRecordArray[1].User.Username)
+	assert.Equal(t, "admin@example.org", dbConnection.CreateUserRecordArray[1].User.Email)
 	assert.Len(t, dbConnection.CreateUserRecordArray[1].Hash, 32)
 }
 
@@ -42,8 +47,7 @@ func TestInitUser_JsonSuccessfully(t *testing.T) {
 	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}, DeleteInitUsersResponseArray: []*db.ErrorResponse{{Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	os.Setenv(EnvInitUserFile, "user_test.json")
-	err := userFacade.initDefaultUser()
+	err := userFacade.initDefaultUser("user_test.json")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -70,8 +74,7 @@ func TestInitUser_FileNotFound(t *testing.T) {
 	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	os.Setenv(EnvInitUserFile, "some_random_file.yml")
-	err := userFacade.initDefaultUser()
+	err := userFacade.initDefaultUser("some_random_file.yml")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -88,8 +91,7 @@ func TestInitUser_NotPasable(t *testing.T) {
 	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	os.Setenv(EnvInitUserFile, "user_test_wrong_fornat.yml")
-	err := userFacade.initDefaultUser()
+	err := userFacade.initDefaultUser("user_test_wrong_fornat.yml")
 
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -102,13 +104,64 @@ func TestInitUser_NotPasable(t *testing.T) {
 	assert.Equal(t, 0, len(dbConnection.DeleteInitUsersRecordArray))
 }
 
+func TestInitUser_PasswordHashSuccessfully(t *testing.T) {
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}}, CreateUserWithPasswordHashResponseArray: []*db.ErrorResponse{{Err: nil}}, DeleteInitUsersResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.initDefaultUser("user_test_hashed.yml")
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.CreateUserRecordArray))
+	assert.Equal(t, 1, len(dbConnection.CreateUserWithPasswordHashRecordArray))
+	assert.Equal(t, 1, len(dbConnection.DeleteInitUsersRecordArray))
+
+	assert.Equal(t, "c5ffc340-507e-4c66-a6ce-a7d98842f9ba", dbConnection.CreateUserWithPasswordHashRecordArray[0].User.ID.String())
+	assert.Equal(t, "5089c6c45c47b46fb2744178ce05bccf", dbConnection.CreateUserWithPasswordHashRecordArray[0].User.Password)
+	assert.Equal(t, "someSalt", dbConnection.CreateUserWithPasswordHashRecordArray[0].Hash)
+	assert.True(t, dbConnection.CreateUserWithPasswordHashRecordArray[0].User.InitUser)
+
+	assert.Equal(t, "5cc3621d-e5ac-4d81-93df-462b27e0cc2b", dbConnection.CreateUserRecordArray[0].User.ID.String())
+	assert.Equal(t, "someOtherPassword", dbConnection.CreateUserRecordArray[0].User.Password)
+}
+
+func TestInitUser_PasswordHashWrongFormat(t *testing.T) {
+	dbConnection := &db.DBMock{DeleteInitUsersResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.initDefaultUser("user_test_hashed_wrong_format.yml")
+
+	assert.ErrorContains(t, err, "md5 hash")
+	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
+	assert.Equal(t, 0, len(dbConnection.CreateUserWithPasswordHashRecordArray))
+}
+
+func TestCreateInitUser_PasswordAndPasswordHash(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.createInitUser(context.Background(), &initUser{Id: userId, Password: password, PasswordHash: "5089c6c45c47b46fb2744178ce05bccf", Salt: "someSalt"})
+
+	assert.ErrorContains(t, err, "can't be set both")
+	assert.Equal(t, 0, len(dbConnection.CreateUserWithPasswordHashRecordArray))
+}
+
+func TestCreateInitUser_SaltMissing(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.createInitUser(context.Background(), &initUser{Id: userId, PasswordHash: "5089c6c45c47b46fb2744178ce05bccf"})
+
+	assert.ErrorContains(t, err, "salt")
+	assert.Equal(t, 0, len(dbConnection.CreateUserWithPasswordHashRecordArray))
+}
+
 // CreateUser Test
 
 func TestCreateUser_Successfully(t *testing.T) {
 	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.CreateUser(userId, password, false)
+	err := userFacade.CreateUser(context.Background(), userId, password, nil, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 1, len(dbConnection.CreateUserRecordArray))
@@ -130,7 +183,7 @@ func TestCreateUser_CreateUser_UnknownError(t *testing.T) {
 	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.CreateUser(userId, password, false)
+	err := userFacade.CreateUser(context.Background(), userId, password, nil, false)
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 1, len(dbConnection.CreateUserRecordArray))
@@ -146,10 +199,10 @@ func TestCreateUser_CreateUser_UnknownError(t *testing.T) {
 }
 
 func TestCreateUser_CreateUser_AlreadyExistsWrongPassword(t *testing.T) {
-	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: db.ErrUserAlreadyExists}}, LoginUserResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: db.ErrUserAlreadyExists}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.CreateUser(userId, password, false)
+	err := userFacade.CreateUser(context.Background(), userId, password, nil, false)
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 1, len(dbConnection.CreateUserRecordArray))
@@ -169,10 +222,10 @@ func TestCreateUser_CreateUser_AlreadyExistsWrongPassword(t *testing.T) {
 }
 
 func TestCreateUser_CreateUser_AlreadyExistsRetry(t *testing.T) {
-	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: db.ErrUserAlreadyExists}}, LoginUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: db.ErrUserAlreadyExists}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.CreateUser(userId, password, false)
+	err := userFacade.CreateUser(context.Background(), userId, password, nil, false)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 1, len(dbConnection.CreateUserRecordArray))
@@ -194,14 +247,13 @@ func TestCreateUser_CreateUser_AlreadyExistsRetry(t *testing.T) {
 // RefreshToken Test
 
 func TestRefreshToken_Successfully(t *testing.T) {
-	t.Setenv(EnvPrivateKeyPath, privateKeyPath)
-	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, CheckRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{Err: nil}}}
 
-	signKey, err := loadSignKey()
+	signKey, err := loadSignKey(privateKeyPath)
 	assert.Nil(t, err)
 	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
 
-	tokenResponseDTO, err := userFacade.RefreshToken(userId, refreshToken)
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
@@ -224,12 +276,27 @@ func TestRefreshToken_Successfully(t *testing.T) {
 	assert.Equal(t, int(dbConnection.UpdateRefreshTokenRecordArray[0].RefreshTokenExpireAt.Unix()), tokenResponseDTO.RefreshExpiresIn)
 }
 
+func TestRefreshToken_UpdateLastLogin_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}}
+
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, updateLastLoginOnRefresh: true}
+
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
+	assert.Nil(t, err)
+	assert.NotNil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.UpdateRefreshTokenRecordArray))
+	assert.Equal(t, 1, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.Equal(t, userId, dbConnection.UpdateLastLoginRecordArray[0].UserId)
+}
+
 func TestRefreshToken_CheckRefreshToken_UnknownError(t *testing.T) {
-	dbConnection := &db.DBMock{CheckRefreshTokenResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	dbConnection := &db.DBMock{CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}}
 
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	tokenResponseDTO, err := userFacade.RefreshToken(userId, refreshToken)
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
 	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
@@ -246,14 +313,13 @@ func TestRefreshToken_CheckRefreshToken_UnknownError(t *testing.T) {
 }
 
 func TestRefreshToken_UpdateRefreshToken_UnknownError(t *testing.T) {
-	t.Setenv(EnvPrivateKeyPath, privateKeyPath)
-	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: errUnknown}}, CheckRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: errUnknown}}, CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{Err: nil}}}
 
-	signKey, err := loadSignKey()
+	signKey, err := loadSignKey(privateKeyPath)
 	assert.Nil(t, err)
 	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
 
-	tokenResponseDTO, err := userFacade.RefreshToken(userId, refreshToken)
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
 	assert.Equal(t, 1, len(dbConnection.UpdateRefreshTokenRecordArray))
@@ -276,15 +342,17 @@ func TestRefreshToken_UpdateRefreshToken_UnknownError(t *testing.T) {
 // LoginUser Test
 
 func TestLoginUser_Successfully(t *testing.T) {
-	t.Setenv(EnvPrivateKeyPath, privateKeyPath)
-	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}}
 
-	signKey, err := loadSignKey()
+	signKey, err := loadSignKey(privateKeyPath)
 	assert.Nil(t, err)
 	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
 
-	tokenResponseDTO, err := userFacade.LoginUser(userId, password)
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
 	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.AddLoginHistoryRecordArray))
+	assert.Equal(t, userId, dbConnection.UpdateLastLoginRecordArray[0].UserId)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
 	assert.Equal(t, 1, len(dbConnection.UpdateRefreshTokenRecordArray))
@@ -308,15 +376,14 @@ func TestLoginUser_Successfully(t *testing.T) {
 }
 
 func TestLoginUser_LoginUser_UnknownError(t *testing.T) {
-	t.Setenv(EnvPrivateKeyPath, privateKeyPath)
 
-	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}}
 
-	signKey, err := loadSignKey()
+	signKey, err := loadSignKey(privateKeyPath)
 	assert.Nil(t, err)
 	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey}
 
-	tokenResponseDTO, err := userFacade.LoginUser(userId, password)
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
 	assert.NotNil(t, err)
 	assert.Nil(t, tokenResponseDTO)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -333,14 +400,13 @@ func TestLoginUser_LoginUser_UnknownError(t *testing.T) {
 }
 
 func TestLoginUser_UpdateRefreshToken_UnknownError(t *testing.T) {
-	t.Setenv(EnvPrivateKeyPath, privateKeyPath)
-	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: errUnknown}}, LoginUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: errUnknown}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}}
 
-	signKey, err := loadSignKey()
+	signKey, err := loadSignKey(privateKeyPath)
 	assert.Nil(t, err)
 	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
 
-	tokenResponseDTO, err := userFacade.LoginUser(userId, password)
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
 	assert.Equal(t, 1, len(dbConnection.UpdateRefreshTokenRecordArray))
@@ -361,44 +427,249 @@ func TestLoginUser_UpdateRefreshToken_UnknownError(t *testing.T) {
 
 }
 
+func TestLoginUser_WithLoginHistory_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, loginHistorySize: 3}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.Nil(t, err)
+	assert.NotNil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.Equal(t, 1, len(dbConnection.AddLoginHistoryRecordArray))
+
+	assert.Equal(t, userId, dbConnection.AddLoginHistoryRecordArray[0].Entry.UserId)
+	assert.Equal(t, clientInfo.IP, dbConnection.AddLoginHistoryRecordArray[0].Entry.IP)
+	assert.Equal(t, clientInfo.UserAgent, dbConnection.AddLoginHistoryRecordArray[0].Entry.UserAgent)
+	assert.Equal(t, true, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+	assert.Equal(t, dbConnection.UpdateLastLoginRecordArray[0].LastLogin, dbConnection.AddLoginHistoryRecordArray[0].Entry.LoginTime)
+	assert.Equal(t, 3, dbConnection.AddLoginHistoryRecordArray[0].HistorySize)
+}
+
+func TestLoginUser_WithLoginHistory_WrongPassword(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, loginHistorySize: 3}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.NotNil(t, err)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.Equal(t, 1, len(dbConnection.AddLoginHistoryRecordArray))
+
+	assert.Equal(t, userId, dbConnection.AddLoginHistoryRecordArray[0].Entry.UserId)
+	assert.Equal(t, false, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+}
+
+func TestLoginUser_UpdateLastLogin_UnknownError(t *testing.T) {
+	dbConnection := &db.DBMock{UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, loginHistorySize: 3}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.NotNil(t, err)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.UpdateLastLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.AddLoginHistoryRecordArray))
+}
+
+func TestLoginUser_Locked(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{LockedUntil: &lockedUntil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: lockout}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.ErrorIs(t, err, ErrUserLocked)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.GetLockedUntilRecordArray))
+	assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+	assert.Equal(t, 0, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.Equal(t, userId, dbConnection.GetLockedUntilRecordArray[0].UserId)
+}
+
+func TestLoginUser_LockExpired_Successfully(t *testing.T) {
+	lockedUntil := time.Now().Add(-time.Minute)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{LockedUntil: &lockedUntil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdateLastLoginResponseArray: []*db.ErrorResponse{{Err: nil}}, ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}}
+
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10, lockout: lockout}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.Nil(t, err)
+	assert.NotNil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.LoginUserRecordArray))
+	assert.Equal(t, 1, len(dbConnection.ResetFailedLoginRecordArray))
+	assert.Equal(t, userId, dbConnection.ResetFailedLoginRecordArray[0].UserId)
+}
+
+func TestLoginUser_WrongPassword_BelowThreshold(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 2}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: lockout}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.NotNil(t, err)
+	assert.NotErrorIs(t, err, ErrUserLocked)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.LockUserRecordArray))
+	assert.Equal(t, userId, dbConnection.IncrementFailedLoginRecordArray[0].UserId)
+}
+
+func TestLoginUser_WrongPassword_ReachesThreshold(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 3}}, LockUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: lockout}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.NotNil(t, err)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.Equal(t, 1, len(dbConnection.LockUserRecordArray))
+	assert.Equal(t, userId, dbConnection.LockUserRecordArray[0].UserId)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), dbConnection.LockUserRecordArray[0].LockedUntil, time.Second)
+}
+
+func TestLockDuration(t *testing.T) {
+	assert.Equal(t, time.Minute, lockout.lockDuration(3))
+	assert.Equal(t, 2*time.Minute, lockout.lockDuration(4))
+	assert.Equal(t, 8*time.Minute, lockout.lockDuration(6))
+	assert.Equal(t, 10*time.Minute, lockout.lockDuration(7))
+	assert.Equal(t, 10*time.Minute, lockout.lockDuration(100))
+}
+
+// UnlockUser Test
+
+func TestUnlockUser_Successfully(t *testing.T) {
+	dbConnection := &db.DBMock{ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.UnlockUser(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.ResetFailedLoginRecordArray))
+	assert.Equal(t, userId, dbConnection.ResetFailedLoginRecordArray[0].UserId)
+}
+
+func TestUnlockUser_UnknownError(t *testing.T) {
+	dbConnection := &db.DBMock{ResetFailedLoginResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	err := userFacade.UnlockUser(context.Background(), userId)
+
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, len(dbConnection.ResetFailedLoginRecordArray))
+}
+
+// GetLoginHistory Test
+
+func TestGetLoginHistory_Successfully(t *testing.T) {
+	loginTime := time.Now()
+	entries := []*db.LoginHistoryDB{{UserId: userId, LoginTime: loginTime, IP: clientInfo.IP, UserAgent: clientInfo.UserAgent, Success: true}}
+	dbConnection := &db.DBMock{GetLoginHistoryResponseArray: []*db.GetLoginHistoryResponse{{Entries: entries}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	loginHistory, err := userFacade.GetLoginHistory(context.Background(), userId)
+
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(dbConnection.GetLoginHistoryRecordArray))
+	assert.Equal(t, userId, dbConnection.GetLoginHistoryRecordArray[0].UserId)
+	assert.Equal(t, []*adapter.LoginHistoryDTO{{LoginTime: loginTime, IP: clientInfo.IP, UserAgent: clientInfo.UserAgent, Success: true}}, loginHistory)
+}
+
+func TestGetLoginHistory_UnknownError(t *testing.T) {
+	dbConnection := &db.DBMock{GetLoginHistoryResponseArray: []*db.GetLoginHistoryResponse{{Err: errUnknown}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	loginHistory, err := userFacade.GetLoginHistory(context.Background(), userId)
+
+	assert.NotNil(t, err)
+	assert.Nil(t, loginHistory)
+	assert.Equal(t, 1, len(dbConnection.GetLoginHistoryRecordArray))
+}
+
 // UpdatePassword Test
 func TestUpdatePassword_Successfully(t *testing.T) {
-	dbConnection := &db.DBMock{UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}}
-	userFacade := &UserFacade{dbConnection: dbConnection}
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, accessTokenExpireTime: 5, refreshTokenExpireTime: 10}
 
-	err := userFacade.UpdatePassword(userId, password)
+	tokenResponseDTO, err := userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
 
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
-	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
-	assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+	assert.Equal(t, 1, len(dbConnection.UpdateRefreshTokenRecordArray))
+	assert.Equal(t, 1, len(dbConnection.LoginUserRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CheckRefreshTokenRecordArray))
 	assert.Equal(t, 1, len(dbConnection.UpdatePasswordRecordArray))
 	assert.Equal(t, 0, len(dbConnection.DeleteUserRecordArray))
 
+	assert.Equal(t, userId, dbConnection.LoginUserRecordArray[0].User.ID)
+	assert.Equal(t, password, dbConnection.LoginUserRecordArray[0].User.Password)
 	assert.Equal(t, userId, dbConnection.UpdatePasswordRecordArray[0].UserId)
-	assert.Equal(t, authenticate.Password, dbConnection.UpdatePasswordRecordArray[0].Password)
+	assert.Equal(t, "some new password", dbConnection.UpdatePasswordRecordArray[0].Password)
 	assert.Len(t, dbConnection.UpdatePasswordRecordArray[0].Hash, 32)
+	assert.Equal(t, dbConnection.UpdateRefreshTokenRecordArray[0].RefreshToken, tokenResponseDTO.RefreshToken)
+}
+
+func TestUpdatePassword_WrongCurrentPassword(t *testing.T) {
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{}}, LoginUserResponseArray: []*db.CheckUserResponse{{Err: errUnknown}}, IncrementFailedLoginResponseArray: []*db.IncrementFailedLoginResponse{{FailedLoginAttempts: 1}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: lockout}
+
+	tokenResponseDTO, err := userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
+
+	assert.NotNil(t, err)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.IncrementFailedLoginRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+}
+
+func TestUpdatePassword_Locked(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Minute)
+	dbConnection := &db.DBMock{GetLockedUntilResponseArray: []*db.GetLockedUntilResponse{{LockedUntil: &lockedUntil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, lockout: lockout}
+
+	_, err := userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
+
+	assert.ErrorIs(t, err, ErrUserLocked)
+	assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
+}
+
+func TestUpdatePassword_StatusDisabled(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{Status: adapter.UserStatusDisabled}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	_, err := userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
+
+	assert.ErrorIs(t, err, ErrUserDisabled)
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray))
 }
 
 func TestUpdatePassword_UnknownError(t *testing.T) {
-	dbConnection := &db.DBMock{UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.UpdatePassword(userId, password)
+	tokenResponseDTO, err := userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
 
 	assert.NotNil(t, err)
+	assert.Nil(t, tokenResponseDTO)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray))
 	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
-	assert.Equal(t, 0, len(dbConnection.LoginUserRecordArray))
+	assert.Equal(t, 1, len(dbConnection.LoginUserRecordArray))
 	assert.Equal(t, 0, len(dbConnection.CheckRefreshTokenRecordArray))
 	assert.Equal(t, 1, len(dbConnection.UpdatePasswordRecordArray))
 	assert.Equal(t, 0, len(dbConnection.DeleteUserRecordArray))
 
 	assert.Equal(t, userId, dbConnection.UpdatePasswordRecordArray[0].UserId)
-	assert.Equal(t, authenticate.Password, dbConnection.UpdatePasswordRecordArray[0].Password)
+	assert.Equal(t, "some new password", dbConnection.UpdatePasswordRecordArray[0].Password)
 	assert.Len(t, dbConnection.UpdatePasswordRecordArray[0].Hash, 32)
 }
 
@@ -408,7 +679,7 @@ func TestDeleteUser_Successfully(t *testing.T) {
 	dbConnection := &db.DBMock{DeleteUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.DeleteUser(userId)
+	err := userFacade.DeleteUser(context.Background(), userId)
 
 	assert.Nil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -426,7 +697,7 @@ func TestDeleteUser_DeleteUser_UnknownError(t *testing.T) {
 	dbConnection := &db.DBMock{DeleteUserResponseArray: []*db.ErrorResponse{{Err: errUnknown}}}
 	userFacade := &UserFacade{dbConnection: dbConnection}
 
-	err := userFacade.DeleteUser(userId)
+	err := userFacade.DeleteUser(context.Background(), userId)
 
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, len(dbConnection.CloseRecordArray))
@@ -439,3 +710,71 @@ func TestDeleteUser_DeleteUser_UnknownError(t *testing.T) {
 
 	assert.Equal(t, userId, dbConnection.DeleteUserRecordArray[0].UserId)
 }
+
+// CheckSignKey Test
+
+func TestCheckSignKey_Successfully(t *testing.T) {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{signKey: signKey}
+
+	assert.Nil(t, userFacade.CheckSignKey())
+}
+
+func TestCheckSignKey_NotLoaded(t *testing.T) {
+	userFacade := &UserFacade{}
+
+	assert.ErrorContains(t, userFacade.CheckSignKey(), "no sign key loaded")
+}
+
+func TestLoginUser_StatusDisabled(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{Status: adapter.UserStatusDisabled}}}, AddLoginHistoryResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, loginHistorySize: 3}
+
+	tokenResponseDTO, err := userFacade.LoginUser(context.Background(), userId, password, clientInfo)
+	assert.ErrorIs(t, err, ErrUserDisabled)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+	assert.Equal(t, 1, len(dbConnection.AddLoginHistoryRecordArray))
+	assert.Equal(t, false, dbConnection.AddLoginHistoryRecordArray[0].Entry.Success)
+}
+
+func TestRefreshToken_StatusPending(t *testing.T) {
+	dbConnection := &db.DBMock{CheckRefreshTokenResponseArray: []*db.CheckUserResponse{{User: &db.UserDB{Status: adapter.UserStatusPending}}}}
+	userFacade := &UserFacade{dbConnection: dbConnection}
+
+	tokenResponseDTO, err := userFacade.RefreshToken(context.Background(), userId, refreshToken)
+	assert.ErrorIs(t, err, ErrUserPending)
+	assert.Nil(t, tokenResponseDTO)
+	assert.Equal(t, 1, len(dbConnection.CheckRefreshTokenRecordArray))
+	assert.Equal(t, 0, len(dbConnection.UpdateRefreshTokenRecordArray))
+}
+
+func TestParseAudienceToken(t *testing.T) {
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{signKey: signKey}
+	expiresAt := time.Now().Add(time.Minute).Unix()
+	sign := func(key *rsa.PrivateKey, claims jwt.StandardClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+		assert.Nil(t, err)
+		return token
+	}
+
+	claims := &jwt.StandardClaims{}
+	err = userFacade.parseAudienceToken(sign(signKey, jwt.StandardClaims{Audience: mfaChallengeAudience, Subject: userId.String(), ExpiresAt: expiresAt}), mfaChallengeAudience, claims)
+	assert.Nil(t, err)
+	assert.Equal(t, userId.String(), claims.Subject)
+
+	for _, token := range []string{
+		"someToken",
+		sign(signKey, jwt.StandardClaims{Audience: passwordChangeAudience, ExpiresAt: expiresAt}),
+		sign(signKey, jwt.StandardClaims{ExpiresAt: expiresAt}),
+		sign(signKey, jwt.StandardClaims{Audience: mfaChallengeAudience, ExpiresAt: time.Now().Add(-time.Minute).Unix()}),
+		sign(otherKey, jwt.StandardClaims{Audience: mfaChallengeAudience, ExpiresAt: expiresAt}),
+	} {
+		assert.NotNil(t, userFacade.parseAudienceToken(token, mfaChallengeAudience, &jwt.StandardClaims{}))
+	}
+}
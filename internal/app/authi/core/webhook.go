@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+)
+
+// Creates one outbox entry for every subscription of the event. The entries are stored in the same transaction as the change,
+// so a webhook is only sent for committed changes. All entries of an event share the event id and the payload
+func (userFacade *UserFacade) webhookOutbox(ctx context.Context, eventType string, userId uuid.UUID) []*db.WebhookOutboxDB {
+	var urls []string
+	for _, subscription := range userFacade.webhooks {
+		if slices.Contains(subscription.Events, eventType) {
+			urls = append(urls, subscription.Url)
+		}
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	event := &adapter.WebhookEventDTO{Id: uuid.New(), Type: eventType, UserId: userId, OccurredAt: now}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		logging.FromContext(ctx).Errorf("Webhook %s for user %v couldn't be created: %v", eventType, userId, err)
+		return nil
+	}
+
+	webhooks := make([]*db.WebhookOutboxDB, 0, len(urls))
+	for _, url := range urls {
+		webhooks = append(webhooks, &db.WebhookOutboxDB{EventId: event.Id, EventType: eventType, Url: url, Payload: string(payload), CreatedOn: now, NextAttempt: &now})
+	}
+	return webhooks
+}
@@ -0,0 +1,73 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	webhookSubscriptions = []config.WebhookSubscription{
+		{Url: "https://first.example.com/hook", Secret: "first-secret-1234", Events: []string{adapter.WebhookUserCreated, adapter.WebhookUserDeleted}},
+		{Url: "https://second.example.com/hook", Secret: "second-secret-123", Events: []string{adapter.WebhookUserDeleted}},
+	}
+)
+
+func TestWebhookOutbox_OneEntryPerSubscription(t *testing.T) {
+	userFacade := &UserFacade{webhooks: webhookSubscriptions}
+
+	webhooks := userFacade.webhookOutbox(context.Background(), adapter.WebhookUserDeleted, userId)
+
+	assert.Equal(t, 2, len(webhooks))
+	assert.Equal(t, "https://first.example.com/hook", webhooks[0].Url)
+	assert.Equal(t, "https://second.example.com/hook", webhooks[1].Url)
+	assert.Equal(t, webhooks[0].EventId, webhooks[1].EventId)
+	assert.Equal(t, webhooks[0].Payload, webhooks[1].Payload)
+	assert.NotNil(t, webhooks[0].NextAttempt)
+
+	event := new(adapter.WebhookEventDTO)
+	assert.Nil(t, json.Unmarshal([]byte(webhooks[0].Payload), event))
+	assert.Equal(t, webhooks[0].EventId, event.Id)
+	assert.Equal(t, adapter.WebhookUserDeleted, event.Type)
+	assert.Equal(t, userId, event.UserId)
+}
+
+func TestWebhookOutbox_NotSubscribed(t *testing.T) {
+	userFacade := &UserFacade{webhooks: webhookSubscriptions}
+
+	webhooks := userFacade.webhookOutbox(context.Background(), adapter.WebhookPasswordChanged, userId)
+
+	assert.Nil(t, webhooks)
+}
+
+func TestCreateUser_Webhook(t *testing.T) {
+	dbConnection := &db.DBMock{CreateUserResponseArray: []*db.ErrorResponse{{Err: nil}, {Err: nil}}}
+	userFacade := &UserFacade{dbConnection: dbConnection, webhooks: webhookSubscriptions}
+
+	assert.Nil(t, userFacade.CreateUser(context.Background(), userId, password, nil, false))
+	assert.Nil(t, userFacade.CreateUser(context.Background(), userId, password, nil, true))
+
+	assert.Equal(t, 2, len(dbConnection.CreateUserRecordArray))
+	assert.Equal(t, 1, len(dbConnection.CreateUserRecordArray[0].Webhooks))
+	assert.Equal(t, adapter.WebhookUserCreated, dbConnection.CreateUserRecordArray[0].Webhooks[0].EventType)
+	assert.Equal(t, 0, len(dbConnection.CreateUserRecordArray[1].Webhooks))
+}
+
+func TestUpdatePasswordAndDeleteUser_Webhook(t *testing.T) {
+	dbConnection := &db.DBMock{LoginUserResponseArray: []*db.CheckUserResponse{{Err: nil}}, UpdatePasswordResponseArray: []*db.ErrorResponse{{Err: nil}}, UpdateRefreshTokenResponseArray: []*db.ErrorResponse{{Err: nil}}, DeleteUserResponseArray: []*db.ErrorResponse{{Err: nil}}}
+	signKey, err := loadSignKey(privateKeyPath)
+	assert.Nil(t, err)
+	userFacade := &UserFacade{dbConnection: dbConnection, signKey: signKey, webhooks: webhookSubscriptions}
+
+	_, err = userFacade.UpdatePassword(context.Background(), userId, password, "some new password")
+	assert.Nil(t, err)
+	assert.Nil(t, userFacade.DeleteUser(context.Background(), userId))
+
+	assert.Equal(t, 0, len(dbConnection.UpdatePasswordRecordArray[0].Webhooks))
+	assert.Equal(t, 2, len(dbConnection.DeleteUserRecordArray[0].Webhooks))
+}
@@ -1,43 +1,196 @@
 package db
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"time"
 
-	"github.com/BeanCodeDe/authi/internal/app/authi/util"
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
 	"github.com/google/uuid"
 )
 
 type (
 	UserDB struct {
-		ID        uuid.UUID `db:"id"`
-		Password  string    `db:"password"`
-		CreatedOn time.Time `db:"created_on"`
-		LastLogin time.Time `db:"last_login"`
-		InitUser  bool      `db:"init_user"`
+		ID              uuid.UUID  `db:"id"`
+		Password        string     `db:"password"`
+		CreatedOn       time.Time  `db:"created_on"`
+		LastLogin       time.Time  `db:"last_login"`
+		InitUser        bool       `db:"init_user"`
+		Username        string     `db:"username"`
+		Email           string     `db:"email"`
+		EmailVerified   bool       `db:"email_verified"`
+		Admin           bool       `db:"admin"`
+		Status          string     `db:"status"`
+		StatusReason    string     `db:"status_reason"`
+		StatusChangedOn *time.Time `db:"status_changed_on"`
+		LockedUntil     *time.Time `db:"locked_until"`
+		// Time the current password was set
+		PasswordChangedAt time.Time `db:"password_changed_at"`
+		TotpEnabled       bool      `db:"totp_enabled"`
+		// Methods the user authenticated with at the login of the refresh token
+		Amr []string `db:"refresh_token_amr"`
+	}
+	// Totp of a user. The secret is set at the enrollment and the totp is enabled once a code of it was confirmed
+	TotpDB struct {
+		Secret  string `db:"totp_secret"`
+		Enabled bool   `db:"totp_enabled"`
+		// Latest time step whose code was used. Codes of this or earlier time steps are rejected
+		LastStep int64 `db:"totp_last_step"`
+	}
+	// Passkey of a user. The id is the credential id of the authenticator and the public key is cose encoded
+	PasskeyCredentialDB struct {
+		ID              []byte    `db:"id"`
+		UserId          uuid.UUID `db:"user_id"`
+		PublicKey       []byte    `db:"public_key"`
+		AttestationType string    `db:"attestation_type"`
+		Transports      []string  `db:"transports"`
+		AAGUID          []byte    `db:"aaguid"`
+		// Signature counter of the authenticator. Authenticators without counter always report 0
+		SignCount      int64      `db:"sign_count"`
+		BackupEligible bool       `db:"backup_eligible"`
+		BackupState    bool       `db:"backup_state"`
+		CreatedAt      time.Time  `db:"created_at"`
+		LastUsedAt     *time.Time `db:"last_used_at"`
+	}
+	// Filter of users. Unset fields don't filter
+	UserFilterDB struct {
+		Search string
+		Status string
+		Limit  int
+		Offset int
+	}
+	LoginHistoryDB struct {
+		UserId    uuid.UUID `db:"user_id"`
+		LoginTime time.Time `db:"login_time"`
+		IP        string    `db:"ip"`
+		UserAgent string    `db:"user_agent"`
+		Success   bool      `db:"success"`
+	}
+	// Security relevant event of a user. Events are never updated or deleted
+	AuditEventDB struct {
+		Id            int64     `db:"id"`
+		EventTime     time.Time `db:"event_time"`
+		EventType     string    `db:"event_type"`
+		UserId        uuid.UUID `db:"user_id"`
+		Actor         string    `db:"actor"`
+		Success       bool      `db:"success"`
+		Details       string    `db:"details"`
+		IP            string    `db:"ip"`
+		UserAgent     string    `db:"user_agent"`
+		CorrelationId string    `db:"correlation_id"`
+	}
+	// Filter of audit events. Unset fields don't filter
+	AuditEventFilterDB struct {
+		UserId    *uuid.UUID
+		EventType string
+		Success   *bool
+		From      *time.Time
+		To        *time.Time
+		Limit     int
+		Offset    int
+		// Time and id of the last event of the previous page. If set, only older events are loaded
+		BeforeTime *time.Time
+		BeforeId   int64
+	}
+	// Delivery of a webhook event to one subscription. Deliveries without next attempt are delivered or given up
+	WebhookOutboxDB struct {
+		Id          int64      `db:"id"`
+		EventId     uuid.UUID  `db:"event_id"`
+		EventType   string     `db:"event_type"`
+		Url         string     `db:"url"`
+		Payload     string     `db:"payload"`
+		CreatedOn   time.Time  `db:"created_on"`
+		Attempts    int        `db:"attempts"`
+		NextAttempt *time.Time `db:"next_attempt"`
+		LastError   string     `db:"last_error"`
+	}
+	// Statistics of the connection pool
+	PoolStats struct {
+		AcquiredConns        int32
+		IdleConns            int32
+		TotalConns           int32
+		MaxConns             int32
+		AcquireCount         int64
+		CanceledAcquireCount int64
+		EmptyAcquireCount    int64
+		AcquireDuration      time.Duration
 	}
 	Connection interface {
 		Close()
-		CreateUser(user *UserDB, hash string) error
-		UpdateRefreshToken(userId uuid.UUID, refreshToken string, refreshTokenExpireAt time.Time) error
-		LoginUser(user *UserDB) error
-		CheckRefreshToken(userId uuid.UUID, refreshToken string) error
-		UpdatePassword(userId uuid.UUID, password string, hash string) error
-		DeleteUser(userId uuid.UUID) error
-		DeleteInitUsers() error
+		Ping(ctx context.Context) error
+		CheckMigrations() error
+		Stats() *PoolStats
+		CreateUser(ctx context.Context, user *UserDB, hash string, webhooks ...*WebhookOutboxDB) error
+		CreateUserWithPasswordHash(ctx context.Context, user *UserDB, salt string) error
+		UpdateRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, refreshTokenExpireAt time.Time, amr []string) error
+		LoginUser(ctx context.Context, user *UserDB) error
+		CheckRefreshToken(ctx context.Context, user *UserDB, refreshToken string) error
+		UpdatePassword(ctx context.Context, userId uuid.UUID, password string, hash string, historySize int, webhooks ...*WebhookOutboxDB) error
+		IsRecentPassword(ctx context.Context, userId uuid.UUID, password string, historySize int) (bool, error)
+		DeleteUser(ctx context.Context, userId uuid.UUID, webhooks ...*WebhookOutboxDB) error
+		DeleteInitUsers(ctx context.Context) error
+		GetUser(ctx context.Context, userId uuid.UUID) (*UserDB, error)
+		GetUserIdByIdentifier(ctx context.Context, identifier string) (uuid.UUID, error)
+		GetUsers(ctx context.Context, filter *UserFilterDB) ([]*UserDB, error)
+		SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string, webhooks ...*WebhookOutboxDB) error
+		RevokeRefreshToken(ctx context.Context, userId uuid.UUID, webhooks ...*WebhookOutboxDB) error
+		SetEmailVerificationId(ctx context.Context, userId uuid.UUID, verificationId string) error
+		VerifyEmail(ctx context.Context, userId uuid.UUID, verificationId string, email string) error
+		CreatePasswordReset(ctx context.Context, userId uuid.UUID, tokenHash string, expireAt time.Time) error
+		GetPasswordResetUserId(ctx context.Context, tokenHash string) (uuid.UUID, error)
+		ResetPasswordWithToken(ctx context.Context, userId uuid.UUID, tokenHash string, password string, hash string, historySize int, webhooks ...*WebhookOutboxDB) error
+		GetTotp(ctx context.Context, userId uuid.UUID) (*TotpDB, error)
+		SetTotpSecret(ctx context.Context, userId uuid.UUID, secret string) error
+		EnableTotp(ctx context.Context, userId uuid.UUID, step int64, recoveryCodeHashes []string) error
+		UseTotpStep(ctx context.Context, userId uuid.UUID, step int64) error
+		DisableTotp(ctx context.Context, userId uuid.UUID) error
+		ReplaceRecoveryCodes(ctx context.Context, userId uuid.UUID, recoveryCodeHashes []string) error
+		UseRecoveryCode(ctx context.Context, userId uuid.UUID, recoveryCodeHash string) (int, error)
+		AddPasskeyCredential(ctx context.Context, credential *PasskeyCredentialDB) error
+		GetPasskeyCredential(ctx context.Context, credentialId []byte) (*PasskeyCredentialDB, error)
+		GetPasskeyCredentials(ctx context.Context, userId uuid.UUID) ([]*PasskeyCredentialDB, error)
+		UpdatePasskeyCredentialUsage(ctx context.Context, credential *PasskeyCredentialDB) error
+		UsePasskeyChallenge(ctx context.Context, challenge string, expireAt time.Time) error
+		UpdateLastLogin(ctx context.Context, userId uuid.UUID, lastLogin time.Time) error
+		AddLoginHistory(ctx context.Context, entry *LoginHistoryDB, historySize int) error
+		GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*LoginHistoryDB, error)
+		GetLockedUntil(ctx context.Context, userId uuid.UUID) (*time.Time, error)
+		IncrementFailedLoginAttempts(ctx context.Context, userId uuid.UUID) (int, error)
+		LockUser(ctx context.Context, userId uuid.UUID, lockedUntil time.Time) error
+		ResetFailedLoginAttempts(ctx context.Context, userId uuid.UUID) error
+		AllowRequest(ctx context.Context, identifier string, rate float64, burst int) (bool, error)
+		DeleteExpiredRateLimits(ctx context.Context, route string, expireBefore time.Time) error
+		AddAuditEvent(ctx context.Context, event *AuditEventDB) error
+		GetAuditEvents(ctx context.Context, filter *AuditEventFilterDB) ([]*AuditEventDB, error)
+		ClaimWebhooks(ctx context.Context, limit int, leaseUntil time.Time) ([]*WebhookOutboxDB, error)
+		MarkWebhookDelivered(ctx context.Context, id int64) error
+		MarkWebhookFailed(ctx context.Context, id int64, nextAttempt *time.Time, lastError string) error
+		DeleteDeliveredWebhooks(ctx context.Context, deliveredBefore time.Time) error
 	}
 )
 
 var (
 	ErrUserAlreadyExists = errors.New("user already exists")
+	ErrUserNotFound      = errors.New("user not found")
+	ErrIdentifierTaken   = errors.New("username or email already taken")
+	ErrVerificationUsed  = errors.New("verification was already used or replaced")
+	ErrResetNotFound     = errors.New("password reset not found, expired or already used")
+	ErrTotpEnabled       = errors.New("totp is already enabled")
+	ErrTotpStepUsed      = errors.New("totp is not enabled or the time step was already used")
+	ErrTotpNotEnabled    = errors.New("totp is not enabled")
+	ErrRecoveryCodeUsed  = errors.New("recovery code not found or already used")
+	ErrPasskeyExists     = errors.New("passkey credential already exists")
+	ErrPasskeyNotFound   = errors.New("passkey credential not found")
+	ErrChallengeUsed     = errors.New("passkey challenge was already used")
 )
 
-func NewConnection() (Connection, error) {
-	switch db := strings.ToLower(util.GetEnvWithFallback("DATABASE", "postgresql")); db {
-	case "postgresql":
-		return newPostgresConnection()
+func NewConnection(databaseConfig *config.DatabaseConfig) (Connection, error) {
+	switch db := strings.ToLower(databaseConfig.Type); db {
+	case config.DatabasePostgres:
+		return newPostgresConnection(&databaseConfig.Postgres)
 	default:
-		return nil, errors.New("no configuration for %s found")
+		return nil, fmt.Errorf("no configuration for database %s found", db)
 	}
 }
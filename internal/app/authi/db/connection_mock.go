@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,39 +9,131 @@ import (
 
 type (
 	DBMock struct {
-		CloseRecordArray                []*CloseRecord
-		CreateUserRecordArray           []*CreateUserRecord
-		UpdateRefreshTokenRecordArray   []*UpdateRefreshTokenRecord
-		LoginUserRecordArray            []*LoginUserRecord
-		CheckRefreshTokenRecordArray    []*CheckRefreshTokenRecord
-		UpdatePasswordRecordArray       []*UpdatePasswordRecord
-		DeleteUserRecordArray           []*DeleteUserRecord
-		DeleteInitUsersRecordArray      []*CloseRecord
-		CreateUserResponseArray         []*ErrorResponse
-		UpdateRefreshTokenResponseArray []*ErrorResponse
-		LoginUserResponseArray          []*ErrorResponse
-		CheckRefreshTokenResponseArray  []*ErrorResponse
-		UpdatePasswordResponseArray     []*ErrorResponse
-		DeleteUserResponseArray         []*ErrorResponse
-		DeleteInitUsersResponseArray    []*ErrorResponse
+		CloseRecordArray                          []*CloseRecord
+		CreateUserRecordArray                     []*CreateUserRecord
+		UpdateRefreshTokenRecordArray             []*UpdateRefreshTokenRecord
+		LoginUserRecordArray                      []*LoginUserRecord
+		CheckRefreshTokenRecordArray              []*CheckRefreshTokenRecord
+		UpdatePasswordRecordArray                 []*UpdatePasswordRecord
+		DeleteUserRecordArray                     []*DeleteUserRecord
+		DeleteInitUsersRecordArray                []*CloseRecord
+		CreateUserResponseArray                   []*ErrorResponse
+		UpdateRefreshTokenResponseArray           []*ErrorResponse
+		LoginUserResponseArray                    []*CheckUserResponse
+		CheckRefreshTokenResponseArray            []*CheckUserResponse
+		UpdatePasswordResponseArray               []*ErrorResponse
+		DeleteUserResponseArray                   []*ErrorResponse
+		DeleteInitUsersResponseArray              []*ErrorResponse
+		UpdateLastLoginRecordArray                []*UpdateLastLoginRecord
+		UpdateLastLoginResponseArray              []*ErrorResponse
+		AddLoginHistoryRecordArray                []*AddLoginHistoryRecord
+		AddLoginHistoryResponseArray              []*ErrorResponse
+		GetLoginHistoryRecordArray                []*GetLoginHistoryRecord
+		GetLoginHistoryResponseArray              []*GetLoginHistoryResponse
+		GetLockedUntilRecordArray                 []*UserIdRecord
+		GetLockedUntilResponseArray               []*GetLockedUntilResponse
+		IncrementFailedLoginRecordArray           []*UserIdRecord
+		IncrementFailedLoginResponseArray         []*IncrementFailedLoginResponse
+		LockUserRecordArray                       []*LockUserRecord
+		LockUserResponseArray                     []*ErrorResponse
+		ResetFailedLoginRecordArray               []*UserIdRecord
+		ResetFailedLoginResponseArray             []*ErrorResponse
+		AllowRequestRecordArray                   []*AllowRequestRecord
+		AllowRequestResponseArray                 []*AllowRequestResponse
+		DeleteExpiredRateLimitsRecordArray        []*DeleteExpiredRateLimitsRecord
+		DeleteExpiredRateLimitsResponseArray      []*ErrorResponse
+		CreateUserWithPasswordHashRecordArray     []*CreateUserRecord
+		CreateUserWithPasswordHashResponseArray   []*ErrorResponse
+		PingRecordArray                           []*CloseRecord
+		PingResponseArray                         []*ErrorResponse
+		CheckMigrationsRecordArray                []*CloseRecord
+		CheckMigrationsResponseArray              []*ErrorResponse
+		StatsRecordArray                          []*CloseRecord
+		StatsResponseArray                        []*PoolStats
+		AddAuditEventRecordArray                  []*AddAuditEventRecord
+		AddAuditEventResponseArray                []*ErrorResponse
+		GetAuditEventsRecordArray                 []*GetAuditEventsRecord
+		GetAuditEventsResponseArray               []*GetAuditEventsResponse
+		ClaimWebhooksRecordArray                  []*ClaimWebhooksRecord
+		ClaimWebhooksResponseArray                []*ClaimWebhooksResponse
+		MarkWebhookDeliveredRecordArray           []*WebhookIdRecord
+		MarkWebhookDeliveredResponseArray         []*ErrorResponse
+		MarkWebhookFailedRecordArray              []*MarkWebhookFailedRecord
+		MarkWebhookFailedResponseArray            []*ErrorResponse
+		DeleteDeliveredWebhooksRecordArray        []*DeleteDeliveredWebhooksRecord
+		DeleteDeliveredWebhooksResponseArray      []*ErrorResponse
+		GetUserRecordArray                        []*UserIdRecord
+		GetUserResponseArray                      []*GetUserResponse
+		GetUserIdByIdentifierRecordArray          []*GetUserIdByIdentifierRecord
+		GetUserIdByIdentifierResponseArray        []*GetUserIdByIdentifierResponse
+		GetUsersRecordArray                       []*GetUsersRecord
+		GetUsersResponseArray                     []*GetUsersResponse
+		SetUserStatusRecordArray                  []*SetUserStatusRecord
+		SetUserStatusResponseArray                []*ErrorResponse
+		RevokeRefreshTokenRecordArray             []*RevokeRefreshTokenRecord
+		RevokeRefreshTokenResponseArray           []*ErrorResponse
+		SetEmailVerificationIdRecordArray         []*SetEmailVerificationIdRecord
+		SetEmailVerificationIdResponseArray       []*ErrorResponse
+		VerifyEmailRecordArray                    []*VerifyEmailRecord
+		VerifyEmailResponseArray                  []*ErrorResponse
+		CreatePasswordResetRecordArray            []*CreatePasswordResetRecord
+		CreatePasswordResetResponseArray          []*ErrorResponse
+		GetPasswordResetUserIdRecordArray         []*GetPasswordResetUserIdRecord
+		GetPasswordResetUserIdResponseArray       []*GetPasswordResetUserIdResponse
+		ResetPasswordWithTokenRecordArray         []*ResetPasswordWithTokenRecord
+		ResetPasswordWithTokenResponseArray       []*ErrorResponse
+		IsRecentPasswordRecordArray               []*IsRecentPasswordRecord
+		IsRecentPasswordResponseArray             []*IsRecentPasswordResponse
+		GetTotpRecordArray                        []*UserIdRecord
+		GetTotpResponseArray                      []*GetTotpResponse
+		SetTotpSecretRecordArray                  []*SetTotpSecretRecord
+		SetTotpSecretResponseArray                []*ErrorResponse
+		EnableTotpRecordArray                     []*EnableTotpRecord
+		EnableTotpResponseArray                   []*ErrorResponse
+		UseTotpStepRecordArray                    []*TotpStepRecord
+		UseTotpStepResponseArray                  []*ErrorResponse
+		DisableTotpRecordArray                    []*UserIdRecord
+		DisableTotpResponseArray                  []*ErrorResponse
+		ReplaceRecoveryCodesRecordArray           []*RecoveryCodesRecord
+		ReplaceRecoveryCodesResponseArray         []*ErrorResponse
+		UseRecoveryCodeRecordArray                []*UseRecoveryCodeRecord
+		UseRecoveryCodeResponseArray              []*UseRecoveryCodeResponse
+		AddPasskeyCredentialRecordArray           []*PasskeyCredentialRecord
+		AddPasskeyCredentialResponseArray         []*ErrorResponse
+		GetPasskeyCredentialRecordArray           []*PasskeyCredentialIdRecord
+		GetPasskeyCredentialResponseArray         []*GetPasskeyCredentialResponse
+		GetPasskeyCredentialsRecordArray          []*UserIdRecord
+		GetPasskeyCredentialsResponseArray        []*GetPasskeyCredentialsResponse
+		UpdatePasskeyCredentialUsageRecordArray   []*PasskeyCredentialRecord
+		UpdatePasskeyCredentialUsageResponseArray []*ErrorResponse
+		UsePasskeyChallengeRecordArray            []*PasskeyChallengeRecord
+		UsePasskeyChallengeResponseArray          []*ErrorResponse
 	}
 
 	ErrorResponse struct {
 		Err error
 	}
 
+	// Response of a check of user credentials. Admin and status of User are copied into the checked user
+	CheckUserResponse struct {
+		User *UserDB
+		Err  error
+	}
+
 	CloseRecord struct {
 	}
 
 	CreateUserRecord struct {
-		User *UserDB
-		Hash string
+		User     *UserDB
+		Hash     string
+		Webhooks []*WebhookOutboxDB
 	}
 
 	UpdateRefreshTokenRecord struct {
 		UserId               uuid.UUID
 		RefreshToken         string
 		RefreshTokenExpireAt time.Time
+		Amr                  []string
 	}
 
 	LoginUserRecord struct {
@@ -51,14 +144,248 @@ type (
 		RefreshToken string
 	}
 	UpdatePasswordRecord struct {
-		UserId   uuid.UUID
-		Password string
-		Hash     string
+		UserId      uuid.UUID
+		Password    string
+		Hash        string
+		HistorySize int
+		Webhooks    []*WebhookOutboxDB
+	}
+
+	IsRecentPasswordRecord struct {
+		UserId      uuid.UUID
+		Password    string
+		HistorySize int
+	}
+
+	IsRecentPasswordResponse struct {
+		Recent bool
+		Err    error
+	}
+
+	GetTotpResponse struct {
+		Totp *TotpDB
+		Err  error
+	}
+
+	SetTotpSecretRecord struct {
+		UserId uuid.UUID
+		Secret string
+	}
+
+	TotpStepRecord struct {
+		UserId uuid.UUID
+		Step   int64
+	}
+
+	EnableTotpRecord struct {
+		UserId             uuid.UUID
+		Step               int64
+		RecoveryCodeHashes []string
+	}
+
+	RecoveryCodesRecord struct {
+		UserId             uuid.UUID
+		RecoveryCodeHashes []string
+	}
+
+	UseRecoveryCodeRecord struct {
+		UserId           uuid.UUID
+		RecoveryCodeHash string
+	}
+
+	UseRecoveryCodeResponse struct {
+		Remaining int
+		Err       error
+	}
+
+	PasskeyCredentialRecord struct {
+		Credential *PasskeyCredentialDB
+	}
+
+	PasskeyCredentialIdRecord struct {
+		CredentialId []byte
+	}
+
+	PasskeyChallengeRecord struct {
+		Challenge string
+		ExpireAt  time.Time
+	}
+
+	GetPasskeyCredentialResponse struct {
+		Credential *PasskeyCredentialDB
+		Err        error
+	}
+
+	GetPasskeyCredentialsResponse struct {
+		Credentials []*PasskeyCredentialDB
+		Err         error
 	}
 
 	DeleteUserRecord struct {
+		UserId   uuid.UUID
+		Webhooks []*WebhookOutboxDB
+	}
+
+	UserIdRecord struct {
+		UserId uuid.UUID
+	}
+
+	GetUserResponse struct {
+		User *UserDB
+		Err  error
+	}
+
+	GetUserIdByIdentifierRecord struct {
+		Identifier string
+	}
+
+	GetUserIdByIdentifierResponse struct {
+		UserId uuid.UUID
+		Err    error
+	}
+
+	GetUsersRecord struct {
+		Filter *UserFilterDB
+	}
+
+	GetUsersResponse struct {
+		Users []*UserDB
+		Err   error
+	}
+
+	SetUserStatusRecord struct {
+		UserId   uuid.UUID
+		Status   string
+		Reason   string
+		Webhooks []*WebhookOutboxDB
+	}
+
+	SetEmailVerificationIdRecord struct {
+		UserId         uuid.UUID
+		VerificationId string
+	}
+
+	VerifyEmailRecord struct {
+		UserId         uuid.UUID
+		VerificationId string
+		Email          string
+	}
+
+	CreatePasswordResetRecord struct {
+		UserId    uuid.UUID
+		TokenHash string
+		ExpireAt  time.Time
+	}
+
+	GetPasswordResetUserIdRecord struct {
+		TokenHash string
+	}
+
+	GetPasswordResetUserIdResponse struct {
+		UserId uuid.UUID
+		Err    error
+	}
+
+	ResetPasswordWithTokenRecord struct {
+		UserId      uuid.UUID
+		TokenHash   string
+		Password    string
+		Hash        string
+		HistorySize int
+		Webhooks    []*WebhookOutboxDB
+	}
+
+	RevokeRefreshTokenRecord struct {
+		UserId   uuid.UUID
+		Webhooks []*WebhookOutboxDB
+	}
+
+	ClaimWebhooksRecord struct {
+		Limit      int
+		LeaseUntil time.Time
+	}
+
+	ClaimWebhooksResponse struct {
+		Webhooks []*WebhookOutboxDB
+		Err      error
+	}
+
+	WebhookIdRecord struct {
+		Id int64
+	}
+
+	MarkWebhookFailedRecord struct {
+		Id          int64
+		NextAttempt *time.Time
+		LastError   string
+	}
+
+	DeleteDeliveredWebhooksRecord struct {
+		DeliveredBefore time.Time
+	}
+
+	UpdateLastLoginRecord struct {
+		UserId    uuid.UUID
+		LastLogin time.Time
+	}
+
+	AddLoginHistoryRecord struct {
+		Entry       *LoginHistoryDB
+		HistorySize int
+	}
+
+	GetLoginHistoryRecord struct {
 		UserId uuid.UUID
 	}
+
+	GetLoginHistoryResponse struct {
+		Entries []*LoginHistoryDB
+		Err     error
+	}
+
+	AddAuditEventRecord struct {
+		Event *AuditEventDB
+	}
+
+	GetAuditEventsRecord struct {
+		Filter *AuditEventFilterDB
+	}
+
+	GetAuditEventsResponse struct {
+		Events []*AuditEventDB
+		Err    error
+	}
+
+	GetLockedUntilResponse struct {
+		LockedUntil *time.Time
+		Err         error
+	}
+
+	IncrementFailedLoginResponse struct {
+		FailedLoginAttempts int
+		Err                 error
+	}
+
+	AllowRequestRecord struct {
+		Identifier string
+		Rate       float64
+		Burst      int
+	}
+
+	AllowRequestResponse struct {
+		Allowed bool
+		Err     error
+	}
+
+	DeleteExpiredRateLimitsRecord struct {
+		Route        string
+		ExpireBefore time.Time
+	}
+
+	LockUserRecord struct {
+		UserId      uuid.UUID
+		LockedUntil time.Time
+	}
 )
 
 func (mock *DBMock) Close() {
@@ -66,51 +393,357 @@ func (mock *DBMock) Close() {
 	mock.CloseRecordArray = append(mock.CloseRecordArray, closeRecord)
 }
 
-func (mock *DBMock) CreateUser(user *UserDB, hash string) error {
-	record := &CreateUserRecord{User: user, Hash: hash}
+func (mock *DBMock) Ping(ctx context.Context) error {
+	record := &CloseRecord{}
+	mock.PingRecordArray = append(mock.PingRecordArray, record)
+	response := mock.PingResponseArray[len(mock.PingRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) CheckMigrations() error {
+	record := &CloseRecord{}
+	mock.CheckMigrationsRecordArray = append(mock.CheckMigrationsRecordArray, record)
+	response := mock.CheckMigrationsResponseArray[len(mock.CheckMigrationsRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) Stats() *PoolStats {
+	record := &CloseRecord{}
+	mock.StatsRecordArray = append(mock.StatsRecordArray, record)
+	return mock.StatsResponseArray[len(mock.StatsRecordArray)-1]
+}
+
+func (mock *DBMock) CreateUser(ctx context.Context, user *UserDB, hash string, webhooks ...*WebhookOutboxDB) error {
+	record := &CreateUserRecord{User: user, Hash: hash, Webhooks: webhooks}
 	mock.CreateUserRecordArray = append(mock.CreateUserRecordArray, record)
 	response := mock.CreateUserResponseArray[len(mock.CreateUserRecordArray)-1]
 	return response.Err
 }
 
-func (mock *DBMock) UpdateRefreshToken(userId uuid.UUID, refreshToken string, refreshTokenExpireAt time.Time) error {
-	record := &UpdateRefreshTokenRecord{UserId: userId, RefreshToken: refreshToken, RefreshTokenExpireAt: refreshTokenExpireAt}
+func (mock *DBMock) CreateUserWithPasswordHash(ctx context.Context, user *UserDB, salt string) error {
+	record := &CreateUserRecord{User: user, Hash: salt}
+	mock.CreateUserWithPasswordHashRecordArray = append(mock.CreateUserWithPasswordHashRecordArray, record)
+	response := mock.CreateUserWithPasswordHashResponseArray[len(mock.CreateUserWithPasswordHashRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) UpdateRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, refreshTokenExpireAt time.Time, amr []string) error {
+	record := &UpdateRefreshTokenRecord{UserId: userId, RefreshToken: refreshToken, RefreshTokenExpireAt: refreshTokenExpireAt, Amr: amr}
 	mock.UpdateRefreshTokenRecordArray = append(mock.UpdateRefreshTokenRecordArray, record)
 	response := mock.UpdateRefreshTokenResponseArray[len(mock.UpdateRefreshTokenRecordArray)-1]
 	return response.Err
 }
 
-func (mock *DBMock) LoginUser(user *UserDB) error {
+func (mock *DBMock) LoginUser(ctx context.Context, user *UserDB) error {
 	record := &LoginUserRecord{User: user}
 	mock.LoginUserRecordArray = append(mock.LoginUserRecordArray, record)
 	response := mock.LoginUserResponseArray[len(mock.LoginUserRecordArray)-1]
+	response.copyTo(user)
 	return response.Err
 }
 
-func (mock *DBMock) CheckRefreshToken(userId uuid.UUID, refreshToken string) error {
-	record := &CheckRefreshTokenRecord{UserId: userId, RefreshToken: refreshToken}
+func (mock *DBMock) CheckRefreshToken(ctx context.Context, user *UserDB, refreshToken string) error {
+	record := &CheckRefreshTokenRecord{UserId: user.ID, RefreshToken: refreshToken}
 	mock.CheckRefreshTokenRecordArray = append(mock.CheckRefreshTokenRecordArray, record)
 	response := mock.CheckRefreshTokenResponseArray[len(mock.CheckRefreshTokenRecordArray)-1]
+	response.copyTo(user)
 	return response.Err
 }
 
-func (mock *DBMock) UpdatePassword(userId uuid.UUID, password string, hash string) error {
-	record := &UpdatePasswordRecord{UserId: userId, Password: password, Hash: hash}
+func (mock *DBMock) UpdatePassword(ctx context.Context, userId uuid.UUID, password string, hash string, historySize int, webhooks ...*WebhookOutboxDB) error {
+	record := &UpdatePasswordRecord{UserId: userId, Password: password, Hash: hash, HistorySize: historySize, Webhooks: webhooks}
 	mock.UpdatePasswordRecordArray = append(mock.UpdatePasswordRecordArray, record)
 	response := mock.UpdatePasswordResponseArray[len(mock.UpdatePasswordRecordArray)-1]
 	return response.Err
 }
 
-func (mock *DBMock) DeleteUser(userId uuid.UUID) error {
-	record := &DeleteUserRecord{UserId: userId}
+func (mock *DBMock) DeleteUser(ctx context.Context, userId uuid.UUID, webhooks ...*WebhookOutboxDB) error {
+	record := &DeleteUserRecord{UserId: userId, Webhooks: webhooks}
 	mock.DeleteUserRecordArray = append(mock.DeleteUserRecordArray, record)
 	response := mock.DeleteUserResponseArray[len(mock.DeleteUserRecordArray)-1]
 	return response.Err
 }
 
-func (mock *DBMock) DeleteInitUsers() error {
+func (mock *DBMock) DeleteInitUsers(ctx context.Context) error {
 	record := &CloseRecord{}
 	mock.DeleteInitUsersRecordArray = append(mock.DeleteInitUsersRecordArray, record)
 	response := mock.DeleteInitUsersResponseArray[len(mock.DeleteInitUsersRecordArray)-1]
 	return response.Err
 }
+
+func (mock *DBMock) UpdateLastLogin(ctx context.Context, userId uuid.UUID, lastLogin time.Time) error {
+	record := &UpdateLastLoginRecord{UserId: userId, LastLogin: lastLogin}
+	mock.UpdateLastLoginRecordArray = append(mock.UpdateLastLoginRecordArray, record)
+	response := mock.UpdateLastLoginResponseArray[len(mock.UpdateLastLoginRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) AddLoginHistory(ctx context.Context, entry *LoginHistoryDB, historySize int) error {
+	record := &AddLoginHistoryRecord{Entry: entry, HistorySize: historySize}
+	mock.AddLoginHistoryRecordArray = append(mock.AddLoginHistoryRecordArray, record)
+	response := mock.AddLoginHistoryResponseArray[len(mock.AddLoginHistoryRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*LoginHistoryDB, error) {
+	record := &GetLoginHistoryRecord{UserId: userId}
+	mock.GetLoginHistoryRecordArray = append(mock.GetLoginHistoryRecordArray, record)
+	response := mock.GetLoginHistoryResponseArray[len(mock.GetLoginHistoryRecordArray)-1]
+	return response.Entries, response.Err
+}
+
+func (mock *DBMock) GetLockedUntil(ctx context.Context, userId uuid.UUID) (*time.Time, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.GetLockedUntilRecordArray = append(mock.GetLockedUntilRecordArray, record)
+	response := mock.GetLockedUntilResponseArray[len(mock.GetLockedUntilRecordArray)-1]
+	return response.LockedUntil, response.Err
+}
+
+func (mock *DBMock) IncrementFailedLoginAttempts(ctx context.Context, userId uuid.UUID) (int, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.IncrementFailedLoginRecordArray = append(mock.IncrementFailedLoginRecordArray, record)
+	response := mock.IncrementFailedLoginResponseArray[len(mock.IncrementFailedLoginRecordArray)-1]
+	return response.FailedLoginAttempts, response.Err
+}
+
+func (mock *DBMock) LockUser(ctx context.Context, userId uuid.UUID, lockedUntil time.Time) error {
+	record := &LockUserRecord{UserId: userId, LockedUntil: lockedUntil}
+	mock.LockUserRecordArray = append(mock.LockUserRecordArray, record)
+	response := mock.LockUserResponseArray[len(mock.LockUserRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) ResetFailedLoginAttempts(ctx context.Context, userId uuid.UUID) error {
+	record := &UserIdRecord{UserId: userId}
+	mock.ResetFailedLoginRecordArray = append(mock.ResetFailedLoginRecordArray, record)
+	response := mock.ResetFailedLoginResponseArray[len(mock.ResetFailedLoginRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) AllowRequest(ctx context.Context, identifier string, rate float64, burst int) (bool, error) {
+	record := &AllowRequestRecord{Identifier: identifier, Rate: rate, Burst: burst}
+	mock.AllowRequestRecordArray = append(mock.AllowRequestRecordArray, record)
+	response := mock.AllowRequestResponseArray[len(mock.AllowRequestRecordArray)-1]
+	return response.Allowed, response.Err
+}
+
+func (mock *DBMock) DeleteExpiredRateLimits(ctx context.Context, route string, expireBefore time.Time) error {
+	record := &DeleteExpiredRateLimitsRecord{Route: route, ExpireBefore: expireBefore}
+	mock.DeleteExpiredRateLimitsRecordArray = append(mock.DeleteExpiredRateLimitsRecordArray, record)
+	response := mock.DeleteExpiredRateLimitsResponseArray[len(mock.DeleteExpiredRateLimitsRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) AddAuditEvent(ctx context.Context, event *AuditEventDB) error {
+	record := &AddAuditEventRecord{Event: event}
+	mock.AddAuditEventRecordArray = append(mock.AddAuditEventRecordArray, record)
+	response := mock.AddAuditEventResponseArray[len(mock.AddAuditEventRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) GetAuditEvents(ctx context.Context, filter *AuditEventFilterDB) ([]*AuditEventDB, error) {
+	record := &GetAuditEventsRecord{Filter: filter}
+	mock.GetAuditEventsRecordArray = append(mock.GetAuditEventsRecordArray, record)
+	response := mock.GetAuditEventsResponseArray[len(mock.GetAuditEventsRecordArray)-1]
+	return response.Events, response.Err
+}
+
+func (mock *DBMock) ClaimWebhooks(ctx context.Context, limit int, leaseUntil time.Time) ([]*WebhookOutboxDB, error) {
+	record := &ClaimWebhooksRecord{Limit: limit, LeaseUntil: leaseUntil}
+	mock.ClaimWebhooksRecordArray = append(mock.ClaimWebhooksRecordArray, record)
+	response := mock.ClaimWebhooksResponseArray[len(mock.ClaimWebhooksRecordArray)-1]
+	return response.Webhooks, response.Err
+}
+
+func (mock *DBMock) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	record := &WebhookIdRecord{Id: id}
+	mock.MarkWebhookDeliveredRecordArray = append(mock.MarkWebhookDeliveredRecordArray, record)
+	response := mock.MarkWebhookDeliveredResponseArray[len(mock.MarkWebhookDeliveredRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) MarkWebhookFailed(ctx context.Context, id int64, nextAttempt *time.Time, lastError string) error {
+	record := &MarkWebhookFailedRecord{Id: id, NextAttempt: nextAttempt, LastError: lastError}
+	mock.MarkWebhookFailedRecordArray = append(mock.MarkWebhookFailedRecordArray, record)
+	response := mock.MarkWebhookFailedResponseArray[len(mock.MarkWebhookFailedRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) DeleteDeliveredWebhooks(ctx context.Context, deliveredBefore time.Time) error {
+	record := &DeleteDeliveredWebhooksRecord{DeliveredBefore: deliveredBefore}
+	mock.DeleteDeliveredWebhooksRecordArray = append(mock.DeleteDeliveredWebhooksRecordArray, record)
+	response := mock.DeleteDeliveredWebhooksResponseArray[len(mock.DeleteDeliveredWebhooksRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) GetUser(ctx context.Context, userId uuid.UUID) (*UserDB, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.GetUserRecordArray = append(mock.GetUserRecordArray, record)
+	response := mock.GetUserResponseArray[len(mock.GetUserRecordArray)-1]
+	return response.User, response.Err
+}
+
+func (mock *DBMock) GetUserIdByIdentifier(ctx context.Context, identifier string) (uuid.UUID, error) {
+	record := &GetUserIdByIdentifierRecord{Identifier: identifier}
+	mock.GetUserIdByIdentifierRecordArray = append(mock.GetUserIdByIdentifierRecordArray, record)
+	response := mock.GetUserIdByIdentifierResponseArray[len(mock.GetUserIdByIdentifierRecordArray)-1]
+	return response.UserId, response.Err
+}
+
+func (mock *DBMock) GetUsers(ctx context.Context, filter *UserFilterDB) ([]*UserDB, error) {
+	record := &GetUsersRecord{Filter: filter}
+	mock.GetUsersRecordArray = append(mock.GetUsersRecordArray, record)
+	response := mock.GetUsersResponseArray[len(mock.GetUsersRecordArray)-1]
+	return response.Users, response.Err
+}
+
+func (mock *DBMock) SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string, webhooks ...*WebhookOutboxDB) error {
+	record := &SetUserStatusRecord{UserId: userId, Status: status, Reason: reason, Webhooks: webhooks}
+	mock.SetUserStatusRecordArray = append(mock.SetUserStatusRecordArray, record)
+	response := mock.SetUserStatusResponseArray[len(mock.SetUserStatusRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) RevokeRefreshToken(ctx context.Context, userId uuid.UUID, webhooks ...*WebhookOutboxDB) error {
+	record := &RevokeRefreshTokenRecord{UserId: userId, Webhooks: webhooks}
+	mock.RevokeRefreshTokenRecordArray = append(mock.RevokeRefreshTokenRecordArray, record)
+	response := mock.RevokeRefreshTokenResponseArray[len(mock.RevokeRefreshTokenRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) SetEmailVerificationId(ctx context.Context, userId uuid.UUID, verificationId string) error {
+	record := &SetEmailVerificationIdRecord{UserId: userId, VerificationId: verificationId}
+	mock.SetEmailVerificationIdRecordArray = append(mock.SetEmailVerificationIdRecordArray, record)
+	response := mock.SetEmailVerificationIdResponseArray[len(mock.SetEmailVerificationIdRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) VerifyEmail(ctx context.Context, userId uuid.UUID, verificationId string, email string) error {
+	record := &VerifyEmailRecord{UserId: userId, VerificationId: verificationId, Email: email}
+	mock.VerifyEmailRecordArray = append(mock.VerifyEmailRecordArray, record)
+	response := mock.VerifyEmailResponseArray[len(mock.VerifyEmailRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) CreatePasswordReset(ctx context.Context, userId uuid.UUID, tokenHash string, expireAt time.Time) error {
+	record := &CreatePasswordResetRecord{UserId: userId, TokenHash: tokenHash, ExpireAt: expireAt}
+	mock.CreatePasswordResetRecordArray = append(mock.CreatePasswordResetRecordArray, record)
+	response := mock.CreatePasswordResetResponseArray[len(mock.CreatePasswordResetRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) GetPasswordResetUserId(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	record := &GetPasswordResetUserIdRecord{TokenHash: tokenHash}
+	mock.GetPasswordResetUserIdRecordArray = append(mock.GetPasswordResetUserIdRecordArray, record)
+	response := mock.GetPasswordResetUserIdResponseArray[len(mock.GetPasswordResetUserIdRecordArray)-1]
+	return response.UserId, response.Err
+}
+
+func (mock *DBMock) ResetPasswordWithToken(ctx context.Context, userId uuid.UUID, tokenHash string, password string, hash string, historySize int, webhooks ...*WebhookOutboxDB) error {
+	record := &ResetPasswordWithTokenRecord{UserId: userId, TokenHash: tokenHash, Password: password, Hash: hash, HistorySize: historySize, Webhooks: webhooks}
+	mock.ResetPasswordWithTokenRecordArray = append(mock.ResetPasswordWithTokenRecordArray, record)
+	response := mock.ResetPasswordWithTokenResponseArray[len(mock.ResetPasswordWithTokenRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) IsRecentPassword(ctx context.Context, userId uuid.UUID, password string, historySize int) (bool, error) {
+	record := &IsRecentPasswordRecord{UserId: userId, Password: password, HistorySize: historySize}
+	mock.IsRecentPasswordRecordArray = append(mock.IsRecentPasswordRecordArray, record)
+	response := mock.IsRecentPasswordResponseArray[len(mock.IsRecentPasswordRecordArray)-1]
+	return response.Recent, response.Err
+}
+
+func (mock *DBMock) GetTotp(ctx context.Context, userId uuid.UUID) (*TotpDB, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.GetTotpRecordArray = append(mock.GetTotpRecordArray, record)
+	response := mock.GetTotpResponseArray[len(mock.GetTotpRecordArray)-1]
+	return response.Totp, response.Err
+}
+
+func (mock *DBMock) SetTotpSecret(ctx context.Context, userId uuid.UUID, secret string) error {
+	record := &SetTotpSecretRecord{UserId: userId, Secret: secret}
+	mock.SetTotpSecretRecordArray = append(mock.SetTotpSecretRecordArray, record)
+	response := mock.SetTotpSecretResponseArray[len(mock.SetTotpSecretRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) EnableTotp(ctx context.Context, userId uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	record := &EnableTotpRecord{UserId: userId, Step: step, RecoveryCodeHashes: recoveryCodeHashes}
+	mock.EnableTotpRecordArray = append(mock.EnableTotpRecordArray, record)
+	response := mock.EnableTotpResponseArray[len(mock.EnableTotpRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) UseTotpStep(ctx context.Context, userId uuid.UUID, step int64) error {
+	record := &TotpStepRecord{UserId: userId, Step: step}
+	mock.UseTotpStepRecordArray = append(mock.UseTotpStepRecordArray, record)
+	response := mock.UseTotpStepResponseArray[len(mock.UseTotpStepRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) DisableTotp(ctx context.Context, userId uuid.UUID) error {
+	record := &UserIdRecord{UserId: userId}
+	mock.DisableTotpRecordArray = append(mock.DisableTotpRecordArray, record)
+	response := mock.DisableTotpResponseArray[len(mock.DisableTotpRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) ReplaceRecoveryCodes(ctx context.Context, userId uuid.UUID, recoveryCodeHashes []string) error {
+	record := &RecoveryCodesRecord{UserId: userId, RecoveryCodeHashes: recoveryCodeHashes}
+	mock.ReplaceRecoveryCodesRecordArray = append(mock.ReplaceRecoveryCodesRecordArray, record)
+	response := mock.ReplaceRecoveryCodesResponseArray[len(mock.ReplaceRecoveryCodesRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) UseRecoveryCode(ctx context.Context, userId uuid.UUID, recoveryCodeHash string) (int, error) {
+	record := &UseRecoveryCodeRecord{UserId: userId, RecoveryCodeHash: recoveryCodeHash}
+	mock.UseRecoveryCodeRecordArray = append(mock.UseRecoveryCodeRecordArray, record)
+	response := mock.UseRecoveryCodeResponseArray[len(mock.UseRecoveryCodeRecordArray)-1]
+	return response.Remaining, response.Err
+}
+
+func (mock *DBMock) AddPasskeyCredential(ctx context.Context, credential *PasskeyCredentialDB) error {
+	record := &PasskeyCredentialRecord{Credential: credential}
+	mock.AddPasskeyCredentialRecordArray = append(mock.AddPasskeyCredentialRecordArray, record)
+	response := mock.AddPasskeyCredentialResponseArray[len(mock.AddPasskeyCredentialRecordArray)-1]
+	return response.Err
+}
+
+func (mock *DBMock) GetPasskeyCredential(ctx context.Context, credentialId []byte) (*PasskeyCredentialDB, error) {
+	record := &PasskeyCredentialIdRecord{CredentialId: credentialId}
+	mock.GetPasskeyCredentialRecordArray = append(mock.GetPasskeyCredentialRecordArray, record)
+	response := mock.GetPasskeyCredentialResponseArray[len(mock.GetPasskeyCredentialRecordArray)-1]
+	return response.Credential, response.Err
+}
+
+func (mock *DBMock) GetPasskeyCredentials(ctx context.Context, userId uuid.UUID) ([]*PasskeyCredentialDB, error) {
+	record := &UserIdRecord{UserId: userId}
+	mock.GetPasskeyCredentialsRecordArray = append(mock.GetPasskeyCredentialsRecordArray, record)
+	response := mock.GetPasskeyCredentialsResponseArray[len(mock.GetPasskeyCredentialsRecordArray)-1]
+	return response.Credentials, response.Err
+}
+
+func (mock *DBMock) UpdatePasskeyCredentialUsage(ctx context.Context, credential *PasskeyCredentialDB) error {
+	record := &PasskeyCredentialRecord{Credential: credential}
+	mock.UpdatePasskeyCredentialUsageRecordArray = append(mock.UpdatePasskeyCredentialUsageRecordArray, record)
+	response := mock.UpdatePasskeyCredentialUsageResponseArray[len(mock.UpdatePasskeyCredentialUsageRecordArray)-1]
+	return response.Err
+}
+
+func (response *CheckUserResponse) copyTo(user *UserDB) {
+	if response.User != nil {
+		user.Admin = response.User.Admin
+		user.Status = response.User.Status
+		user.EmailVerified = response.User.EmailVerified
+		user.PasswordChangedAt = response.User.PasswordChangedAt
+		user.TotpEnabled = response.User.TotpEnabled
+		user.Amr = response.User.Amr
+	}
+}
+
+func (mock *DBMock) UsePasskeyChallenge(ctx context.Context, challenge string, expireAt time.Time) error {
+	record := &PasskeyChallengeRecord{Challenge: challenge, ExpireAt: expireAt}
+	mock.UsePasskeyChallengeRecordArray = append(mock.UsePasskeyChallengeRecordArray, record)
+	response := mock.UsePasskeyChallengeResponseArray[len(mock.UsePasskeyChallengeRecordArray)-1]
+	return response.Err
+}
@@ -0,0 +1,10 @@
+CREATE TABLE auth.login_history (
+    id bigserial PRIMARY KEY NOT NULL,
+    user_id uuid NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+    login_time timestamp NOT NULL,
+    ip varchar,
+    user_agent varchar,
+    success boolean NOT NULL
+);
+
+CREATE INDEX idx_login_history_user ON auth.login_history (user_id, login_time DESC);
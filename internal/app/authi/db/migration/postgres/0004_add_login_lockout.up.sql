@@ -0,0 +1,2 @@
+ALTER TABLE auth.user ADD COLUMN failed_login_attempts integer NOT NULL DEFAULT 0;
+ALTER TABLE auth.user ADD COLUMN locked_until timestamp;
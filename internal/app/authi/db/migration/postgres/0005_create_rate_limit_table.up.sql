@@ -0,0 +1,8 @@
+CREATE TABLE auth.rate_limit (
+    identifier varchar PRIMARY KEY NOT NULL,
+    tokens double precision NOT NULL,
+    allowed boolean NOT NULL,
+    last_refill timestamp NOT NULL
+);
+
+CREATE INDEX idx_rate_limit_last_refill ON auth.rate_limit (last_refill);
@@ -0,0 +1,25 @@
+CREATE TABLE auth.audit_event (
+    id bigserial PRIMARY KEY NOT NULL,
+    event_time timestamp NOT NULL,
+    event_type varchar NOT NULL,
+    user_id uuid NOT NULL,
+    actor varchar NOT NULL,
+    success boolean NOT NULL,
+    details varchar,
+    ip varchar,
+    user_agent varchar,
+    correlation_id varchar
+);
+
+CREATE INDEX idx_audit_event_time ON auth.audit_event (event_time DESC, id DESC);
+CREATE INDEX idx_audit_event_user ON auth.audit_event (user_id, event_time DESC);
+CREATE INDEX idx_audit_event_type ON auth.audit_event (event_type, event_time DESC);
+
+CREATE FUNCTION auth.reject_audit_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_event_append_only BEFORE UPDATE OR DELETE ON auth.audit_event
+    FOR EACH ROW EXECUTE FUNCTION auth.reject_audit_event_change();
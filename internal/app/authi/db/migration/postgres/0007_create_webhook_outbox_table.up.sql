@@ -0,0 +1,15 @@
+CREATE TABLE auth.webhook_outbox (
+    id bigserial PRIMARY KEY NOT NULL,
+    event_id uuid NOT NULL,
+    event_type varchar NOT NULL,
+    url varchar NOT NULL,
+    payload varchar NOT NULL,
+    created_on timestamp NOT NULL,
+    attempts integer NOT NULL DEFAULT 0,
+    next_attempt timestamp,
+    delivered_on timestamp,
+    last_error varchar
+);
+
+CREATE INDEX idx_webhook_outbox_next_attempt ON auth.webhook_outbox (next_attempt) WHERE next_attempt IS NOT NULL;
+CREATE INDEX idx_webhook_outbox_delivered_on ON auth.webhook_outbox (delivered_on) WHERE delivered_on IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE auth.user ADD COLUMN admin boolean NOT NULL DEFAULT false;
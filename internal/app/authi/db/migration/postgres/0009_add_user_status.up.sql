@@ -0,0 +1,5 @@
+ALTER TABLE auth.user ADD COLUMN status varchar NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'disabled', 'locked', 'pending'));
+ALTER TABLE auth.user ADD COLUMN status_reason varchar;
+ALTER TABLE auth.user ADD COLUMN status_changed_on timestamp;
+
+CREATE INDEX idx_user_status ON auth.user (status) WHERE status <> 'active';
@@ -0,0 +1,5 @@
+ALTER TABLE auth.user ADD COLUMN username varchar;
+ALTER TABLE auth.user ADD COLUMN email varchar;
+
+CREATE UNIQUE INDEX idx_user_username ON auth.user (LOWER(username));
+CREATE UNIQUE INDEX idx_user_email ON auth.user (LOWER(email));
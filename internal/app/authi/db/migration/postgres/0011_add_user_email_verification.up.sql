@@ -0,0 +1,2 @@
+ALTER TABLE auth.user ADD COLUMN email_verified boolean NOT NULL DEFAULT false;
+ALTER TABLE auth.user ADD COLUMN email_verification_id varchar(32);
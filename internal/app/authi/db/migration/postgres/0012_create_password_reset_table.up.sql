@@ -0,0 +1,7 @@
+CREATE TABLE auth.password_reset (
+    token_hash char(64) PRIMARY KEY NOT NULL,
+    user_id uuid NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+    expire_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_password_reset_user ON auth.password_reset (user_id);
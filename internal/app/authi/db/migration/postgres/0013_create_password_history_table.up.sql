@@ -0,0 +1,11 @@
+ALTER TABLE auth.user ADD COLUMN password_changed_at timestamp NOT NULL DEFAULT now();
+
+CREATE TABLE auth.password_history (
+    id bigserial PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+    password varchar NOT NULL,
+    salt varchar(32) NOT NULL,
+    changed_at timestamp NOT NULL
+);
+
+CREATE INDEX idx_password_history_user ON auth.password_history (user_id, changed_at);
@@ -0,0 +1,4 @@
+ALTER TABLE auth.user ADD COLUMN totp_secret varchar(32);
+ALTER TABLE auth.user ADD COLUMN totp_enabled boolean NOT NULL DEFAULT false;
+ALTER TABLE auth.user ADD COLUMN totp_last_step bigint;
+ALTER TABLE auth.user ADD COLUMN refresh_token_amr varchar(8)[];
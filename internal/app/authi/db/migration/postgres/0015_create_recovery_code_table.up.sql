@@ -0,0 +1,8 @@
+CREATE TABLE auth.recovery_code (
+    id bigserial PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+    code_hash char(64) NOT NULL,
+    used_at timestamp
+);
+
+CREATE INDEX idx_recovery_code_user ON auth.recovery_code (user_id, code_hash);
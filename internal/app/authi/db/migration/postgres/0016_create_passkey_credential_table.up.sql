@@ -0,0 +1,15 @@
+CREATE TABLE auth.passkey_credential (
+    id bytea PRIMARY KEY,
+    user_id uuid NOT NULL REFERENCES auth.user(id) ON DELETE CASCADE,
+    public_key bytea NOT NULL,
+    attestation_type varchar(32) NOT NULL,
+    transports varchar(16)[] NOT NULL DEFAULT '{}',
+    aaguid bytea NOT NULL,
+    sign_count bigint NOT NULL DEFAULT 0,
+    backup_eligible boolean NOT NULL DEFAULT false,
+    backup_state boolean NOT NULL DEFAULT false,
+    created_at timestamp NOT NULL,
+    last_used_at timestamp
+);
+
+CREATE INDEX idx_passkey_credential_user ON auth.passkey_credential (user_id);
@@ -0,0 +1,4 @@
+CREATE TABLE auth.passkey_challenge (
+    challenge varchar(128) PRIMARY KEY NOT NULL,
+    expire_at timestamp NOT NULL
+);
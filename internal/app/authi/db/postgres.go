@@ -5,12 +5,15 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 	"time"
 
-	"github.com/BeanCodeDe/authi/internal/app/authi/util"
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/golang-migrate/migrate/v4/source/iofs"
 	"github.com/google/uuid"
@@ -23,92 +26,166 @@ import (
 var (
 	//go:embed migration/postgres/*.up.sql
 	postgresMigrationFs embed.FS
+	// Escapes the wildcards of LIKE patterns
+	likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+)
+
+const (
+	userColumns = "id, created_on, last_login, init_user, COALESCE(username, '') AS username, COALESCE(email, '') AS email, email_verified, admin, status, COALESCE(status_reason, '') AS status_reason, status_changed_on, locked_until, password_changed_at, totp_enabled"
 )
 
 type (
 	postgresConnection struct {
-		dbPool *pgxpool.Pool
+		dbPool          *tracedPool
+		migration       *migrate.Migrate
+		latestMigration uint
 	}
 )
 
-func newPostgresConnection() (Connection, error) {
-	user := util.GetEnvWithFallback("POSTGRES_USER", "postgres")
-	dbName := util.GetEnvWithFallback("POSTGRES_DB", "postgres")
-	password, err := util.GetEnv("POSTGRES_PASSWORD")
-	if err != nil {
-		return nil, fmt.Errorf("postgres password has to be set: %w", err)
-	}
-	host := util.GetEnvWithFallback("POSTGRES_HOST", "postgres")
-	port, err := util.GetEnvIntWithFallback("POSTGRES_PORT", 5432)
-	options := util.GetEnvWithFallback("POSTGRES_OPTIONS", "sslmode=disable")
-	migrationOptions := util.GetEnvWithFallback("POSTGRES_MIGRATION_OPTIONS", "&x-migrations-table=authi-migration")
-
-	if err != nil {
-		return nil, fmt.Errorf("port is not a number: %w", err)
-	}
-
-	url := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", user, password, host, port, dbName, options)
-	err = migratePostgresDatabase(url + migrationOptions)
+func newPostgresConnection(postgresConfig *config.PostgresConfig) (Connection, error) {
+	url := fmt.Sprintf("postgres://%s:%s@%s:%d/%s?%s", postgresConfig.User, postgresConfig.Password, postgresConfig.Host, postgresConfig.Port, postgresConfig.DB, postgresConfig.Options)
+	migration, latestMigration, err := migratePostgresDatabase(url + postgresConfig.MigrationOptions)
 	if err != nil {
 		return nil, fmt.Errorf("error while migrating database: %w", err)
 	}
 
 	dbPool, err := pgxpool.Connect(context.Background(), url)
 	if err != nil {
+		migration.Close()
 		return nil, fmt.Errorf("unable to connect to database: %v", err)
 	}
-	return &postgresConnection{dbPool: dbPool}, nil
+	return &postgresConnection{dbPool: &tracedPool{dbPool}, migration: migration, latestMigration: latestMigration}, nil
 }
 
 func (connection *postgresConnection) Close() {
 	connection.dbPool.Close()
+	connection.migration.Close()
 }
 
-func migratePostgresDatabase(url string) error {
+// Migrates the database to the latest version and returns the migration instance with the latest version to check the state later on
+func migratePostgresDatabase(url string) (*migrate.Migrate, uint, error) {
 	d, err := iofs.New(postgresMigrationFs, "migration/postgres")
 	if err != nil {
-		return fmt.Errorf("error while creating instance of migration scrips: %w", err)
+		return nil, 0, fmt.Errorf("error while creating instance of migration scrips: %w", err)
+	}
+	latestMigration, err := latestMigrationVersion(d)
+	if err != nil {
+		return nil, 0, err
 	}
 	m, err := migrate.NewWithSourceInstance("iofs", d, url)
 	if err != nil {
-		return fmt.Errorf("error while creating instance of migration scrips: %w", err)
+		return nil, 0, fmt.Errorf("error while creating instance of migration scrips: %w", err)
 	}
 	err = m.Up()
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		m.Close()
+		return nil, 0, fmt.Errorf("error while migrating: %w", err)
+	}
+	return m, latestMigration, nil
+}
+
+func latestMigrationVersion(migrationSource source.Driver) (uint, error) {
+	version, err := migrationSource.First()
 	if err != nil {
-		if errors.Is(err, migrate.ErrNoChange) {
-			return nil
+		return 0, fmt.Errorf("error while reading first migration script: %w", err)
+	}
+	for {
+		next, err := migrationSource.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("error while reading migration script after version %d: %w", version, err)
 		}
-		return fmt.Errorf("error while migrating: %w", err)
+		version = next
+	}
+}
+
+func (connection *postgresConnection) Ping(ctx context.Context) error {
+	if err := connection.dbPool.Ping(ctx); err != nil {
+		return fmt.Errorf("unknown error when pinging database: %v", err)
 	}
 	return nil
 }
 
-func (connection *postgresConnection) CreateUser(user *UserDB, hash string) error {
-	if _, err := connection.dbPool.Exec(context.Background(), "INSERT INTO auth.user(id, password,salt,created_on,last_login, init_user) VALUES($1,MD5($2),$3,$4,$5,$6)", user.ID, user.Password+hash, hash, user.CreatedOn, user.LastLogin, user.InitUser); err != nil {
-		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) {
-			switch pgErr.Code {
-			case pgerrcode.UniqueViolation:
-				return ErrUserAlreadyExists
+func (connection *postgresConnection) Stats() *PoolStats {
+	stat := connection.dbPool.Stat()
+	return &PoolStats{
+		AcquiredConns:        stat.AcquiredConns(),
+		IdleConns:            stat.IdleConns(),
+		TotalConns:           stat.TotalConns(),
+		MaxConns:             stat.MaxConns(),
+		AcquireCount:         stat.AcquireCount(),
+		CanceledAcquireCount: stat.CanceledAcquireCount(),
+		EmptyAcquireCount:    stat.EmptyAcquireCount(),
+		AcquireDuration:      stat.AcquireDuration(),
+	}
+}
+
+func (connection *postgresConnection) CheckMigrations() error {
+	version, dirty, err := connection.migration.Version()
+	if err != nil {
+		return fmt.Errorf("unknown error when reading migration version: %v", err)
+	}
+	if dirty {
+		return fmt.Errorf("migration version %d is dirty", version)
+	}
+	if version < connection.latestMigration {
+		return fmt.Errorf("migration version %d is older than expected version %d", version, connection.latestMigration)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) CreateUser(ctx context.Context, user *UserDB, hash string, webhooks ...*WebhookOutboxDB) error {
+	return connection.withWebhooks(ctx, webhooks, func(querier querier) error {
+		if _, err := querier.Exec(ctx, "INSERT INTO auth.user(id, password,salt,created_on,last_login, init_user, admin, username, email) VALUES($1,MD5($2),$3,$4,$5,$6,$7,NULLIF($8, ''),NULLIF($9, ''))", user.ID, user.Password+hash, hash, user.CreatedOn, user.LastLogin, user.InitUser, user.Admin, user.Username, user.Email); err != nil {
+			if uniqueErr := uniqueViolationError(err); uniqueErr != nil {
+				return uniqueErr
 			}
+
+			return fmt.Errorf("unknown error when inserting user: %v", err)
 		}
+		return nil
+	})
+}
 
-		return fmt.Errorf("unknown error when inserting user: %v", err)
+// Creates a user whose password field already contains MD5(password + salt)
+func (connection *postgresConnection) CreateUserWithPasswordHash(ctx context.Context, user *UserDB, salt string) error {
+	if _, err := connection.dbPool.Exec(ctx, "INSERT INTO auth.user(id, password,salt,created_on,last_login, init_user, admin, username, email) VALUES($1,LOWER($2),$3,$4,$5,$6,$7,NULLIF($8, ''),NULLIF($9, ''))", user.ID, user.Password, salt, user.CreatedOn, user.LastLogin, user.InitUser, user.Admin, user.Username, user.Email); err != nil {
+		if uniqueErr := uniqueViolationError(err); uniqueErr != nil {
+			return uniqueErr
+		}
+
+		return fmt.Errorf("unknown error when inserting user with password hash: %v", err)
 	}
 	return nil
 }
 
-func (connection *postgresConnection) UpdateRefreshToken(userId uuid.UUID, refreshToken string, refreshTokenExpireAt time.Time) error {
-	if _, err := connection.dbPool.Exec(context.Background(), "UPDATE auth.user SET refresh_token=$1, refresh_token_expire=$2 WHERE id=$3", refreshToken, refreshTokenExpireAt, userId); err != nil {
+// Maps violations of the primary key to ErrUserAlreadyExists and of the unique username or email to ErrIdentifierTaken. Returns nil for other errors
+func uniqueViolationError(err error) error {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+		return nil
+	}
+	switch pgErr.ConstraintName {
+	case "idx_user_username", "idx_user_email":
+		return ErrIdentifierTaken
+	default:
+		return ErrUserAlreadyExists
+	}
+}
+
+func (connection *postgresConnection) UpdateRefreshToken(ctx context.Context, userId uuid.UUID, refreshToken string, refreshTokenExpireAt time.Time, amr []string) error {
+	if _, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET refresh_token=$1, refresh_token_expire=$2, refresh_token_amr=$3 WHERE id=$4", refreshToken, refreshTokenExpireAt, amr, userId); err != nil {
 		return fmt.Errorf("unknown error when updating refresh token of user %s error: %v", userId, err)
 	}
 	return nil
 }
 
-func (connection *postgresConnection) LoginUser(user *UserDB) error {
+func (connection *postgresConnection) LoginUser(ctx context.Context, user *UserDB) error {
 
 	var users []*UserDB
-	if err := pgxscan.Select(context.Background(), connection.dbPool, &users, `SELECT id,created_on,last_login FROM auth.user WHERE id = $1 AND password = MD5(CONCAT($2::text,(SELECT salt FROM auth.user WHERE id = $1)::text))`, user.ID, user.Password); err != nil {
+	if err := pgxscan.Select(ctx, connection.dbPool, &users, `SELECT id,created_on,last_login,admin,status,email_verified,password_changed_at,totp_enabled FROM auth.user WHERE id = $1 AND password = MD5(CONCAT($2::text,(SELECT salt FROM auth.user WHERE id = $1)::text))`, user.ID, user.Password); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
@@ -129,52 +206,632 @@ func (connection *postgresConnection) LoginUser(user *UserDB) error {
 
 	user.CreatedOn = users[0].CreatedOn
 	user.LastLogin = users[0].LastLogin
+	user.Admin = users[0].Admin
+	user.Status = users[0].Status
+	user.EmailVerified = users[0].EmailVerified
+	user.PasswordChangedAt = users[0].PasswordChangedAt
+	user.TotpEnabled = users[0].TotpEnabled
 
 	return nil
 }
 
-func (connection *postgresConnection) CheckRefreshToken(userId uuid.UUID, refreshToken string) error {
+func (connection *postgresConnection) CheckRefreshToken(ctx context.Context, user *UserDB, refreshToken string) error {
 
 	var users []*UserDB
-	if err := pgxscan.Select(context.Background(), connection.dbPool, &users, `SELECT id FROM auth.user WHERE id = $1 AND refresh_token = $2 AND refresh_token_expire > now()`, userId, refreshToken); err != nil {
+	if err := pgxscan.Select(ctx, connection.dbPool, &users, `SELECT id,admin,status,email_verified,password_changed_at,COALESCE(refresh_token_amr, '{}') AS refresh_token_amr FROM auth.user WHERE id = $1 AND refresh_token = $2 AND refresh_token_expire > now()`, user.ID, refreshToken); err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) {
 			switch pgErr.Code {
 			case pgerrcode.NoDataFound:
-				return fmt.Errorf("no user with id %s and matching refresh token found", userId)
+				return fmt.Errorf("no user with id %s and matching refresh token found", user.ID)
 			}
 		}
 		return fmt.Errorf("unknown error when checking user: %v", err)
 	}
 
 	if len(users) == 0 {
-		return fmt.Errorf("user with id %s not found. Probably wrong refresh token", userId)
+		return fmt.Errorf("user with id %s not found. Probably wrong refresh token", user.ID)
 	}
 
 	if len(users) != 1 {
 		return fmt.Errorf("cant find only one user. Len: %v, Userlist: %v", len(users), users)
 	}
 
+	user.Admin = users[0].Admin
+	user.Status = users[0].Status
+	user.EmailVerified = users[0].EmailVerified
+	user.PasswordChangedAt = users[0].PasswordChangedAt
+	user.Amr = users[0].Amr
 	return nil
 }
 
-func (connection *postgresConnection) UpdatePassword(userId uuid.UUID, password string, hash string) error {
-	if _, err := connection.dbPool.Exec(context.Background(), "UPDATE auth.user SET password=MD5($1), salt=$2 WHERE id=$3", password+hash, hash, userId); err != nil {
-		return fmt.Errorf("unknown error when updating password of user %s error: %v", userId, err)
+// Sets the new password and moves the current one into the password history, which keeps the historySize - 1 previous passwords.
+// The refresh token is revoked, so every session has to log in with the new password. Unknown users are reported with ErrUserNotFound and no webhook is stored
+func (connection *postgresConnection) UpdatePassword(ctx context.Context, userId uuid.UUID, password string, hash string, historySize int, webhooks ...*WebhookOutboxDB) error {
+	return connection.inTransaction(ctx, webhooks, func(querier querier) error {
+		commandTag, err := querier.Exec(ctx, `
+			WITH history AS (`+insertPasswordHistory+` WHERE id=$3 AND $4 > 1)
+			UPDATE auth.user SET password=MD5($1), salt=$2, password_changed_at=now(), refresh_token=NULL, refresh_token_expire=NULL WHERE id=$3`, password+hash, hash, userId, historySize)
+		if err != nil {
+			return fmt.Errorf("unknown error when updating password of user %s error: %v", userId, err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return ErrUserNotFound
+		}
+		return trimPasswordHistory(ctx, querier, userId, historySize)
+	})
+}
+
+// Statement that copies the current password of the users matching the appended condition into the password history
+const insertPasswordHistory = "INSERT INTO auth.password_history(user_id, password, salt, changed_at) SELECT id, password, salt, password_changed_at FROM auth.user"
+
+func trimPasswordHistory(ctx context.Context, querier querier, userId uuid.UUID, historySize int) error {
+	if _, err := querier.Exec(ctx, "DELETE FROM auth.password_history WHERE user_id=$1 AND id NOT IN (SELECT id FROM auth.password_history WHERE user_id=$1 ORDER BY changed_at DESC, id DESC LIMIT GREATEST($2::int - 1, 0))", userId, historySize); err != nil {
+		return fmt.Errorf("unknown error when trimming password history of user %s error: %v", userId, err)
 	}
 	return nil
 }
 
-func (connection *postgresConnection) DeleteUser(userId uuid.UUID) error {
-	if _, err := connection.dbPool.Exec(context.Background(), "DELETE FROM auth.user WHERE id=$1", userId); err != nil {
-		return fmt.Errorf("unknown error when deleting user %s error: %v", userId, err)
+// Checks whether the password matches the current password or one of the previous passwords of the user. historySize counts the current password
+func (connection *postgresConnection) IsRecentPassword(ctx context.Context, userId uuid.UUID, password string, historySize int) (bool, error) {
+	var recent bool
+	if err := pgxscan.Get(ctx, connection.dbPool, &recent, `
+		SELECT EXISTS (SELECT 1 FROM (
+			SELECT password, salt, password_changed_at AS changed_at FROM auth.user WHERE id=$1
+			UNION ALL
+			SELECT password, salt, changed_at FROM auth.password_history WHERE user_id=$1
+			ORDER BY changed_at DESC LIMIT $3
+		) recent WHERE recent.password = MD5($2 || recent.salt))`, userId, password, historySize); err != nil {
+		return false, fmt.Errorf("unknown error when checking password history of user %s error: %v", userId, err)
 	}
-	return nil
+	return recent, nil
 }
 
-func (connection *postgresConnection) DeleteInitUsers() error {
-	if _, err := connection.dbPool.Exec(context.Background(), "DELETE FROM auth.user WHERE init_user=TRUE"); err != nil {
+func (connection *postgresConnection) DeleteUser(ctx context.Context, userId uuid.UUID, webhooks ...*WebhookOutboxDB) error {
+	return connection.withWebhooks(ctx, webhooks, func(querier querier) error {
+		if _, err := querier.Exec(ctx, "DELETE FROM auth.user WHERE id=$1", userId); err != nil {
+			return fmt.Errorf("unknown error when deleting user %s error: %v", userId, err)
+		}
+		return nil
+	})
+}
+
+func (connection *postgresConnection) DeleteInitUsers(ctx context.Context) error {
+	if _, err := connection.dbPool.Exec(ctx, "DELETE FROM auth.user WHERE init_user=TRUE"); err != nil {
 		return fmt.Errorf("unknown error when deleting init users: %v", err)
 	}
 	return nil
 }
+
+func (connection *postgresConnection) GetUser(ctx context.Context, userId uuid.UUID) (*UserDB, error) {
+	var users []*UserDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &users, `SELECT `+userColumns+` FROM auth.user WHERE id = $1`, userId); err != nil {
+		return nil, fmt.Errorf("unknown error when loading user %s error: %v", userId, err)
+	}
+
+	if len(users) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return users[0], nil
+}
+
+// Finds the id of the user whose username or email matches the identifier, ignoring the case
+func (connection *postgresConnection) GetUserIdByIdentifier(ctx context.Context, identifier string) (uuid.UUID, error) {
+	var userIds []uuid.UUID
+	if err := pgxscan.Select(ctx, connection.dbPool, &userIds, `SELECT id FROM auth.user WHERE LOWER(username) = LOWER($1) OR LOWER(email) = LOWER($1)`, identifier); err != nil {
+		return uuid.Nil, fmt.Errorf("unknown error when loading user by identifier error: %v", err)
+	}
+
+	if len(userIds) != 1 {
+		return uuid.Nil, ErrUserNotFound
+	}
+	return userIds[0], nil
+}
+
+func (connection *postgresConnection) GetUsers(ctx context.Context, filter *UserFilterDB) ([]*UserDB, error) {
+	query, args := userQuery(filter)
+	var users []*UserDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &users, query, args...); err != nil {
+		return nil, fmt.Errorf("unknown error when loading users error: %v", err)
+	}
+	return users, nil
+}
+
+// Builds the query for users with a condition for every set field of the filter, oldest users first. The search matches the start of the id, username or email
+func userQuery(filter *UserFilterDB) (string, []any) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Search != "" {
+		addCondition("(id::text LIKE ($%[1]d || '%%') OR LOWER(username) LIKE ($%[1]d || '%%') OR LOWER(email) LIKE ($%[1]d || '%%'))", likeEscaper.Replace(strings.ToLower(filter.Search)))
+	}
+	if filter.Status != "" {
+		addCondition("status = $%d", filter.Status)
+	}
+
+	query := `SELECT ` + userColumns + ` FROM auth.user`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_on, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	return query, args
+}
+
+// Stores the status with reason and time of the change. Every status except active also revokes the refresh token
+func (connection *postgresConnection) SetUserStatus(ctx context.Context, userId uuid.UUID, status string, reason string, webhooks ...*WebhookOutboxDB) error {
+	return connection.withWebhooks(ctx, webhooks, func(querier querier) error {
+		commandTag, err := querier.Exec(ctx, "UPDATE auth.user SET status=$1, status_reason=NULLIF($2, ''), status_changed_on=now(), refresh_token=CASE WHEN $1 = 'active' THEN refresh_token END WHERE id=$3", status, reason, userId)
+		if err != nil {
+			return fmt.Errorf("unknown error when setting status of user %s error: %v", userId, err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+// Stores the id of the latest verification mail. Older verification mails can't be used anymore
+func (connection *postgresConnection) SetEmailVerificationId(ctx context.Context, userId uuid.UUID, verificationId string) error {
+	commandTag, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET email_verification_id=$1 WHERE id=$2", verificationId, userId)
+	if err != nil {
+		return fmt.Errorf("unknown error when setting email verification of user %s error: %v", userId, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Marks the email as verified if the verification id is the latest one and the email wasn't changed since. The verification id can only be used once
+func (connection *postgresConnection) VerifyEmail(ctx context.Context, userId uuid.UUID, verificationId string, email string) error {
+	commandTag, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET email_verified=true, email_verification_id=NULL WHERE id=$1 AND email_verification_id=$2 AND LOWER(email)=LOWER($3)", userId, verificationId, email)
+	if err != nil {
+		return fmt.Errorf("unknown error when verifying email of user %s error: %v", userId, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrVerificationUsed
+	}
+	return nil
+}
+
+// Stores the hash of a password reset token. Older resets of the user and expired resets of all users are deleted
+func (connection *postgresConnection) CreatePasswordReset(ctx context.Context, userId uuid.UUID, tokenHash string, expireAt time.Time) error {
+	if _, err := connection.dbPool.Exec(ctx, `
+		WITH outdated AS (DELETE FROM auth.password_reset WHERE user_id = $1 OR expire_at <= now())
+		INSERT INTO auth.password_reset(token_hash, user_id, expire_at) VALUES($2, $1, $3)`, userId, tokenHash, expireAt); err != nil {
+		return fmt.Errorf("unknown error when creating password reset of user %s error: %v", userId, err)
+	}
+	return nil
+}
+
+// Finds the user of a password reset that isn't expired yet
+func (connection *postgresConnection) GetPasswordResetUserId(ctx context.Context, tokenHash string) (uuid.UUID, error) {
+	var userIds []uuid.UUID
+	if err := pgxscan.Select(ctx, connection.dbPool, &userIds, `SELECT user_id FROM auth.password_reset WHERE token_hash = $1 AND expire_at > now()`, tokenHash); err != nil {
+		return uuid.Nil, fmt.Errorf("unknown error when loading password reset error: %v", err)
+	}
+
+	if len(userIds) == 0 {
+		return uuid.Nil, ErrResetNotFound
+	}
+	return userIds[0], nil
+}
+
+// Deletes the password reset and sets the new password in one statement, so a reset can only be used once. Sessions and failed logins of the user are reset as well
+func (connection *postgresConnection) ResetPasswordWithToken(ctx context.Context, userId uuid.UUID, tokenHash string, password string, hash string, historySize int, webhooks ...*WebhookOutboxDB) error {
+	return connection.inTransaction(ctx, webhooks, func(querier querier) error {
+		commandTag, err := querier.Exec(ctx, `
+			WITH reset AS (DELETE FROM auth.password_reset WHERE token_hash = $1 AND user_id = $2 AND expire_at > now() RETURNING user_id),
+			history AS (`+insertPasswordHistory+` WHERE id IN (SELECT user_id FROM reset) AND $5 > 1)
+			UPDATE auth.user SET password=MD5($3), salt=$4, password_changed_at=now(), refresh_token=NULL, refresh_token_expire=NULL, failed_login_attempts=0, locked_until=NULL
+			WHERE id IN (SELECT user_id FROM reset)`, tokenHash, userId, password+hash, hash, historySize)
+		if err != nil {
+			return fmt.Errorf("unknown error when resetting password of user %s error: %v", userId, err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return ErrResetNotFound
+		}
+		return trimPasswordHistory(ctx, querier, userId, historySize)
+	})
+}
+
+// Revokes the refresh token, so the user has to log in again once the access token expired
+func (connection *postgresConnection) RevokeRefreshToken(ctx context.Context, userId uuid.UUID, webhooks ...*WebhookOutboxDB) error {
+	return connection.withWebhooks(ctx, webhooks, func(querier querier) error {
+		commandTag, err := querier.Exec(ctx, "UPDATE auth.user SET refresh_token=NULL, refresh_token_expire=NULL WHERE id=$1", userId)
+		if err != nil {
+			return fmt.Errorf("unknown error when revoking refresh token of user %s error: %v", userId, err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+}
+
+func (connection *postgresConnection) GetTotp(ctx context.Context, userId uuid.UUID) (*TotpDB, error) {
+	var totps []*TotpDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &totps, `SELECT COALESCE(totp_secret, '') AS totp_secret, totp_enabled, COALESCE(totp_last_step, 0) AS totp_last_step FROM auth.user WHERE id = $1`, userId); err != nil {
+		return nil, fmt.Errorf("unknown error when loading totp of user %s error: %v", userId, err)
+	}
+
+	if len(totps) == 0 {
+		return nil, ErrUserNotFound
+	}
+	return totps[0], nil
+}
+
+// Replaces the secret of a totp that isn't enabled yet, so only the latest enrollment can be confirmed
+func (connection *postgresConnection) SetTotpSecret(ctx context.Context, userId uuid.UUID, secret string) error {
+	commandTag, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET totp_secret=$1, totp_last_step=NULL WHERE id=$2 AND NOT totp_enabled", secret, userId)
+	if err != nil {
+		return fmt.Errorf("unknown error when setting totp secret of user %s error: %v", userId, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrTotpEnabled
+	}
+	return nil
+}
+
+// Enables the enrolled totp and replaces the recovery codes in one transaction. The time step of the confirmed code is stored, so the code can't be used to log in
+func (connection *postgresConnection) EnableTotp(ctx context.Context, userId uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	return connection.inTransaction(ctx, nil, func(querier querier) error {
+		commandTag, err := querier.Exec(ctx, "UPDATE auth.user SET totp_enabled=true, totp_last_step=$1 WHERE id=$2 AND totp_secret IS NOT NULL AND NOT totp_enabled", step, userId)
+		if err != nil {
+			return fmt.Errorf("unknown error when enabling totp of user %s error: %v", userId, err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return ErrTotpEnabled
+		}
+		return replaceRecoveryCodes(ctx, querier, userId, recoveryCodeHashes)
+	})
+}
+
+func replaceRecoveryCodes(ctx context.Context, querier querier, userId uuid.UUID, recoveryCodeHashes []string) error {
+	if _, err := querier.Exec(ctx, `
+		WITH deleted AS (DELETE FROM auth.recovery_code WHERE user_id=$1)
+		INSERT INTO auth.recovery_code(user_id, code_hash) SELECT $1, unnest($2::text[])`, userId, recoveryCodeHashes); err != nil {
+		return fmt.Errorf("unknown error when replacing recovery codes of user %s error: %v", userId, err)
+	}
+	return nil
+}
+
+// Stores the time step of a used code. Fails if a code of this or a later time step was used before, so every code can only be used once
+func (connection *postgresConnection) UseTotpStep(ctx context.Context, userId uuid.UUID, step int64) error {
+	commandTag, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET totp_last_step=$1 WHERE id=$2 AND totp_enabled AND COALESCE(totp_last_step, -1) < $1", step, userId)
+	if err != nil {
+		return fmt.Errorf("unknown error when using totp of user %s error: %v", userId, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrTotpStepUsed
+	}
+	return nil
+}
+
+// Disables the totp and deletes the recovery codes of the user
+func (connection *postgresConnection) DisableTotp(ctx context.Context, userId uuid.UUID) error {
+	commandTag, err := connection.dbPool.Exec(ctx, `
+		WITH codes AS (DELETE FROM auth.recovery_code WHERE user_id=$1)
+		UPDATE auth.user SET totp_secret=NULL, totp_enabled=false, totp_last_step=NULL WHERE id=$1`, userId)
+	if err != nil {
+		return fmt.Errorf("unknown error when disabling totp of user %s error: %v", userId, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Replaces all recovery codes of a user with enabled totp, so codes of earlier generations can't be used anymore
+func (connection *postgresConnection) ReplaceRecoveryCodes(ctx context.Context, userId uuid.UUID, recoveryCodeHashes []string) error {
+	return connection.inTransaction(ctx, nil, func(querier querier) error {
+		commandTag, err := querier.Exec(ctx, "SELECT id FROM auth.user WHERE id=$1 AND totp_enabled FOR UPDATE", userId)
+		if err != nil {
+			return fmt.Errorf("unknown error when loading totp of user %s error: %v", userId, err)
+		}
+		if commandTag.RowsAffected() == 0 {
+			return ErrTotpNotEnabled
+		}
+		return replaceRecoveryCodes(ctx, querier, userId, recoveryCodeHashes)
+	})
+}
+
+// Marks the recovery code as used and returns the number of unused codes that are left
+func (connection *postgresConnection) UseRecoveryCode(ctx context.Context, userId uuid.UUID, recoveryCodeHash string) (int, error) {
+	var result struct {
+		Used      int `db:"used"`
+		Remaining int `db:"remaining"`
+	}
+	if err := pgxscan.Get(ctx, connection.dbPool, &result, `
+		WITH used AS (UPDATE auth.recovery_code SET used_at=now() WHERE user_id=$1 AND code_hash=$2 AND used_at IS NULL RETURNING id)
+		SELECT (SELECT count(*) FROM used) AS used,
+			(SELECT count(*) FROM auth.recovery_code WHERE user_id=$1 AND used_at IS NULL AND id NOT IN (SELECT id FROM used)) AS remaining`, userId, recoveryCodeHash); err != nil {
+		return 0, fmt.Errorf("unknown error when using recovery code of user %s error: %v", userId, err)
+	}
+	if result.Used == 0 {
+		return 0, ErrRecoveryCodeUsed
+	}
+	return result.Remaining, nil
+}
+
+// Stores a new passkey. Credential ids are unique over all users, so a credential can't be registered twice
+func (connection *postgresConnection) AddPasskeyCredential(ctx context.Context, credential *PasskeyCredentialDB) error {
+	if _, err := connection.dbPool.Exec(ctx, `
+		INSERT INTO auth.passkey_credential(id, user_id, public_key, attestation_type, transports, aaguid, sign_count, backup_eligible, backup_state, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		credential.ID, credential.UserId, credential.PublicKey, credential.AttestationType, credential.Transports, credential.AAGUID, credential.SignCount, credential.BackupEligible, credential.BackupState, credential.CreatedAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrPasskeyExists
+		}
+		return fmt.Errorf("unknown error when adding passkey of user %s error: %v", credential.UserId, err)
+	}
+	return nil
+}
+
+const passkeyCredentialColumns = "id, user_id, public_key, attestation_type, transports, aaguid, sign_count, backup_eligible, backup_state, created_at, last_used_at"
+
+func (connection *postgresConnection) GetPasskeyCredential(ctx context.Context, credentialId []byte) (*PasskeyCredentialDB, error) {
+	var credentials []*PasskeyCredentialDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &credentials, `SELECT `+passkeyCredentialColumns+` FROM auth.passkey_credential WHERE id = $1`, credentialId); err != nil {
+		return nil, fmt.Errorf("unknown error when loading passkey error: %v", err)
+	}
+
+	if len(credentials) == 0 {
+		return nil, ErrPasskeyNotFound
+	}
+	return credentials[0], nil
+}
+
+func (connection *postgresConnection) GetPasskeyCredentials(ctx context.Context, userId uuid.UUID) ([]*PasskeyCredentialDB, error) {
+	var credentials []*PasskeyCredentialDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &credentials, `SELECT `+passkeyCredentialColumns+` FROM auth.passkey_credential WHERE user_id = $1 ORDER BY created_at`, userId); err != nil {
+		return nil, fmt.Errorf("unknown error when loading passkeys of user %s error: %v", userId, err)
+	}
+	return credentials, nil
+}
+
+// Stores the sign count, backup state and last use of a passkey after a login
+func (connection *postgresConnection) UpdatePasskeyCredentialUsage(ctx context.Context, credential *PasskeyCredentialDB) error {
+	commandTag, err := connection.dbPool.Exec(ctx, "UPDATE auth.passkey_credential SET sign_count=$1, backup_state=$2, last_used_at=$3 WHERE id=$4", credential.SignCount, credential.BackupState, credential.LastUsedAt, credential.ID)
+	if err != nil {
+		return fmt.Errorf("unknown error when updating passkey of user %s error: %v", credential.UserId, err)
+	}
+	if commandTag.RowsAffected() == 0 {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// Marks the challenge of a passkey ceremony as used, so the ceremony can't be replayed. Used challenges are kept until they expire
+func (connection *postgresConnection) UsePasskeyChallenge(ctx context.Context, challenge string, expireAt time.Time) error {
+	if _, err := connection.dbPool.Exec(ctx, `
+		WITH expired AS (DELETE FROM auth.passkey_challenge WHERE expire_at <= now())
+		INSERT INTO auth.passkey_challenge(challenge, expire_at) VALUES($1, $2)`, challenge, expireAt); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+			return ErrChallengeUsed
+		}
+		return fmt.Errorf("unknown error when using passkey challenge error: %v", err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) UpdateLastLogin(ctx context.Context, userId uuid.UUID, lastLogin time.Time) error {
+	if _, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET last_login=$1 WHERE id=$2", lastLogin, userId); err != nil {
+		return fmt.Errorf("unknown error when updating last login of user %s error: %v", userId, err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) AddLoginHistory(ctx context.Context, entry *LoginHistoryDB, historySize int) error {
+	if _, err := connection.dbPool.Exec(ctx, "INSERT INTO auth.login_history(user_id, login_time, ip, user_agent, success) SELECT $1,$2,$3,$4,$5 WHERE EXISTS (SELECT 1 FROM auth.user WHERE id=$1)", entry.UserId, entry.LoginTime, entry.IP, entry.UserAgent, entry.Success); err != nil {
+		return fmt.Errorf("unknown error when inserting login history of user %s error: %v", entry.UserId, err)
+	}
+	if _, err := connection.dbPool.Exec(ctx, "DELETE FROM auth.login_history WHERE user_id=$1 AND id NOT IN (SELECT id FROM auth.login_history WHERE user_id=$1 ORDER BY login_time DESC, id DESC LIMIT $2)", entry.UserId, historySize); err != nil {
+		return fmt.Errorf("unknown error when trimming login history of user %s error: %v", entry.UserId, err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) GetLoginHistory(ctx context.Context, userId uuid.UUID) ([]*LoginHistoryDB, error) {
+	var entries []*LoginHistoryDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &entries, `SELECT user_id, login_time, COALESCE(ip, '') AS ip, COALESCE(user_agent, '') AS user_agent, success FROM auth.login_history WHERE user_id = $1 ORDER BY login_time DESC, id DESC`, userId); err != nil {
+		return nil, fmt.Errorf("unknown error when loading login history of user %s error: %v", userId, err)
+	}
+	return entries, nil
+}
+
+func (connection *postgresConnection) GetLockedUntil(ctx context.Context, userId uuid.UUID) (*time.Time, error) {
+	var lockedUntil []*time.Time
+	if err := pgxscan.Select(ctx, connection.dbPool, &lockedUntil, `SELECT locked_until FROM auth.user WHERE id = $1`, userId); err != nil {
+		return nil, fmt.Errorf("unknown error when loading lock of user %s error: %v", userId, err)
+	}
+
+	if len(lockedUntil) == 0 {
+		return nil, nil
+	}
+	return lockedUntil[0], nil
+}
+
+func (connection *postgresConnection) IncrementFailedLoginAttempts(ctx context.Context, userId uuid.UUID) (int, error) {
+	var failedLoginAttempts []int
+	if err := pgxscan.Select(ctx, connection.dbPool, &failedLoginAttempts, `UPDATE auth.user SET failed_login_attempts=failed_login_attempts+1 WHERE id=$1 RETURNING failed_login_attempts`, userId); err != nil {
+		return 0, fmt.Errorf("unknown error when incrementing failed login attempts of user %s error: %v", userId, err)
+	}
+
+	if len(failedLoginAttempts) == 0 {
+		return 0, nil
+	}
+	return failedLoginAttempts[0], nil
+}
+
+func (connection *postgresConnection) LockUser(ctx context.Context, userId uuid.UUID, lockedUntil time.Time) error {
+	if _, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET locked_until=$1 WHERE id=$2", lockedUntil, userId); err != nil {
+		return fmt.Errorf("unknown error when locking user %s error: %v", userId, err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) ResetFailedLoginAttempts(ctx context.Context, userId uuid.UUID) error {
+	if _, err := connection.dbPool.Exec(ctx, "UPDATE auth.user SET failed_login_attempts=0, locked_until=NULL WHERE id=$1", userId); err != nil {
+		return fmt.Errorf("unknown error when resetting failed login attempts of user %s error: %v", userId, err)
+	}
+	return nil
+}
+
+// Token bucket per identifier. The bucket is refilled by rate tokens per second up to burst and every allowed request takes one token
+func (connection *postgresConnection) AllowRequest(ctx context.Context, identifier string, rate float64, burst int) (bool, error) {
+	var allowed []bool
+	if err := pgxscan.Select(ctx, connection.dbPool, &allowed, `
+		INSERT INTO auth.rate_limit AS limits (identifier, tokens, allowed, last_refill) VALUES ($1, $3::double precision - 1, $3 >= 1, now())
+		ON CONFLICT (identifier) DO UPDATE SET
+			tokens = CASE
+				WHEN LEAST($3, limits.tokens + EXTRACT(EPOCH FROM (now() - limits.last_refill)) * $2) >= 1
+				THEN LEAST($3, limits.tokens + EXTRACT(EPOCH FROM (now() - limits.last_refill)) * $2) - 1
+				ELSE LEAST($3, limits.tokens + EXTRACT(EPOCH FROM (now() - limits.last_refill)) * $2)
+			END,
+			allowed = LEAST($3, limits.tokens + EXTRACT(EPOCH FROM (now() - limits.last_refill)) * $2) >= 1,
+			last_refill = now()
+		RETURNING allowed`, identifier, rate, burst); err != nil {
+		return false, fmt.Errorf("unknown error when checking rate limit of %s error: %v", identifier, err)
+	}
+
+	if len(allowed) != 1 {
+		return false, fmt.Errorf("cant find only one rate limit. Len: %v", len(allowed))
+	}
+	return allowed[0], nil
+}
+
+// Deletes the buckets of the route that weren't refilled since expireBefore. Routes expire at different times, so each route only deletes its own buckets
+func (connection *postgresConnection) DeleteExpiredRateLimits(ctx context.Context, route string, expireBefore time.Time) error {
+	if _, err := connection.dbPool.Exec(ctx, "DELETE FROM auth.rate_limit WHERE identifier LIKE ($1 || ':%') AND last_refill < $2", likeEscaper.Replace(route), expireBefore); err != nil {
+		return fmt.Errorf("unknown error when deleting expired rate limits of route %s: %v", route, err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) AddAuditEvent(ctx context.Context, event *AuditEventDB) error {
+	if _, err := connection.dbPool.Exec(ctx, "INSERT INTO auth.audit_event(event_time, event_type, user_id, actor, success, details, ip, user_agent, correlation_id) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9)", event.EventTime, event.EventType, event.UserId, event.Actor, event.Success, event.Details, event.IP, event.UserAgent, event.CorrelationId); err != nil {
+		return fmt.Errorf("unknown error when inserting audit event %s of user %s error: %v", event.EventType, event.UserId, err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) GetAuditEvents(ctx context.Context, filter *AuditEventFilterDB) ([]*AuditEventDB, error) {
+	query, args := auditEventQuery(filter)
+	var events []*AuditEventDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &events, query, args...); err != nil {
+		return nil, fmt.Errorf("unknown error when loading audit events error: %v", err)
+	}
+	return events, nil
+}
+
+// Builds the query for audit events with a condition for every set field of the filter, newest events first
+func auditEventQuery(filter *AuditEventFilterDB) (string, []any) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.UserId != nil {
+		addCondition("user_id = $%d", *filter.UserId)
+	}
+	if filter.EventType != "" {
+		addCondition("event_type = $%d", filter.EventType)
+	}
+	if filter.Success != nil {
+		addCondition("success = $%d", *filter.Success)
+	}
+	if filter.From != nil {
+		addCondition("event_time >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		addCondition("event_time < $%d", *filter.To)
+	}
+	if filter.BeforeTime != nil {
+		args = append(args, *filter.BeforeTime, filter.BeforeId)
+		conditions = append(conditions, fmt.Sprintf("(event_time, id) < ($%d, $%d)", len(args)-1, len(args)))
+	}
+
+	query := `SELECT id, event_time, event_type, user_id, actor, success, COALESCE(details, '') AS details, COALESCE(ip, '') AS ip, COALESCE(user_agent, '') AS user_agent, COALESCE(correlation_id, '') AS correlation_id FROM auth.audit_event`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY event_time DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	return query, args
+}
+
+// Runs the change and stores the webhooks in one transaction, so webhooks are only sent for committed changes and never get lost.
+// Without webhooks a change of a single statement runs directly on the pool
+func (connection *postgresConnection) withWebhooks(ctx context.Context, webhooks []*WebhookOutboxDB, change func(querier querier) error) error {
+	if len(webhooks) == 0 {
+		return change(connection.dbPool)
+	}
+	return connection.inTransaction(ctx, webhooks, change)
+}
+
+// Runs the change and stores the webhooks in one transaction, also if there are no webhooks. Changes of several statements use it to be atomic
+func (connection *postgresConnection) inTransaction(ctx context.Context, webhooks []*WebhookOutboxDB, change func(querier querier) error) error {
+	tx, err := connection.dbPool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("unknown error when starting transaction: %v", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := change(tx); err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		if _, err := tx.Exec(ctx, "INSERT INTO auth.webhook_outbox(event_id, event_type, url, payload, created_on, next_attempt) VALUES($1,$2,$3,$4,$5,$6)", webhook.EventId, webhook.EventType, webhook.Url, webhook.Payload, webhook.CreatedOn, webhook.NextAttempt); err != nil {
+			return fmt.Errorf("unknown error when inserting webhook %s error: %v", webhook.EventType, err)
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("unknown error when committing transaction: %v", err)
+	}
+	return nil
+}
+
+// Loads due webhooks and moves their next attempt to leaseUntil, so other instances don't deliver them at the same time
+func (connection *postgresConnection) ClaimWebhooks(ctx context.Context, limit int, leaseUntil time.Time) ([]*WebhookOutboxDB, error) {
+	var webhooks []*WebhookOutboxDB
+	if err := pgxscan.Select(ctx, connection.dbPool, &webhooks, `
+		UPDATE auth.webhook_outbox SET next_attempt = $2
+		WHERE id IN (SELECT id FROM auth.webhook_outbox WHERE next_attempt <= now() ORDER BY next_attempt, id LIMIT $1 FOR UPDATE SKIP LOCKED)
+		RETURNING id, event_id, event_type, url, payload, created_on, attempts, next_attempt, COALESCE(last_error, '') AS last_error`, limit, leaseUntil); err != nil {
+		return nil, fmt.Errorf("unknown error when claiming webhooks error: %v", err)
+	}
+	return webhooks, nil
+}
+
+func (connection *postgresConnection) MarkWebhookDelivered(ctx context.Context, id int64) error {
+	if _, err := connection.dbPool.Exec(ctx, "UPDATE auth.webhook_outbox SET attempts=attempts+1, next_attempt=NULL, delivered_on=now(), last_error=NULL WHERE id=$1", id); err != nil {
+		return fmt.Errorf("unknown error when marking webhook %d as delivered error: %v", id, err)
+	}
+	return nil
+}
+
+// Counts the failed attempt. Without next attempt the webhook is given up
+func (connection *postgresConnection) MarkWebhookFailed(ctx context.Context, id int64, nextAttempt *time.Time, lastError string) error {
+	if _, err := connection.dbPool.Exec(ctx, "UPDATE auth.webhook_outbox SET attempts=attempts+1, next_attempt=$2, last_error=$3 WHERE id=$1", id, nextAttempt, lastError); err != nil {
+		return fmt.Errorf("unknown error when marking webhook %d as failed error: %v", id, err)
+	}
+	return nil
+}
+
+func (connection *postgresConnection) DeleteDeliveredWebhooks(ctx context.Context, deliveredBefore time.Time) error {
+	if _, err := connection.dbPool.Exec(ctx, "DELETE FROM auth.webhook_outbox WHERE delivered_on < $1", deliveredBefore); err != nil {
+		return fmt.Errorf("unknown error when deleting delivered webhooks: %v", err)
+	}
+	return nil
+}
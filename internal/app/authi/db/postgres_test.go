@@ -0,0 +1,98 @@
+package db
+
+import (
+	"io/fs"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestMigrationVersion(t *testing.T) {
+	migrationScripts, err := fs.Glob(postgresMigrationFs, "migration/postgres/*.up.sql")
+	assert.Nil(t, err)
+	migrationSource, err := iofs.New(postgresMigrationFs, "migration/postgres")
+	assert.Nil(t, err)
+
+	version, err := latestMigrationVersion(migrationSource)
+	assert.Nil(t, err)
+	assert.Equal(t, uint(len(migrationScripts)), version)
+}
+
+func TestUserColumns_SelectsUser(t *testing.T) {
+	// The password is never loaded with the user and the methods of the login belong to the refresh token
+	notSelected := map[string]bool{"password": true, "refresh_token_amr": true}
+	columns := strings.Split(userColumns, ", ")
+
+	userType := reflect.TypeOf(UserDB{})
+	for i := 0; i < userType.NumField(); i++ {
+		column := userType.Field(i).Tag.Get("db")
+		if notSelected[column] {
+			continue
+		}
+		assert.True(t, slices.ContainsFunc(columns, func(selected string) bool {
+			return selected == column || strings.HasSuffix(selected, " AS "+column)
+		}), "column %s isn't selected", column)
+	}
+}
+
+func TestStatementOperation(t *testing.T) {
+	assert.Equal(t, "SELECT", statementOperation("select id FROM authi.user WHERE id = $1"))
+	assert.Equal(t, "INSERT", statementOperation("\n\t\tINSERT INTO authi.user(id) VALUES($1)"))
+	assert.Equal(t, "SQL", statementOperation("  "))
+}
+
+func TestAuditEventQuery_WithoutFilter(t *testing.T) {
+	query, args := auditEventQuery(&AuditEventFilterDB{Limit: 10, Offset: 20})
+
+	assert.NotContains(t, query, "WHERE")
+	assert.Contains(t, query, "ORDER BY event_time DESC, id DESC LIMIT $1 OFFSET $2")
+	assert.Equal(t, []any{10, 20}, args)
+}
+
+func TestAuditEventQuery_WithFilter(t *testing.T) {
+	userId := uuid.New()
+	success := false
+	from := time.Now().Add(-time.Hour)
+
+	query, args := auditEventQuery(&AuditEventFilterDB{UserId: &userId, EventType: "user.login", Success: &success, From: &from, Limit: 10})
+
+	assert.Contains(t, query, "WHERE user_id = $1 AND event_type = $2 AND success = $3 AND event_time >= $4 ORDER BY event_time DESC, id DESC LIMIT $5 OFFSET $6")
+	assert.Equal(t, []any{userId, "user.login", false, from, 10, 0}, args)
+}
+
+func TestAuditEventQuery_BeforeEvent(t *testing.T) {
+	before := time.Now()
+
+	query, args := auditEventQuery(&AuditEventFilterDB{EventType: "user.login", BeforeTime: &before, BeforeId: 42, Limit: 10})
+
+	assert.Contains(t, query, "WHERE event_type = $1 AND (event_time, id) < ($2, $3) ORDER BY event_time DESC, id DESC LIMIT $4 OFFSET $5")
+	assert.Equal(t, []any{"user.login", before, int64(42), 10, 0}, args)
+}
+
+func TestUserQuery_WithoutFilter(t *testing.T) {
+	query, args := userQuery(&UserFilterDB{Limit: 10})
+
+	assert.NotContains(t, query, "WHERE")
+	assert.Contains(t, query, "ORDER BY created_on, id LIMIT $1 OFFSET $2")
+	assert.Equal(t, []any{10, 0}, args)
+}
+
+func TestUserQuery_WithFilter(t *testing.T) {
+	query, args := userQuery(&UserFilterDB{Search: "C5FF", Status: "disabled", Limit: 10, Offset: 20})
+
+	assert.Contains(t, query, "WHERE (id::text LIKE ($1 || '%') OR LOWER(username) LIKE ($1 || '%') OR LOWER(email) LIKE ($1 || '%')) AND status = $2 ORDER BY created_on, id LIMIT $3 OFFSET $4")
+	assert.NotRegexp(t, `\bpassword\b`, query)
+	assert.Equal(t, []any{"c5ff", "disabled", 10, 20}, args)
+}
+
+func TestUserQuery_SearchEscapesWildcards(t *testing.T) {
+	_, args := userQuery(&UserFilterDB{Search: "Max_100%", Limit: 10})
+
+	assert.Equal(t, []any{`max\_100\%`, 10, 0}, args)
+}
@@ -0,0 +1,113 @@
+package db
+
+import (
+	"context"
+	"strings"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/tracing"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Pool that records a span for every sql statement. Only the statement is recorded, never its arguments
+	tracedPool struct {
+		*pgxpool.Pool
+	}
+
+	// Transaction that records a span for every sql statement like tracedPool
+	tracedTx struct {
+		pgx.Tx
+	}
+
+	// Executes sql statements on the pool or inside of a transaction
+	querier interface {
+		Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+		Query(ctx context.Context, sql string, arguments ...interface{}) (pgx.Rows, error)
+	}
+
+	// Rows that end the span of their query when they are closed
+	tracedRows struct {
+		pgx.Rows
+		span trace.Span
+	}
+)
+
+func (pool *tracedPool) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return tracedExec(ctx, pool.Pool, sql, arguments...)
+}
+
+func (pool *tracedPool) Query(ctx context.Context, sql string, arguments ...interface{}) (pgx.Rows, error) {
+	return tracedQuery(ctx, pool.Pool, sql, arguments...)
+}
+
+// Starts a transaction whose statements are traced
+func (pool *tracedPool) Begin(ctx context.Context) (*tracedTx, error) {
+	tx, err := pool.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedTx{tx}, nil
+}
+
+func (tx *tracedTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return tracedExec(ctx, tx.Tx, sql, arguments...)
+}
+
+func (tx *tracedTx) Query(ctx context.Context, sql string, arguments ...interface{}) (pgx.Rows, error) {
+	return tracedQuery(ctx, tx.Tx, sql, arguments...)
+}
+
+func tracedExec(ctx context.Context, querier querier, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	ctx, span := startStatementSpan(ctx, sql)
+	defer span.End()
+
+	commandTag, err := querier.Exec(ctx, sql, arguments...)
+	recordStatementError(span, err)
+	return commandTag, err
+}
+
+func tracedQuery(ctx context.Context, querier querier, sql string, arguments ...interface{}) (pgx.Rows, error) {
+	ctx, span := startStatementSpan(ctx, sql)
+
+	rows, err := querier.Query(ctx, sql, arguments...)
+	if err != nil {
+		recordStatementError(span, err)
+		span.End()
+		return nil, err
+	}
+	return &tracedRows{Rows: rows, span: span}, nil
+}
+
+func (rows *tracedRows) Close() {
+	rows.Rows.Close()
+	recordStatementError(rows.span, rows.Rows.Err())
+	rows.span.End()
+}
+
+func startStatementSpan(ctx context.Context, sql string) (context.Context, trace.Span) {
+	return tracing.Tracer().Start(ctx, statementOperation(sql),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBQueryText(sql)),
+	)
+}
+
+func recordStatementError(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+}
+
+// First keyword of the statement, e.g. SELECT or INSERT, used as name of the span
+func statementOperation(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "SQL"
+	}
+	return strings.ToUpper(fields[0])
+}
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type (
+	// Rate limiter store that keeps its buckets in the database, so all instances of authi share the same limits
+	RateLimiterStore struct {
+		connection  Connection
+		route       string
+		rate        float64
+		burst       int
+		expiresIn   time.Duration
+		mutex       sync.Mutex
+		lastCleanup time.Time
+	}
+)
+
+// Buckets of the route are removed once they weren't used for expiresIn. It has to be at least the time to refill a bucket, otherwise clients could empty their bucket, wait and start with a full one.
+// The identifiers of the buckets have to start with the route and a colon
+func NewRateLimiterStore(connection Connection, route string, rate float64, burst int, expiresIn time.Duration) *RateLimiterStore {
+	return &RateLimiterStore{connection: connection, route: route, rate: rate, burst: burst, expiresIn: expiresIn, lastCleanup: time.Now()}
+}
+
+func (store *RateLimiterStore) Allow(identifier string) (bool, error) {
+	store.cleanupExpired()
+
+	allowed, err := store.connection.AllowRequest(context.Background(), identifier, store.rate, store.burst)
+	if err != nil {
+		return false, fmt.Errorf("error while checking rate limit: %w", err)
+	}
+	return allowed, nil
+}
+
+func (store *RateLimiterStore) cleanupExpired() {
+	store.mutex.Lock()
+	now := time.Now()
+	if now.Sub(store.lastCleanup) < store.expiresIn {
+		store.mutex.Unlock()
+		return
+	}
+	store.lastCleanup = now
+	store.mutex.Unlock()
+
+	// Buckets that weren't touched since expiresIn are full again, so removing them doesn't change the limits
+	if err := store.connection.DeleteExpiredRateLimits(context.Background(), store.route, now.Add(-store.expiresIn)); err != nil {
+		log.Warnf("Expired rate limits couldn't be deleted: %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	errUnknown = errors.New("some error")
+)
+
+func TestRateLimiterStoreAllow_Successfully(t *testing.T) {
+	dbConnection := &DBMock{AllowRequestResponseArray: []*AllowRequestResponse{{Allowed: true}}}
+	store := NewRateLimiterStore(dbConnection, "login", 1, 5, time.Minute)
+
+	allowed, err := store.Allow("login:192.0.2.1:someUser")
+
+	assert.Nil(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, len(dbConnection.AllowRequestRecordArray))
+	assert.Equal(t, 0, len(dbConnection.DeleteExpiredRateLimitsRecordArray))
+	assert.Equal(t, "login:192.0.2.1:someUser", dbConnection.AllowRequestRecordArray[0].Identifier)
+	assert.Equal(t, 1.0, dbConnection.AllowRequestRecordArray[0].Rate)
+	assert.Equal(t, 5, dbConnection.AllowRequestRecordArray[0].Burst)
+}
+
+func TestRateLimiterStoreAllow_Denied(t *testing.T) {
+	dbConnection := &DBMock{AllowRequestResponseArray: []*AllowRequestResponse{{Allowed: false}}}
+	store := NewRateLimiterStore(dbConnection, "login", 1, 5, time.Minute)
+
+	allowed, err := store.Allow("login:192.0.2.1:someUser")
+
+	assert.Nil(t, err)
+	assert.False(t, allowed)
+}
+
+func TestRateLimiterStoreAllow_UnknownError(t *testing.T) {
+	dbConnection := &DBMock{AllowRequestResponseArray: []*AllowRequestResponse{{Err: errUnknown}}}
+	store := NewRateLimiterStore(dbConnection, "login", 1, 5, time.Minute)
+
+	allowed, err := store.Allow("login:192.0.2.1:someUser")
+
+	assert.ErrorIs(t, err, errUnknown)
+	assert.False(t, allowed)
+}
+
+func TestRateLimiterStoreAllow_CleanupExpired(t *testing.T) {
+	dbConnection := &DBMock{AllowRequestResponseArray: []*AllowRequestResponse{{Allowed: true}, {Allowed: true}}, DeleteExpiredRateLimitsResponseArray: []*ErrorResponse{{Err: nil}}}
+	store := NewRateLimiterStore(dbConnection, "login", 1, 5, time.Minute)
+	store.lastCleanup = time.Now().Add(-2 * time.Minute)
+
+	_, err := store.Allow("login:192.0.2.1:someUser")
+	assert.Nil(t, err)
+	_, err = store.Allow("login:192.0.2.1:someUser")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(dbConnection.AllowRequestRecordArray))
+	assert.Equal(t, 1, len(dbConnection.DeleteExpiredRateLimitsRecordArray))
+	assert.Equal(t, "login", dbConnection.DeleteExpiredRateLimitsRecordArray[0].Route)
+	assert.WithinDuration(t, time.Now().Add(-time.Minute), dbConnection.DeleteExpiredRateLimitsRecordArray[0].ExpireBefore, time.Second)
+}
+
+func TestRateLimiterStoreAllow_CleanupOnlyOwnRoute(t *testing.T) {
+	dbConnection := &DBMock{AllowRequestResponseArray: []*AllowRequestResponse{{Allowed: true}, {Allowed: true}}, DeleteExpiredRateLimitsResponseArray: []*ErrorResponse{{Err: nil}, {Err: nil}}}
+	defaultStore := NewRateLimiterStore(dbConnection, "default", 1, 5, time.Minute)
+	defaultStore.lastCleanup = time.Now().Add(-2 * time.Minute)
+	loginStore := NewRateLimiterStore(dbConnection, "login", 0.1, 10, 100*time.Second)
+	loginStore.lastCleanup = time.Now().Add(-2 * time.Minute)
+
+	_, err := defaultStore.Allow("default:192.0.2.1:someUser")
+	assert.Nil(t, err)
+	_, err = loginStore.Allow("login:192.0.2.1:someUser")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 2, len(dbConnection.DeleteExpiredRateLimitsRecordArray))
+	assert.Equal(t, "default", dbConnection.DeleteExpiredRateLimitsRecordArray[0].Route)
+	assert.WithinDuration(t, time.Now().Add(-time.Minute), dbConnection.DeleteExpiredRateLimitsRecordArray[0].ExpireBefore, time.Second)
+	assert.Equal(t, "login", dbConnection.DeleteExpiredRateLimitsRecordArray[1].Route)
+	assert.WithinDuration(t, time.Now().Add(-100*time.Second), dbConnection.DeleteExpiredRateLimitsRecordArray[1].ExpireBefore, time.Second)
+}
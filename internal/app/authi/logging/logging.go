@@ -0,0 +1,112 @@
+// Package to set up the structured logging of authi
+package logging
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Replacement of every sensitive value in log entries
+	Redacted = "[REDACTED]"
+
+	// Keys of the fields in request and access log entries
+	MethodField        = "method"
+	RouteField         = "route"
+	StatusField        = "status"
+	LatencyField       = "latency_ms"
+	UserIdField        = "user_id"
+	CorrelationIdField = "correlation_id"
+	RemoteIpField      = "remote_ip"
+	UserAgentField     = "user_agent"
+)
+
+var (
+	// Parts of field keys whose values are never written to the log
+	sensitiveKeys = []string{"password", "token", "secret", "authorization", "salt", "hash"}
+
+	// Sensitive values inside of log messages, the first group is kept
+	sensitivePatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(bearer\s+)[^\s"',]+`),
+		regexp.MustCompile(`()eyJ[\w-]+\.[\w-]+\.[\w-]*`),
+		regexp.MustCompile(`(?i)("[\w-]*(?:password|token|secret|salt)[\w-]*"\s*:\s*")[^"]*`),
+		regexp.MustCompile(`(?i)(\b[\w-]*(?:password|token|secret|salt)[\w-]*\s*=\s*)[^\s"',]+`),
+	}
+)
+
+type (
+	// Formatter that removes passwords and tokens before the entry is formatted by the wrapped formatter
+	redactingFormatter struct {
+		formatter log.Formatter
+	}
+)
+
+// Sets level and format of the standard logger. Every entry is redacted before it is written
+func Init(logLevel string, logFormat string) {
+	switch strings.ToLower(logLevel) {
+	case "debug":
+		log.SetLevel(log.DebugLevel)
+	case "info":
+		log.SetLevel(log.InfoLevel)
+	case "warn", "warning":
+		log.SetLevel(log.WarnLevel)
+	}
+	log.SetFormatter(NewFormatter(logFormat))
+}
+
+// Logger with the correlation id of ctx, so entries of one request can be found together
+func FromContext(ctx context.Context) *log.Entry {
+	if correlationId := adapter.CorrelationIdFromContext(ctx); correlationId != "" {
+		return log.WithField(CorrelationIdField, correlationId)
+	}
+	return log.NewEntry(log.StandardLogger())
+}
+
+// Creates the redacting formatter for the given format. Unknown formats are written as text
+func NewFormatter(logFormat string) log.Formatter {
+	if strings.ToLower(logFormat) == config.LogFormatJson {
+		return &redactingFormatter{formatter: &log.JSONFormatter{}}
+	}
+	return &redactingFormatter{formatter: &log.TextFormatter{FullTimestamp: true}}
+}
+
+func (formatter *redactingFormatter) Format(entry *log.Entry) ([]byte, error) {
+	redactedEntry := entry.Dup()
+	redactedEntry.Level = entry.Level
+	redactedEntry.Caller = entry.Caller
+	redactedEntry.Buffer = entry.Buffer
+	redactedEntry.Message = Redact(entry.Message)
+	for key, value := range redactedEntry.Data {
+		if isSensitiveKey(key) {
+			redactedEntry.Data[key] = Redacted
+			continue
+		}
+		if text, ok := value.(string); ok {
+			redactedEntry.Data[key] = Redact(text)
+		}
+	}
+	return formatter.formatter.Format(redactedEntry)
+}
+
+// Replaces passwords and tokens in the text
+func Redact(text string) string {
+	for _, pattern := range sensitivePatterns {
+		text = pattern.ReplaceAllString(text, "${1}"+Redacted)
+	}
+	return text
+}
+
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
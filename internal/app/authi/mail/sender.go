@@ -0,0 +1,129 @@
+// Package to send mails to users
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// Header values must not contain line breaks, otherwise further headers could be injected
+	errInvalidHeader = errors.New("mail header contains line break")
+)
+
+type (
+	// Plain text mail to a single recipient
+	Message struct {
+		To      string
+		Subject string
+		Body    string
+	}
+
+	Sender interface {
+		Send(ctx context.Context, message *Message) error
+	}
+
+	// Writes mails to the log. Only meant for development, because the mails may contain tokens
+	logSender struct {
+		from string
+	}
+
+	// Appends mails to a file, e.g. to read them in tests
+	fileSender struct {
+		from  string
+		path  string
+		mutex sync.Mutex
+	}
+
+	smtpSender struct {
+		from    string
+		address string
+		auth    smtp.Auth
+	}
+)
+
+func NewSender(mailConfig *config.MailConfig) (Sender, error) {
+	switch strings.ToLower(mailConfig.Sender) {
+	case config.MailSenderLog:
+		return &logSender{from: mailConfig.From}, nil
+	case config.MailSenderFile:
+		return &fileSender{from: mailConfig.From, path: mailConfig.FilePath}, nil
+	case config.MailSenderSmtp:
+		var auth smtp.Auth
+		if mailConfig.Smtp.User != "" {
+			auth = smtp.PlainAuth("", mailConfig.Smtp.User, mailConfig.Smtp.Password, mailConfig.Smtp.Host)
+		}
+		address := net.JoinHostPort(mailConfig.Smtp.Host, strconv.Itoa(mailConfig.Smtp.Port))
+		return &smtpSender{from: mailConfig.From, address: address, auth: auth}, nil
+	default:
+		return nil, fmt.Errorf("no mail sender %s found", mailConfig.Sender)
+	}
+}
+
+// Formats the message with the headers of a plain text mail and line breaks as required by SMTP
+func formatMessage(from string, message *Message, date time.Time) ([]byte, error) {
+	for _, header := range []string{from, message.To, message.Subject} {
+		if strings.ContainsAny(header, "\r\n") {
+			return nil, errInvalidHeader
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString("From: " + from + "\r\n")
+	builder.WriteString("To: " + message.To + "\r\n")
+	builder.WriteString("Subject: " + message.Subject + "\r\n")
+	builder.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	builder.WriteString("MIME-Version: 1.0\r\n")
+	builder.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	builder.WriteString("\r\n")
+	builder.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	builder.WriteString("\r\n")
+	return []byte(builder.String()), nil
+}
+
+func (sender *logSender) Send(ctx context.Context, message *Message) error {
+	logging.FromContext(ctx).WithFields(log.Fields{"from": sender.from, "to": message.To, "subject": message.Subject}).Infof("Mail not sent, because the log sender is configured:\n%s", message.Body)
+	return nil
+}
+
+func (sender *fileSender) Send(ctx context.Context, message *Message) error {
+	data, err := formatMessage(sender.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+	file, err := os.OpenFile(sender.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error while opening mail file: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, "\r\n"...)); err != nil {
+		return fmt.Errorf("error while writing mail to file: %v", err)
+	}
+	return nil
+}
+
+func (sender *smtpSender) Send(ctx context.Context, message *Message) error {
+	data, err := formatMessage(sender.from, message, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := smtp.SendMail(sender.address, sender.auth, sender.from, []string{message.To}, data); err != nil {
+		return fmt.Errorf("error while sending mail via smtp: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"context"
+)
+
+type (
+	SendRecord struct {
+		Message *Message
+	}
+
+	SendResponse struct {
+		Err error
+	}
+
+	SenderMock struct {
+		SendRecordArray   []*SendRecord
+		SendResponseArray []*SendResponse
+	}
+)
+
+func (mock *SenderMock) Send(ctx context.Context, message *Message) error {
+	record := &SendRecord{Message: message}
+	mock.SendRecordArray = append(mock.SendRecordArray, record)
+	response := mock.SendResponseArray[len(mock.SendRecordArray)-1]
+	return response.Err
+}
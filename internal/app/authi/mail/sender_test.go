@@ -0,0 +1,122 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	message = &Message{To: "max@example.org", Subject: "Verify your email", Body: "Hello\nyour token is someToken"}
+)
+
+func TestNewSender_UnknownSender(t *testing.T) {
+	sender, err := NewSender(&config.MailConfig{Sender: "pigeon"})
+
+	assert.Nil(t, sender)
+	assert.ErrorContains(t, err, "no mail sender pigeon found")
+}
+
+func TestFormatMessage(t *testing.T) {
+	date := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := formatMessage("authi@localhost", message, date)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "From: authi@localhost\r\nTo: max@example.org\r\nSubject: Verify your email\r\nDate: Wed, 01 May 2024 12:00:00 +0000\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\nHello\r\nyour token is someToken\r\n", string(data))
+}
+
+func TestFormatMessage_HeaderInjection(t *testing.T) {
+	_, err := formatMessage("authi@localhost", &Message{To: "max@example.org\r\nBcc: eve@example.org", Subject: "Hello"}, time.Now())
+
+	assert.ErrorIs(t, err, errInvalidHeader)
+}
+
+func TestFileSender_Send(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mails.txt")
+	sender, err := NewSender(&config.MailConfig{Sender: config.MailSenderFile, From: "authi@localhost", FilePath: path})
+	assert.Nil(t, err)
+
+	assert.Nil(t, sender.Send(context.Background(), message))
+	assert.Nil(t, sender.Send(context.Background(), message))
+
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(string(data), "To: max@example.org"))
+	assert.Contains(t, string(data), "your token is someToken")
+}
+
+func TestLogSender_Send(t *testing.T) {
+	sender, err := NewSender(&config.MailConfig{Sender: config.MailSenderLog, From: "authi@localhost"})
+	assert.Nil(t, err)
+
+	assert.Nil(t, sender.Send(context.Background(), message))
+}
+
+func TestSmtpSender_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+	received := make(chan []string, 1)
+	go serveSmtp(listener, received)
+
+	address := listener.Addr().(*net.TCPAddr)
+	sender, err := NewSender(&config.MailConfig{Sender: config.MailSenderSmtp, From: "authi@localhost", Smtp: config.SmtpConfig{Host: "127.0.0.1", Port: address.Port}})
+	assert.Nil(t, err)
+
+	assert.Nil(t, sender.Send(context.Background(), message))
+
+	commands := <-received
+	assert.Contains(t, commands, "MAIL FROM:<authi@localhost> BODY=8BITMIME")
+	assert.Contains(t, commands, "RCPT TO:<max@example.org>")
+	assert.Contains(t, commands, "Subject: Verify your email")
+	assert.Contains(t, commands, "your token is someToken")
+}
+
+// Minimal smtp server that accepts one mail and reports all received lines
+func serveSmtp(listener net.Listener, received chan<- []string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var lines []string
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("220 localhost ESMTP\r\n"))
+	inData := false
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+		switch {
+		case inData && line == ".":
+			inData = false
+			conn.Write([]byte("250 OK\r\n"))
+		case inData:
+		case strings.HasPrefix(line, "EHLO"):
+			conn.Write([]byte("250-localhost\r\n250 8BITMIME\r\n"))
+		case line == "DATA":
+			inData = true
+			conn.Write([]byte("354 Go ahead\r\n"))
+		case line == "QUIT":
+			conn.Write([]byte("221 Bye\r\n"))
+			received <- lines
+			return
+		default:
+			conn.Write([]byte("250 OK\r\n"))
+		}
+	}
+	received <- lines
+}
@@ -0,0 +1,180 @@
+// Package to collect prometheus metrics of authi
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "authi"
+
+	resultSuccess = "success"
+	resultFailure = "failure"
+)
+
+type (
+	// Source of the connection pool statistics, usually the database connection
+	PoolStatsProvider interface {
+		Stats() *db.PoolStats
+	}
+
+	poolCollector struct {
+		provider             PoolStatsProvider
+		acquiredConns        *prometheus.Desc
+		idleConns            *prometheus.Desc
+		totalConns           *prometheus.Desc
+		maxConns             *prometheus.Desc
+		acquireCount         *prometheus.Desc
+		canceledAcquireCount *prometheus.Desc
+		emptyAcquireCount    *prometheus.Desc
+		acquireDuration      *prometheus.Desc
+	}
+)
+
+var (
+	// Registry with all metrics of authi
+	Registry = prometheus.NewRegistry()
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Number of handled http requests per method, route and status",
+	}, []string{"method", "route", "status"})
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Duration of http requests per method, route and status",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	loginsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Number of logins per result",
+	}, []string{"result"})
+	refreshesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "token_refreshes_total",
+		Help:      "Number of token refreshes per result",
+	}, []string{"result"})
+	tokensIssuedTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "tokens_issued_total",
+		Help:      "Number of issued token pairs",
+	})
+	rateLimitDeniedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_denied_total",
+		Help:      "Number of requests denied by the rate limiter per route",
+	}, []string{"route"})
+	webhookDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_deliveries_total",
+		Help:      "Number of webhook delivery attempts per event and result",
+	}, []string{"event", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		requestsTotal,
+		requestDuration,
+		loginsTotal,
+		refreshesTotal,
+		tokensIssuedTotal,
+		rateLimitDeniedTotal,
+		webhookDeliveriesTotal,
+	)
+}
+
+// Http handler to expose all metrics of the registry
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+func ObserveRequest(method string, route string, status int, duration time.Duration) {
+	statusLabel := strconv.Itoa(status)
+	requestsTotal.WithLabelValues(method, route, statusLabel).Inc()
+	requestDuration.WithLabelValues(method, route, statusLabel).Observe(duration.Seconds())
+}
+
+func CountLogin(success bool) {
+	loginsTotal.WithLabelValues(result(success)).Inc()
+}
+
+func CountRefresh(success bool) {
+	refreshesTotal.WithLabelValues(result(success)).Inc()
+}
+
+func CountTokenIssued() {
+	tokensIssuedTotal.Inc()
+}
+
+func CountRateLimitDenied(route string) {
+	rateLimitDeniedTotal.WithLabelValues(route).Inc()
+}
+
+func CountWebhookDelivery(event string, success bool) {
+	webhookDeliveriesTotal.WithLabelValues(event, result(success)).Inc()
+}
+
+func result(success bool) string {
+	if success {
+		return resultSuccess
+	}
+	return resultFailure
+}
+
+// Registers the statistics of the connection pool. Registering a second pool replaces the first one
+func RegisterPool(provider PoolStatsProvider) {
+	collector := newPoolCollector(provider)
+	Registry.Unregister(collector)
+	Registry.MustRegister(collector)
+}
+
+func newPoolCollector(provider PoolStatsProvider) *poolCollector {
+	desc := func(name string, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		provider:             provider,
+		acquiredConns:        desc("acquired_connections", "Number of currently acquired connections"),
+		idleConns:            desc("idle_connections", "Number of currently idle connections"),
+		totalConns:           desc("total_connections", "Number of all connections in the pool"),
+		maxConns:             desc("max_connections", "Max size of the pool"),
+		acquireCount:         desc("acquires_total", "Number of successful acquires from the pool"),
+		canceledAcquireCount: desc("canceled_acquires_total", "Number of acquires that were canceled"),
+		emptyAcquireCount:    desc("empty_acquires_total", "Number of acquires that had to wait for a connection"),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Total time spent acquiring connections"),
+	}
+}
+
+func (collector *poolCollector) Describe(descs chan<- *prometheus.Desc) {
+	descs <- collector.acquiredConns
+	descs <- collector.idleConns
+	descs <- collector.totalConns
+	descs <- collector.maxConns
+	descs <- collector.acquireCount
+	descs <- collector.canceledAcquireCount
+	descs <- collector.emptyAcquireCount
+	descs <- collector.acquireDuration
+}
+
+func (collector *poolCollector) Collect(metrics chan<- prometheus.Metric) {
+	stats := collector.provider.Stats()
+	metrics <- prometheus.MustNewConstMetric(collector.acquiredConns, prometheus.GaugeValue, float64(stats.AcquiredConns))
+	metrics <- prometheus.MustNewConstMetric(collector.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	metrics <- prometheus.MustNewConstMetric(collector.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	metrics <- prometheus.MustNewConstMetric(collector.maxConns, prometheus.GaugeValue, float64(stats.MaxConns))
+	metrics <- prometheus.MustNewConstMetric(collector.acquireCount, prometheus.CounterValue, float64(stats.AcquireCount))
+	metrics <- prometheus.MustNewConstMetric(collector.canceledAcquireCount, prometheus.CounterValue, float64(stats.CanceledAcquireCount))
+	metrics <- prometheus.MustNewConstMetric(collector.emptyAcquireCount, prometheus.CounterValue, float64(stats.EmptyAcquireCount))
+	metrics <- prometheus.MustNewConstMetric(collector.acquireDuration, prometheus.CounterValue, stats.AcquireDuration.Seconds())
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountLogin(t *testing.T) {
+	successBefore := testutil.ToFloat64(loginsTotal.WithLabelValues(resultSuccess))
+	failureBefore := testutil.ToFloat64(loginsTotal.WithLabelValues(resultFailure))
+
+	CountLogin(true)
+	CountLogin(false)
+	CountLogin(false)
+
+	assert.Equal(t, successBefore+1, testutil.ToFloat64(loginsTotal.WithLabelValues(resultSuccess)))
+	assert.Equal(t, failureBefore+2, testutil.ToFloat64(loginsTotal.WithLabelValues(resultFailure)))
+}
+
+func TestObserveRequest(t *testing.T) {
+	before := testutil.ToFloat64(requestsTotal.WithLabelValues("POST", "/user/:userId/login", "200"))
+
+	ObserveRequest("POST", "/user/:userId/login", 200, time.Millisecond)
+
+	assert.Equal(t, before+1, testutil.ToFloat64(requestsTotal.WithLabelValues("POST", "/user/:userId/login", "200")))
+}
+
+func TestRegisterPool(t *testing.T) {
+	stats := &db.PoolStats{AcquiredConns: 1, IdleConns: 2, TotalConns: 3, MaxConns: 4, AcquireCount: 5, AcquireDuration: 2 * time.Second}
+	dbConnection := &db.DBMock{StatsResponseArray: []*db.PoolStats{stats, stats}}
+
+	RegisterPool(&db.DBMock{})
+	RegisterPool(dbConnection)
+	metricFamilies, err := Registry.Gather()
+	assert.Nil(t, err)
+
+	values := make(map[string]float64)
+	for _, metricFamily := range metricFamilies {
+		for _, metric := range metricFamily.GetMetric() {
+			if metric.GetGauge() != nil {
+				values[metricFamily.GetName()] = metric.GetGauge().GetValue()
+			}
+			if metric.GetCounter() != nil {
+				values[metricFamily.GetName()] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(3), values["authi_db_pool_total_connections"])
+	assert.Equal(t, float64(4), values["authi_db_pool_max_connections"])
+	assert.Equal(t, float64(5), values["authi_db_pool_acquires_total"])
+	assert.Equal(t, float64(2), values["authi_db_pool_acquire_duration_seconds_total"])
+	assert.Equal(t, 1, len(dbConnection.StatsRecordArray))
+}
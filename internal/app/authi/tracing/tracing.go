@@ -0,0 +1,85 @@
+// Package to set up OpenTelemetry tracing of authi
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "github.com/BeanCodeDe/authi"
+)
+
+// Tracer that is used for all spans of authi. Without Init spans are not recorded
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Sets up W3C trace context propagation and the tracer provider with the configured exporter.
+// The returned function flushes all remaining spans and has to be called on shutdown
+func Init(tracingConfig *config.TracingConfig) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, err := newExporter(tracingConfig)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	tracerProvider := NewTracerProvider(exporter, tracingConfig.ServiceName)
+	otel.SetTracerProvider(tracerProvider)
+	return tracerProvider.Shutdown, nil
+}
+
+// Creates a tracer provider that sends all spans to the exporter. With the stdout exporter spans can be checked in tests
+func NewTracerProvider(exporter sdktrace.SpanExporter, serviceName string) *sdktrace.TracerProvider {
+	return sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(semconv.ServiceName(serviceName))),
+	)
+}
+
+// Creates a stdout exporter that writes all spans as json to the writer
+func NewStdoutExporter(writer io.Writer) (sdktrace.SpanExporter, error) {
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(writer))
+	if err != nil {
+		return nil, fmt.Errorf("error while creating stdout exporter: %w", err)
+	}
+	return exporter, nil
+}
+
+func newExporter(tracingConfig *config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch strings.ToLower(tracingConfig.Exporter) {
+	case config.TracingExporterNone:
+		return nil, nil
+	case config.TracingExporterStdout:
+		return NewStdoutExporter(os.Stdout)
+	case config.TracingExporterOtlp:
+		options := []otlptracehttp.Option{otlptracehttp.WithEndpoint(tracingConfig.Endpoint)}
+		if tracingConfig.Insecure {
+			options = append(options, otlptracehttp.WithInsecure())
+		}
+		exporter, err := otlptracehttp.New(context.Background(), options...)
+		if err != nil {
+			return nil, fmt.Errorf("error while creating otlp exporter: %w", err)
+		}
+		return exporter, nil
+	default:
+		return nil, fmt.Errorf("no tracing exporter %s found", tracingConfig.Exporter)
+	}
+}
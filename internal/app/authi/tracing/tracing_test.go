@@ -0,0 +1,40 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+)
+
+func TestInit_None(t *testing.T) {
+	shutdown, err := Init(&config.TracingConfig{Exporter: config.TracingExporterNone})
+
+	assert.Nil(t, err)
+	assert.Nil(t, shutdown(context.Background()))
+	assert.Contains(t, otel.GetTextMapPropagator().Fields(), "traceparent")
+}
+
+func TestInit_UnknownExporter(t *testing.T) {
+	shutdown, err := Init(&config.TracingConfig{Exporter: "zipkin"})
+
+	assert.Nil(t, shutdown)
+	assert.ErrorContains(t, err, "zipkin")
+}
+
+func TestNewTracerProvider_Stdout(t *testing.T) {
+	var output bytes.Buffer
+	exporter, err := NewStdoutExporter(&output)
+	assert.Nil(t, err)
+	tracerProvider := NewTracerProvider(exporter, "authi-test")
+
+	_, span := tracerProvider.Tracer(tracerName).Start(context.Background(), "some span")
+	span.End()
+	assert.Nil(t, tracerProvider.Shutdown(context.Background()))
+
+	assert.Contains(t, output.String(), `"Name":"some span"`)
+	assert.Contains(t, output.String(), "authi-test")
+}
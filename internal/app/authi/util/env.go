@@ -4,6 +4,12 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+)
+
+const (
+	// Suffix of environment variables that point to a file containing the value, e.g. for docker or kubernetes secrets
+	FileEnvSuffix = "_FILE"
 )
 
 func GetEnvWithFallback(key, fallback string) string {
@@ -26,3 +32,36 @@ func GetEnvIntWithFallback(key string, fallback int) (int, error) {
 	}
 	return fallback, nil
 }
+
+func GetEnvFloatWithFallback(key string, fallback float64) (float64, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return strconv.ParseFloat(value, 64)
+	}
+	return fallback, nil
+}
+
+func GetEnvBoolWithFallback(key string, fallback bool) (bool, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return strconv.ParseBool(value)
+	}
+	return fallback, nil
+}
+
+// Reads the value from the file in environment variable key + "_FILE" if it is set, otherwise from environment variable key.
+// Setting both is an error, so a secret isn't silently taken from the wrong source
+func GetEnvOrFileWithFallback(key, fallback string) (string, error) {
+	fileKey := key + FileEnvSuffix
+	path, ok := os.LookupEnv(fileKey)
+	if !ok {
+		return GetEnvWithFallback(key, fallback), nil
+	}
+	if _, ok := os.LookupEnv(key); ok {
+		return "", fmt.Errorf("environment variable %s and %s can't be set both", key, fileKey)
+	}
+
+	value, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error while reading file from environment variable %s: %w", fileKey, err)
+	}
+	return strings.TrimRight(string(value), "\r\n"), nil
+}
@@ -1,6 +1,8 @@
 package util
 
 import (
+	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
 
@@ -73,3 +75,103 @@ func TestGetEnvIntWithFallback_WrongFormat(t *testing.T) {
 	assert.Equal(t, 0, value)
 	assert.ErrorContains(t, err, "invalid syntax")
 }
+
+func TestGetEnvFloatWithFallback_Successfully(t *testing.T) {
+	someEnv := "SOME_ENV"
+	t.Setenv(someEnv, "0.5")
+
+	value, err := GetEnvFloatWithFallback(someEnv, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, value)
+}
+
+func TestGetEnvFloatWithFallback_NotFound(t *testing.T) {
+	value, err := GetEnvFloatWithFallback("SOME_ENV", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10.0, value)
+}
+
+func TestGetEnvFloatWithFallback_WrongFormat(t *testing.T) {
+	someEnv := "SOME_ENV"
+	t.Setenv(someEnv, "half")
+
+	value, err := GetEnvFloatWithFallback(someEnv, 10)
+	assert.Equal(t, 0.0, value)
+	assert.ErrorContains(t, err, "invalid syntax")
+}
+
+func TestGetEnvBoolWithFallback_Successfully(t *testing.T) {
+	someEnv := "SOME_ENV"
+	someFallbackValue := false
+	t.Setenv(someEnv, "true")
+
+	value, err := GetEnvBoolWithFallback(someEnv, someFallbackValue)
+	assert.Nil(t, err)
+	assert.Equal(t, true, value)
+}
+
+func TestGetEnvBoolWithFallback_NotFound(t *testing.T) {
+	someEnv := "SOME_ENV"
+	someFallbackValue := true
+
+	value, err := GetEnvBoolWithFallback(someEnv, someFallbackValue)
+	assert.Nil(t, err)
+	assert.Equal(t, someFallbackValue, value)
+}
+
+func TestGetEnvBoolWithFallback_WrongFormat(t *testing.T) {
+	someEnv := "SOME_ENV"
+	someFallbackValue := true
+	t.Setenv(someEnv, "maybe")
+
+	value, err := GetEnvBoolWithFallback(someEnv, someFallbackValue)
+	assert.Equal(t, false, value)
+	assert.ErrorContains(t, err, "invalid syntax")
+}
+
+func TestGetEnvOrFileWithFallback_FromFile(t *testing.T) {
+	someEnv := "SOME_ENV"
+	someFile := filepath.Join(t.TempDir(), "secret")
+	assert.Nil(t, os.WriteFile(someFile, []byte("SOME VALUE\n"), 0600))
+	t.Setenv(someEnv+FileEnvSuffix, someFile)
+
+	value, err := GetEnvOrFileWithFallback(someEnv, "SOME FALLBACK")
+	assert.Nil(t, err)
+	assert.Equal(t, "SOME VALUE", value)
+}
+
+func TestGetEnvOrFileWithFallback_FromEnv(t *testing.T) {
+	someEnv := "SOME_ENV"
+	t.Setenv(someEnv, "SOME VALUE")
+
+	value, err := GetEnvOrFileWithFallback(someEnv, "SOME FALLBACK")
+	assert.Nil(t, err)
+	assert.Equal(t, "SOME VALUE", value)
+}
+
+func TestGetEnvOrFileWithFallback_NotFound(t *testing.T) {
+	someEnv := "SOME_ENV"
+
+	value, err := GetEnvOrFileWithFallback(someEnv, "SOME FALLBACK")
+	assert.Nil(t, err)
+	assert.Equal(t, "SOME FALLBACK", value)
+}
+
+func TestGetEnvOrFileWithFallback_BothSet(t *testing.T) {
+	someEnv := "SOME_ENV"
+	t.Setenv(someEnv, "SOME VALUE")
+	t.Setenv(someEnv+FileEnvSuffix, "some_file")
+
+	value, err := GetEnvOrFileWithFallback(someEnv, "SOME FALLBACK")
+	assert.Equal(t, "", value)
+	assert.ErrorContains(t, err, "can't be set both")
+}
+
+func TestGetEnvOrFileWithFallback_FileNotFound(t *testing.T) {
+	someEnv := "SOME_ENV"
+	t.Setenv(someEnv+FileEnvSuffix, filepath.Join(t.TempDir(), "some_random_file"))
+
+	value, err := GetEnvOrFileWithFallback(someEnv, "SOME FALLBACK")
+	assert.Equal(t, "", value)
+	assert.ErrorContains(t, err, someEnv+FileEnvSuffix)
+}
@@ -0,0 +1,220 @@
+// Package to deliver the webhooks of the outbox to the subscribers
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/internal/app/authi/metrics"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// Maximum number of webhooks delivered per poll
+	batchSize        = 100
+	firstRetryDelay  = 10 * time.Second
+	maxRetryDelay    = time.Hour
+	cleanupInterval  = time.Hour
+	maxErrorBodySize = 512
+	// Time on top of the timeout of a delivery until its lease expires, e.g. to mark the webhook as delivered
+	leaseMargin = 10 * time.Second
+)
+
+type (
+	// Polls the outbox for due webhooks and sends them signed to the subscribers. Failed deliveries are retried with exponential backoff
+	Dispatcher struct {
+		dbConnection db.Connection
+		client       *http.Client
+		secrets      map[string]string
+		maxAttempts  int
+		timeout      time.Duration
+		pollInterval time.Duration
+		retention    time.Duration
+		lastCleanup  time.Time
+		stop         chan struct{}
+		// Guards stop and the start of the polling, Start and Stop may run in different goroutines
+		mutex sync.Mutex
+		// Context of the deliveries, canceled when Stop gives up waiting for them
+		ctx    context.Context
+		cancel context.CancelFunc
+		done   sync.WaitGroup
+	}
+)
+
+func NewDispatcher(config *config.WebhookConfig, dbConnection db.Connection) *Dispatcher {
+	secrets := make(map[string]string, len(config.Subscriptions))
+	for _, subscription := range config.Subscriptions {
+		secrets[subscription.Url] = subscription.Secret
+	}
+	timeout := time.Duration(config.Timeout) * time.Second
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		dbConnection: dbConnection,
+		client:       &http.Client{Timeout: timeout},
+		secrets:      secrets,
+		maxAttempts:  config.MaxAttempts,
+		timeout:      timeout,
+		pollInterval: time.Duration(config.PollInterval) * time.Second,
+		retention:    time.Duration(config.Retention) * 24 * time.Hour,
+		stop:         make(chan struct{}),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Starts polling in the background until Stop is called. After Stop nothing is started
+func (dispatcher *Dispatcher) Start() {
+	dispatcher.mutex.Lock()
+	defer dispatcher.mutex.Unlock()
+	if dispatcher.stopped() {
+		return
+	}
+	ctx := dispatcher.ctx
+	dispatcher.done.Add(1)
+	go func() {
+		defer dispatcher.done.Done()
+		ticker := time.NewTicker(dispatcher.pollInterval)
+		defer ticker.Stop()
+		for {
+			dispatcher.poll(ctx)
+			select {
+			case <-dispatcher.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stops polling after the running delivery and waits for it until ctx is done, then the delivery is canceled.
+// Undelivered webhooks stay in the outbox and are sent after the next start. Stop can be called before Start and more than once
+func (dispatcher *Dispatcher) Stop(ctx context.Context) {
+	dispatcher.mutex.Lock()
+	if !dispatcher.stopped() {
+		close(dispatcher.stop)
+	}
+	dispatcher.mutex.Unlock()
+	stopped := make(chan struct{})
+	go func() {
+		dispatcher.done.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		dispatcher.cancel()
+		<-stopped
+	}
+	dispatcher.cancel()
+}
+
+func (dispatcher *Dispatcher) poll(ctx context.Context) {
+	// Webhooks are claimed one by one, so the lease only has to cover a single delivery. It keeps other instances from sending the same webhook
+	for i := 0; i < batchSize; i++ {
+		webhooks, err := dispatcher.dbConnection.ClaimWebhooks(ctx, 1, time.Now().Add(dispatcher.timeout+leaseMargin))
+		if err != nil {
+			log.Errorf("Error while claiming webhooks: %v", err)
+			break
+		}
+		if len(webhooks) == 0 {
+			break
+		}
+		dispatcher.deliver(ctx, webhooks[0])
+		if dispatcher.stopped() {
+			return
+		}
+	}
+
+	if time.Since(dispatcher.lastCleanup) >= cleanupInterval {
+		if err := dispatcher.dbConnection.DeleteDeliveredWebhooks(ctx, time.Now().Add(-dispatcher.retention)); err != nil {
+			log.Errorf("Error while deleting delivered webhooks: %v", err)
+			return
+		}
+		dispatcher.lastCleanup = time.Now()
+	}
+}
+
+func (dispatcher *Dispatcher) stopped() bool {
+	select {
+	case <-dispatcher.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+func (dispatcher *Dispatcher) deliver(ctx context.Context, webhook *db.WebhookOutboxDB) {
+	err := dispatcher.send(ctx, webhook)
+	if ctx.Err() != nil {
+		log.Warnf("Delivery of webhook %d was canceled, it is sent again once its lease expired", webhook.Id)
+		return
+	}
+	metrics.CountWebhookDelivery(webhook.EventType, err == nil)
+	if err == nil {
+		if err := dispatcher.dbConnection.MarkWebhookDelivered(ctx, webhook.Id); err != nil {
+			log.Errorf("Error while marking webhook %d as delivered: %v", webhook.Id, err)
+		}
+		return
+	}
+
+	attempts := webhook.Attempts + 1
+	var nextAttempt *time.Time
+	if attempts < dispatcher.maxAttempts {
+		next := time.Now().Add(retryDelay(attempts))
+		nextAttempt = &next
+		log.Warnf("Delivery of webhook %d to %s failed, attempt %d of %d: %v", webhook.Id, webhook.Url, attempts, dispatcher.maxAttempts, err)
+	} else {
+		log.Errorf("Delivery of webhook %d to %s failed, giving up after %d attempts: %v", webhook.Id, webhook.Url, attempts, err)
+	}
+	if err := dispatcher.dbConnection.MarkWebhookFailed(ctx, webhook.Id, nextAttempt, err.Error()); err != nil {
+		log.Errorf("Error while marking webhook %d as failed: %v", webhook.Id, err)
+	}
+}
+
+func (dispatcher *Dispatcher) send(ctx context.Context, webhook *db.WebhookOutboxDB) error {
+	secret, ok := dispatcher.secrets[webhook.Url]
+	if !ok {
+		return fmt.Errorf("no subscription configured for %s", webhook.Url)
+	}
+
+	body := []byte(webhook.Payload)
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.Url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error while creating request: %v", err)
+	}
+	timestamp := time.Now().Unix()
+	request.Header.Set("Content-Type", adapter.ContentTyp)
+	request.Header.Set(adapter.WebhookEventHeaderName, webhook.EventType)
+	request.Header.Set(adapter.WebhookDeliveryHeaderName, webhook.EventId.String())
+	request.Header.Set(adapter.WebhookTimestampHeaderName, strconv.FormatInt(timestamp, 10))
+	request.Header.Set(adapter.WebhookSignatureHeaderName, adapter.SignWebhook(secret, timestamp, body))
+
+	response, err := dispatcher.client.Do(request)
+	if err != nil {
+		return fmt.Errorf("error while sending request: %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		return fmt.Errorf("wrong status code %d: %s", response.StatusCode, message)
+	}
+	return nil
+}
+
+// Delay before the next attempt. It starts with firstRetryDelay and is doubled for every failed attempt up to maxRetryDelay
+func retryDelay(attempts int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryDelay)
+}
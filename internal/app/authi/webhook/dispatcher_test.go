@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/BeanCodeDe/authi/internal/app/authi/config"
+	"github.com/BeanCodeDe/authi/internal/app/authi/db"
+	"github.com/BeanCodeDe/authi/pkg/adapter"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	secret = "webhook-secret-1234"
+)
+
+func newTestDispatcher(url string, dbConnection db.Connection) *Dispatcher {
+	webhookConfig := &config.WebhookConfig{MaxAttempts: 3, Timeout: 1, PollInterval: 1, Retention: 7,
+		Subscriptions: []config.WebhookSubscription{{Url: url, Secret: secret, Events: adapter.WebhookEvents}}}
+	return NewDispatcher(webhookConfig, dbConnection)
+}
+
+func newOutboxEntry(url string, attempts int) *db.WebhookOutboxDB {
+	return &db.WebhookOutboxDB{Id: 42, EventId: uuid.New(), EventType: adapter.WebhookUserDeleted, Url: url, Payload: `{"type":"user.deleted"}`, Attempts: attempts}
+}
+
+func TestPoll_Delivered(t *testing.T) {
+	var received *http.Request
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		receivedBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	webhook := newOutboxEntry(receiver.URL, 0)
+	dbConnection := &db.DBMock{
+		ClaimWebhooksResponseArray:           []*db.ClaimWebhooksResponse{{Webhooks: []*db.WebhookOutboxDB{webhook}}, {}},
+		MarkWebhookDeliveredResponseArray:    []*db.ErrorResponse{{Err: nil}},
+		DeleteDeliveredWebhooksResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+
+	newTestDispatcher(receiver.URL, dbConnection).poll(context.Background())
+
+	assert.Equal(t, 1, len(dbConnection.MarkWebhookDeliveredRecordArray))
+	assert.Equal(t, int64(42), dbConnection.MarkWebhookDeliveredRecordArray[0].Id)
+	assert.Equal(t, 0, len(dbConnection.MarkWebhookFailedRecordArray))
+	assert.Equal(t, 1, len(dbConnection.DeleteDeliveredWebhooksRecordArray))
+	assert.Equal(t, 1, dbConnection.ClaimWebhooksRecordArray[0].Limit)
+	assert.WithinDuration(t, time.Now().Add(time.Second+leaseMargin), dbConnection.ClaimWebhooksRecordArray[0].LeaseUntil, time.Second)
+	assert.Equal(t, webhook.Payload, string(receivedBody))
+	assert.Equal(t, adapter.WebhookUserDeleted, received.Header.Get(adapter.WebhookEventHeaderName))
+	assert.Equal(t, webhook.EventId.String(), received.Header.Get(adapter.WebhookDeliveryHeaderName))
+	assert.NotEmpty(t, received.Header.Get(adapter.WebhookSignatureHeaderName))
+}
+
+func TestPoll_SignatureVerifiable(t *testing.T) {
+	var verifyErr error
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, verifyErr = adapter.VerifyWebhook(r, secret, time.Minute)
+	}))
+	defer receiver.Close()
+	dbConnection := &db.DBMock{
+		ClaimWebhooksResponseArray:           []*db.ClaimWebhooksResponse{{Webhooks: []*db.WebhookOutboxDB{newOutboxEntry(receiver.URL, 0)}}, {}},
+		MarkWebhookDeliveredResponseArray:    []*db.ErrorResponse{{Err: nil}},
+		DeleteDeliveredWebhooksResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+
+	newTestDispatcher(receiver.URL, dbConnection).poll(context.Background())
+
+	assert.Nil(t, verifyErr)
+}
+
+func TestPoll_FailedIsRetried(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	dbConnection := &db.DBMock{
+		ClaimWebhooksResponseArray:           []*db.ClaimWebhooksResponse{{Webhooks: []*db.WebhookOutboxDB{newOutboxEntry(receiver.URL, 1)}}, {}},
+		MarkWebhookFailedResponseArray:       []*db.ErrorResponse{{Err: nil}},
+		DeleteDeliveredWebhooksResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+
+	newTestDispatcher(receiver.URL, dbConnection).poll(context.Background())
+
+	assert.Equal(t, 0, len(dbConnection.MarkWebhookDeliveredRecordArray))
+	assert.Equal(t, 1, len(dbConnection.MarkWebhookFailedRecordArray))
+	assert.NotNil(t, dbConnection.MarkWebhookFailedRecordArray[0].NextAttempt)
+	assert.WithinDuration(t, time.Now().Add(20*time.Second), *dbConnection.MarkWebhookFailedRecordArray[0].NextAttempt, time.Second)
+	assert.Contains(t, dbConnection.MarkWebhookFailedRecordArray[0].LastError, "500")
+}
+
+func TestPoll_FailedIsGivenUp(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer receiver.Close()
+	dbConnection := &db.DBMock{
+		ClaimWebhooksResponseArray:           []*db.ClaimWebhooksResponse{{Webhooks: []*db.WebhookOutboxDB{newOutboxEntry(receiver.URL, 2)}}, {}},
+		MarkWebhookFailedResponseArray:       []*db.ErrorResponse{{Err: nil}},
+		DeleteDeliveredWebhooksResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+
+	newTestDispatcher(receiver.URL, dbConnection).poll(context.Background())
+
+	assert.Equal(t, 1, len(dbConnection.MarkWebhookFailedRecordArray))
+	assert.Nil(t, dbConnection.MarkWebhookFailedRecordArray[0].NextAttempt)
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 10*time.Second, retryDelay(1))
+	assert.Equal(t, 20*time.Second, retryDelay(2))
+	assert.Equal(t, 80*time.Second, retryDelay(4))
+	assert.Equal(t, time.Hour, retryDelay(20))
+}
+
+func TestStartAndStop(t *testing.T) {
+	dbConnection := &db.DBMock{
+		ClaimWebhooksResponseArray:           []*db.ClaimWebhooksResponse{{}},
+		DeleteDeliveredWebhooksResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+	dispatcher := newTestDispatcher("https://example.com/hook", dbConnection)
+
+	dispatcher.Start()
+	dispatcher.Stop(context.Background())
+
+	assert.Equal(t, 1, len(dbConnection.ClaimWebhooksRecordArray))
+}
+
+func TestStop_WithoutStart(t *testing.T) {
+	dbConnection := &db.DBMock{}
+	dispatcher := newTestDispatcher("https://example.com/hook", dbConnection)
+
+	dispatcher.Stop(context.Background())
+	dispatcher.Stop(context.Background())
+	dispatcher.Start()
+	dispatcher.Stop(context.Background())
+
+	assert.Equal(t, 0, len(dbConnection.ClaimWebhooksRecordArray))
+}
+
+func TestStop_CancelsDeliveryWhenContextIsDone(t *testing.T) {
+	delivering := make(chan struct{})
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(delivering)
+		<-release
+	}))
+	defer receiver.Close()
+	defer close(release)
+	dbConnection := &db.DBMock{
+		ClaimWebhooksResponseArray:           []*db.ClaimWebhooksResponse{{Webhooks: []*db.WebhookOutboxDB{newOutboxEntry(receiver.URL, 0)}}, {Webhooks: []*db.WebhookOutboxDB{newOutboxEntry(receiver.URL, 0)}}},
+		DeleteDeliveredWebhooksResponseArray: []*db.ErrorResponse{{Err: nil}},
+	}
+	dispatcher := newTestDispatcher(receiver.URL, dbConnection)
+	dispatcher.client.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	dispatcher.Start()
+	<-delivering
+	start := time.Now()
+	dispatcher.Stop(ctx)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 1, len(dbConnection.ClaimWebhooksRecordArray))
+	assert.Equal(t, 0, len(dbConnection.MarkWebhookFailedRecordArray))
+	assert.Equal(t, 0, len(dbConnection.MarkWebhookDeliveredRecordArray))
+}
@@ -2,9 +2,12 @@
 package adapter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/golang-jwt/jwt"
 	"github.com/google/uuid"
@@ -14,11 +17,21 @@ type (
 	//Interface for authentication
 	AuthAdapter interface {
 		RefreshToken(userId string, token string, refreshToken string) (*TokenResponseDTO, error)
+		RefreshTokenWithContext(ctx context.Context, userId string, token string, refreshToken string) (*TokenResponseDTO, error)
 		GetToken(userId string, password string) (*TokenResponseDTO, error)
+		GetTokenWithContext(ctx context.Context, userId string, password string) (*TokenResponseDTO, error)
+		GetTokenByIdentifier(identifier string, password string) (*TokenResponseDTO, error)
+		GetTokenByIdentifierWithContext(ctx context.Context, identifier string, password string) (*TokenResponseDTO, error)
+		UpdatePassword(userId string, token string, currentPassword string, newPassword string) (*TokenResponseDTO, error)
+		UpdatePasswordWithContext(ctx context.Context, userId string, token string, currentPassword string, newPassword string) (*TokenResponseDTO, error)
 	}
 	//Claim with data from the token
 	Claims struct {
-		UserId uuid.UUID `json:"user_id"`
+		UserId        uuid.UUID `json:"user_id"`
+		Roles         []string  `json:"roles,omitempty"`
+		EmailVerified bool      `json:"email_verified"`
+		// Methods the user authenticated with, e.g. pwd and otp
+		Amr []string `json:"amr,omitempty"`
 		jwt.StandardClaims
 	}
 	//Response with token data
@@ -28,12 +41,142 @@ type (
 		RefreshToken     string `json:"refresh_token"`
 		RefreshExpiresIn int    `json:"refresh_expires_in"`
 	}
+	//Entry of the login history of a user
+	LoginHistoryDTO struct {
+		LoginTime time.Time `json:"login_time"`
+		IP        string    `json:"ip"`
+		UserAgent string    `json:"user_agent"`
+		Success   bool      `json:"success"`
+	}
+	//Security relevant event of a user
+	AuditEventDTO struct {
+		Id            int64     `json:"id"`
+		EventTime     time.Time `json:"event_time"`
+		EventType     string    `json:"event_type"`
+		UserId        uuid.UUID `json:"user_id"`
+		Actor         string    `json:"actor"`
+		Success       bool      `json:"success"`
+		Details       string    `json:"details,omitempty"`
+		IP            string    `json:"ip,omitempty"`
+		UserAgent     string    `json:"user_agent,omitempty"`
+		CorrelationId string    `json:"correlation_id,omitempty"`
+	}
+	//User as seen by admins
+	UserDTO struct {
+		Id                uuid.UUID  `json:"id"`
+		CreatedOn         time.Time  `json:"created_on"`
+		LastLogin         time.Time  `json:"last_login"`
+		InitUser          bool       `json:"init_user"`
+		Username          string     `json:"username,omitempty"`
+		Email             string     `json:"email,omitempty"`
+		EmailVerified     bool       `json:"email_verified"`
+		Admin             bool       `json:"admin"`
+		Status            string     `json:"status"`
+		StatusReason      string     `json:"status_reason,omitempty"`
+		StatusChangedOn   *time.Time `json:"status_changed_on,omitempty"`
+		LockedUntil       *time.Time `json:"locked_until,omitempty"`
+		PasswordChangedAt time.Time  `json:"password_changed_at"`
+		MfaEnabled        bool       `json:"mfa_enabled"`
+	}
+	//Request object to change the status of a user
+	UserStatusDTO struct {
+		Status string `json:"status" validate:"required,oneof=active disabled locked pending"`
+		Reason string `json:"reason"`
+	}
+	//Status of authi and of each of its checks
+	HealthDTO struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks,omitempty"`
+	}
+	//Response if a new password violates the password policy, with a code for every violated rule
+	PasswordPolicyErrorDTO struct {
+		Message    string   `json:"message"`
+		Violations []string `json:"violations"`
+	}
+	//Response if the password of the user expired. The login answers with a token that only allows to change the password
+	PasswordExpiredDTO struct {
+		Message             string `json:"message"`
+		PasswordChangeToken string `json:"password_change_token,omitempty"`
+	}
+	//Response of a login with correct password if the user enabled mfa. The token has to be sent with a code to complete the login
+	MfaChallengeDTO struct {
+		MfaChallengeToken string   `json:"mfa_challenge_token"`
+		Methods           []string `json:"methods"`
+		ExpiresIn         int      `json:"expires_in"`
+	}
+	//Response of a totp enrollment. The uri is meant to be shown as qr code for authenticator apps
+	TotpEnrollmentDTO struct {
+		Secret string `json:"secret"`
+		Uri    string `json:"uri"`
+	}
+	//Response with the recovery codes of a user. The codes are only shown once and each of them can be used once instead of a totp code
+	RecoveryCodesDTO struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+	//Request object with a code of the authenticator app or a recovery code
+	MfaCodeDTO struct {
+		Code string `json:"code" validate:"required"`
+	}
+	//Request object to complete a login with the challenge token and a code of the authenticator app or a recovery code
+	MfaLoginDTO struct {
+		MfaChallengeToken string `json:"mfa_challenge_token" validate:"required"`
+		Code              string `json:"code" validate:"required"`
+	}
+	//Options for navigator.credentials.create or navigator.credentials.get. The challenge token has to be sent back with the response of the authenticator
+	PasskeyOptionsDTO struct {
+		PasskeyChallengeToken string          `json:"passkey_challenge_token"`
+		PublicKey             json.RawMessage `json:"public_key"`
+	}
+	//Request object with the challenge token of the options and the credential that the browser returned for them
+	PasskeyCredentialDTO struct {
+		PasskeyChallengeToken string          `json:"passkey_challenge_token" validate:"required"`
+		Credential            json.RawMessage `json:"credential" validate:"required"`
+	}
 	//Request object for authentication
 	AuthenticateDTO struct {
 		Password string `json:"password" validate:"required"`
 	}
+	//Request object to create a user with optional username and email to log in with
+	CreateUserDTO struct {
+		Password string `json:"password" validate:"required"`
+		Username string `json:"username,omitempty" validate:"omitempty,min=3,max=64,excludes=@"`
+		Email    string `json:"email,omitempty" validate:"omitempty,email,max=254"`
+	}
+	//Request object to confirm the email with the token of the verification mail
+	VerifyEmailDTO struct {
+		Token string `json:"token" validate:"required"`
+	}
+	//Request object to change the password with the current password of the user
+	UpdatePasswordDTO struct {
+		CurrentPassword string `json:"current_password" validate:"required"`
+		NewPassword     string `json:"new_password" validate:"required"`
+	}
+	//Request object to request a password reset mail for the user with the username or email
+	PasswordResetDTO struct {
+		Identifier string `json:"identifier" validate:"required"`
+	}
+	//Request object to set a new password with the token of a password reset mail
+	ConfirmPasswordResetDTO struct {
+		Token    string `json:"token" validate:"required"`
+		Password string `json:"password" validate:"required"`
+	}
+	//Request object to log in with username or email
+	LoginDTO struct {
+		Identifier string `json:"identifier" validate:"required"`
+		Password   string `json:"password" validate:"required"`
+	}
 )
 
+// Checks if the token was issued with the role
+func (claims *Claims) HasRole(role string) bool {
+	return slices.Contains(claims.Roles, role)
+}
+
+// Checks if the user authenticated with the method, e.g. AmrOtp for logins with a second factor
+func (claims *Claims) HasAuthenticationMethod(method string) bool {
+	return slices.Contains(claims.Amr, method)
+}
+
 // Method to handle response with token
 func readTokenResponse(resp *http.Response) (*TokenResponseDTO, error) {
 	defer resp.Body.Close()
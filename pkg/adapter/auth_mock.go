@@ -1,5 +1,7 @@
 package adapter
 
+import "context"
+
 type (
 	RefreshTokenRecord struct {
 		UserId       string
@@ -12,6 +14,13 @@ type (
 		Password string
 	}
 
+	UpdatePasswordRecord struct {
+		UserId          string
+		Token           string
+		CurrentPassword string
+		NewPassword     string
+	}
+
 	TokenResponse struct {
 		TokenResponseDTO *TokenResponseDTO
 		Err              error
@@ -23,6 +32,9 @@ type (
 
 		GetTokenRecordArray   []*GetTokenRecord
 		GetTokenResponseArray []*TokenResponse
+
+		UpdatePasswordRecordArray   []*UpdatePasswordRecord
+		UpdatePasswordResponseArray []*TokenResponse
 	}
 )
 
@@ -40,3 +52,23 @@ func (mock *AdapterMock) GetToken(userId string, password string) (*TokenRespons
 	response := mock.GetTokenResponseArray[len(mock.GetTokenRecordArray)-1]
 	return response.TokenResponseDTO, response.Err
 }
+
+func (mock *AdapterMock) RefreshTokenWithContext(ctx context.Context, userId string, token string, refreshToken string) (*TokenResponseDTO, error) {
+	return mock.RefreshToken(userId, token, refreshToken)
+}
+
+func (mock *AdapterMock) GetTokenWithContext(ctx context.Context, userId string, password string) (*TokenResponseDTO, error) {
+	return mock.GetToken(userId, password)
+}
+
+func (mock *AdapterMock) UpdatePassword(userId string, token string, currentPassword string, newPassword string) (*TokenResponseDTO, error) {
+	updatePasswordRecord := &UpdatePasswordRecord{UserId: userId, Token: token, CurrentPassword: currentPassword, NewPassword: newPassword}
+	mock.UpdatePasswordRecordArray = append(mock.UpdatePasswordRecordArray, updatePasswordRecord)
+
+	response := mock.UpdatePasswordResponseArray[len(mock.UpdatePasswordRecordArray)-1]
+	return response.TokenResponseDTO, response.Err
+}
+
+func (mock *AdapterMock) UpdatePasswordWithContext(ctx context.Context, userId string, token string, currentPassword string, newPassword string) (*TokenResponseDTO, error) {
+	return mock.UpdatePassword(userId, token, currentPassword, newPassword)
+}
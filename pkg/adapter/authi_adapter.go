@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -9,6 +10,8 @@ import (
 	"os"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 const (
@@ -27,13 +30,101 @@ const (
 	AuthiLoginPath = "/login"
 	//Path to refresh api
 	AuthiRefreshPath = "/refresh"
+	//Path to login history api
+	AuthiLoginsPath = "/logins"
+	//Path to check if authi is running
+	AuthiHealthPath = "/healthz"
+	//Path to check if authi is ready to handle requests
+	AuthiReadyPath = "/readyz"
+	//Url of root path for admin apis
+	AuthiAdminPath = "/admin"
+	//Path to audit events api
+	AuthiAuditEventsPath = "/audit-events"
+	//Path to export data as json lines
+	AuthiExportPath = "/export"
+	//Path to users api for admins
+	AuthiUsersPath = "/users"
+	//Path to disable a user
+	AuthiDisablePath = "/disable"
+	//Path to enable a disabled user
+	AuthiEnablePath = "/enable"
+	//Path to change the status of a user
+	AuthiStatusPath = "/status"
+	//Path to revoke all sessions of a user
+	AuthiLogoutPath = "/logout"
+	//Path to set a new password of a user
+	AuthiPasswordPath = "/password"
+	//Path to request a verification mail for the email of a user
+	AuthiVerifyEmailPath = "/verify-email"
+	//Path to confirm a request with a token
+	AuthiConfirmPath = "/confirm"
+	//Path to request a password reset mail
+	AuthiPasswordResetPath = "/password-reset"
+	//Path to change an expired password with the password change token of the login
+	AuthiExpiredPasswordPath = "/expired-password"
+	//Path to manage the mfa of a user and to complete a login with mfa
+	AuthiMfaPath = "/mfa"
+	//Path to enroll, confirm and disable the totp of a user
+	AuthiTotpPath = "/totp"
+	//Path to regenerate the recovery codes of a user
+	AuthiRecoveryCodesPath = "/recovery-codes"
+	//Path to register passkeys of a user and to log in with a passkey
+	AuthiPasskeyPath = "/passkey"
+	//Name of header where the admin api key is stored
+	AdminApiKeyHeaderName = "X-Admin-Api-Key"
+	//Role of users that are allowed to use the admin apis
+	RoleAdmin = "admin"
+	//Status of users that can log in
+	UserStatusActive = "active"
+	//Status of users that were disabled by an admin
+	UserStatusDisabled = "disabled"
+	//Status of users that were locked, e.g. because of a security incident
+	UserStatusLocked = "locked"
+	//Status of users that aren't activated yet
+	UserStatusPending = "pending"
+	//Password is shorter than the min length of the policy
+	PasswordTooShort = "too_short"
+	//Password is longer than the max length of the policy
+	PasswordTooLong = "too_long"
+	//Password has no uppercase letter
+	PasswordMissingUppercase = "missing_uppercase"
+	//Password has no lowercase letter
+	PasswordMissingLowercase = "missing_lowercase"
+	//Password has no digit
+	PasswordMissingDigit = "missing_digit"
+	//Password has no character that is neither letter nor digit
+	PasswordMissingSpecial = "missing_special"
+	//Password contains the user id
+	PasswordContainsUserId = "contains_user_id"
+	//Password contains the username
+	PasswordContainsUsername = "contains_username"
+	//Password is contained in the list of breached passwords
+	PasswordBreached = "breached"
+	//Password is one of the last passwords of the user
+	PasswordReused = "reused"
+	//Authentication method of logins with password
+	AmrPassword = "pwd"
+	//Authentication method of logins with a one time password of an authenticator app
+	AmrOtp = "otp"
+	//Authentication method of logins with more than one factor
+	AmrMfa = "mfa"
+	//Authentication method of logins with a passkey
+	AmrHardwareKey = "hwk"
+	//Mfa method with time based one time passwords of an authenticator app
+	MfaMethodTotp = "totp"
+	//Status of a healthy check
+	HealthStatusUp = "up"
+	//Status of a failed check
+	HealthStatusDown = "down"
 
 	//Content type for AuthenticateDTO
-	ContentTyp    = "application/json; charset=utf-8"
-	correlationId = "X-Correlation-ID"
+	ContentTyp = "application/json; charset=utf-8"
 )
 
 var (
+	//All status a user can have
+	UserStatuses = []string{UserStatusActive, UserStatusDisabled, UserStatusLocked, UserStatusPending}
+
 	//Error that indicates, that the returned http status is not 200
 	errStatusNotOk = errors.New("status is no ok")
 	//Error that indicates, that the returned body can not be parsed into TokenResponseDTO
@@ -42,9 +133,11 @@ var (
 
 // Implementation of auth adapter to login and refresh token of user
 type AuthiAdapter struct {
-	authiRefreshUrl string
-	authiLoginUrl   string
-	correlationId   string
+	authiRefreshUrl           string
+	authiLoginUrl             string
+	authiLoginByIdentifierUrl string
+	authiUserUrl              string
+	correlationId             string
 }
 
 // Initialize auth adapter with public key and url to authi service.
@@ -53,21 +146,40 @@ func NewAuthiAdapter(correlationId string) AuthAdapter {
 	authUrl := os.Getenv(EnvAuthUrl)
 	authiRefreshUrl := authUrl + AuthiRootPath + "/%s" + AuthiRefreshPath
 	authiLoginUrl := authUrl + AuthiRootPath + "/%s" + AuthiLoginPath
-	return &AuthiAdapter{authiRefreshUrl: authiRefreshUrl, authiLoginUrl: authiLoginUrl, correlationId: correlationId}
+	authiLoginByIdentifierUrl := authUrl + AuthiLoginPath
+	authiUserUrl := authUrl + AuthiRootPath + "/%s"
+	return &AuthiAdapter{authiRefreshUrl: authiRefreshUrl, authiLoginUrl: authiLoginUrl, authiLoginByIdentifierUrl: authiLoginByIdentifierUrl, authiUserUrl: authiUserUrl, correlationId: correlationId}
 }
 
 // Get new token with refresh token from authi service
 func (authAdapter *AuthiAdapter) RefreshToken(userId string, token string, refreshToken string) (*TokenResponseDTO, error) {
+	return authAdapter.RefreshTokenWithContext(context.Background(), userId, token, refreshToken)
+}
+
+// Correlation id of the context, otherwise the id of the adapter. Without both a new id is generated
+func (authAdapter *AuthiAdapter) correlationIdOf(ctx context.Context) string {
+	if correlationId := CorrelationIdFromContext(ctx); correlationId != "" {
+		return correlationId
+	}
+	if authAdapter.correlationId != "" {
+		return authAdapter.correlationId
+	}
+	return uuid.NewString()
+}
+
+// Get new token with refresh token from authi service. The trace context and correlation id of ctx are sent along with the request
+func (authAdapter *AuthiAdapter) RefreshTokenWithContext(ctx context.Context, userId string, token string, refreshToken string) (*TokenResponseDTO, error) {
 	client := &http.Client{}
 
-	req, err := http.NewRequest(http.MethodPatch, fmt.Sprintf(authAdapter.authiRefreshUrl, userId), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, fmt.Sprintf(authAdapter.authiRefreshUrl, userId), nil)
 	if err != nil {
 		return nil, err
 	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	req.Header.Set(AuthorizationHeaderName, "Bearer "+token)
 	req.Header.Set(RefreshTokenHeaderName, refreshToken)
-	req.Header.Set(correlationId, uuid.NewString())
+	req.Header.Set(CorrelationIdHeader, authAdapter.correlationIdOf(ctx))
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -78,17 +190,51 @@ func (authAdapter *AuthiAdapter) RefreshToken(userId string, token string, refre
 
 // Login to get token
 func (authAdapter *AuthiAdapter) GetToken(userId string, password string) (*TokenResponseDTO, error) {
-	authenticateJson, err := json.Marshal(&AuthenticateDTO{Password: password})
+	return authAdapter.GetTokenWithContext(context.Background(), userId, password)
+}
+
+// Login to get token. The trace context and correlation id of ctx are sent along with the request
+func (authAdapter *AuthiAdapter) GetTokenWithContext(ctx context.Context, userId string, password string) (*TokenResponseDTO, error) {
+	return authAdapter.requestToken(ctx, http.MethodPost, fmt.Sprintf(authAdapter.authiLoginUrl, userId), "", &AuthenticateDTO{Password: password})
+}
+
+// Login with username or email to get token
+func (authAdapter *AuthiAdapter) GetTokenByIdentifier(identifier string, password string) (*TokenResponseDTO, error) {
+	return authAdapter.GetTokenByIdentifierWithContext(context.Background(), identifier, password)
+}
+
+// Login with username or email to get token. The trace context and correlation id of ctx are sent along with the request
+func (authAdapter *AuthiAdapter) GetTokenByIdentifierWithContext(ctx context.Context, identifier string, password string) (*TokenResponseDTO, error) {
+	return authAdapter.requestToken(ctx, http.MethodPost, authAdapter.authiLoginByIdentifierUrl, "", &LoginDTO{Identifier: identifier, Password: password})
+}
+
+// Change the password with the current password. All other sessions are revoked, so new token for this session are returned
+func (authAdapter *AuthiAdapter) UpdatePassword(userId string, token string, currentPassword string, newPassword string) (*TokenResponseDTO, error) {
+	return authAdapter.UpdatePasswordWithContext(context.Background(), userId, token, currentPassword, newPassword)
+}
+
+// Change the password with the current password. The trace context and correlation id of ctx are sent along with the request
+func (authAdapter *AuthiAdapter) UpdatePasswordWithContext(ctx context.Context, userId string, token string, currentPassword string, newPassword string) (*TokenResponseDTO, error) {
+	return authAdapter.requestToken(ctx, http.MethodPatch, fmt.Sprintf(authAdapter.authiUserUrl, userId), token, &UpdatePasswordDTO{CurrentPassword: currentPassword, NewPassword: newPassword})
+}
+
+// Sends the body as json and reads the token of the response. The access token is only sent if it is set
+func (authAdapter *AuthiAdapter) requestToken(ctx context.Context, method string, url string, token string, body any) (*TokenResponseDTO, error) {
+	bodyJson, err := json.Marshal(body)
 	if err != nil {
 		return nil, err
 	}
 
-	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf(authAdapter.authiLoginUrl, userId), bytes.NewBuffer(authenticateJson))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(bodyJson))
 	if err != nil {
 		return nil, err
 	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	req.Header.Set("Content-Type", ContentTyp)
-	req.Header.Set(correlationId, uuid.NewString())
+	if token != "" {
+		req.Header.Set(AuthorizationHeaderName, "Bearer "+token)
+	}
+	req.Header.Set(CorrelationIdHeader, authAdapter.correlationIdOf(ctx))
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
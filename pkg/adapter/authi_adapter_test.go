@@ -2,6 +2,7 @@ package adapter
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -9,6 +10,9 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestRefreshToken_Successfully(t *testing.T) {
@@ -105,6 +109,76 @@ func TestGetToken_Successfully(t *testing.T) {
 	assert.Equal(t, tokenResponse, result)
 }
 
+func TestGetTokenByIdentifier_Successfully(t *testing.T) {
+	tokenResponse := &TokenResponseDTO{AccessToken: "someToken"}
+	// Setup
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPost, req.Method)
+		assert.Equal(t, AuthiLoginPath, req.URL.Path)
+		assert.Equal(t, ContentTyp, req.Header.Get("Content-Type"))
+
+		login := new(LoginDTO)
+		assert.Nil(t, json.NewDecoder(req.Body).Decode(login))
+		assert.Equal(t, &LoginDTO{Identifier: "max@example.org", Password: "password"}, login)
+
+		tokenResponseJSON, err := json.Marshal(&tokenResponse)
+		assert.Nil(t, err)
+
+		res.Write(bytes.NewBuffer(tokenResponseJSON).Bytes())
+	}))
+	defer func() { testServer.Close() }()
+	authAdapter := getAuthiAdapter(testServer.URL)
+	// Exec
+	result, err := authAdapter.GetTokenByIdentifier("max@example.org", "password")
+
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, tokenResponse, result)
+}
+
+func TestUpdatePassword_Successfully(t *testing.T) {
+	userId := uuid.New()
+	tokenResponse := &TokenResponseDTO{AccessToken: "someNewToken"}
+	// Setup
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPatch, req.Method)
+		assert.Equal(t, AuthiRootPath+"/"+userId.String(), req.URL.Path)
+		assert.Equal(t, "Bearer someToken", req.Header.Get(AuthorizationHeaderName))
+
+		updatePassword := new(UpdatePasswordDTO)
+		assert.Nil(t, json.NewDecoder(req.Body).Decode(updatePassword))
+		assert.Equal(t, &UpdatePasswordDTO{CurrentPassword: "password", NewPassword: "newPassword"}, updatePassword)
+
+		tokenResponseJSON, err := json.Marshal(&tokenResponse)
+		assert.Nil(t, err)
+
+		res.Write(bytes.NewBuffer(tokenResponseJSON).Bytes())
+	}))
+	defer func() { testServer.Close() }()
+	authAdapter := getAuthiAdapter(testServer.URL)
+	// Exec
+	result, err := authAdapter.UpdatePassword(userId.String(), "someToken", "password", "newPassword")
+
+	// Assertions
+	assert.Nil(t, err)
+	assert.Equal(t, tokenResponse, result)
+}
+
+func TestUpdatePassword_WrongPassword(t *testing.T) {
+	// Setup
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer func() { testServer.Close() }()
+	authAdapter := getAuthiAdapter(testServer.URL)
+	// Exec
+	result, err := authAdapter.UpdatePassword(uuid.NewString(), "someToken", "wrongPassword", "newPassword")
+
+	// Assertions
+	assert.ErrorIs(t, err, errStatusNotOk)
+	assert.Nil(t, result)
+}
+
 func TestGetToken_ErrorWhileParsingPassword(t *testing.T) {
 	userId := uuid.New()
 	password := ""
@@ -162,8 +236,75 @@ func TestGetToken_ErrorWhileExecutingRequest(t *testing.T) {
 
 func getAuthiAdapter(url string) AuthAdapter {
 	authAdapter := &AuthiAdapter{
-		authiRefreshUrl: url + AuthiRootPath + "/%s" + AuthiRefreshPath,
-		authiLoginUrl:   url + AuthiRootPath + "/%s" + AuthiLoginPath,
+		authiRefreshUrl:           url + AuthiRootPath + "/%s" + AuthiRefreshPath,
+		authiLoginUrl:             url + AuthiRootPath + "/%s" + AuthiLoginPath,
+		authiLoginByIdentifierUrl: url + AuthiLoginPath,
+		authiUserUrl:              url + AuthiRootPath + "/%s",
 	}
 	return authAdapter
 }
+
+func TestGetTokenWithContext_SendsTraceContext(t *testing.T) {
+	userId := uuid.New()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(previousPropagator)
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceId, SpanID: spanId, TraceFlags: trace.FlagsSampled}))
+	// Setup
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", req.Header.Get("traceparent"))
+		res.Write([]byte("{}"))
+	}))
+	defer func() { testServer.Close() }()
+	authAdapter := getAuthiAdapter(testServer.URL)
+	// Exec
+	result, err := authAdapter.GetTokenWithContext(ctx, userId.String(), "somePassword")
+
+	// Assertions
+	assert.Nil(t, err)
+	assert.NotNil(t, result)
+}
+
+func TestRefreshTokenWithContext_SendsCorrelationIdOfContext(t *testing.T) {
+	userId := uuid.New()
+	correlationId := uuid.NewString()
+	ctx := ContextWithCorrelationId(context.Background(), correlationId)
+	// Setup
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, correlationId, req.Header.Get(CorrelationIdHeader))
+		res.Write([]byte("{}"))
+	}))
+	defer func() { testServer.Close() }()
+	authAdapter := &AuthiAdapter{
+		authiRefreshUrl: testServer.URL + AuthiRootPath + "/%s" + AuthiRefreshPath,
+		correlationId:   "someAdapterCorrelationId",
+	}
+	// Exec
+	result, err := authAdapter.RefreshTokenWithContext(ctx, userId.String(), "someToken", "someRefreshToken")
+
+	// Assertions
+	assert.Nil(t, err)
+	assert.NotNil(t, result)
+}
+
+func TestGetToken_SendsCorrelationIdOfAdapter(t *testing.T) {
+	userId := uuid.New()
+	// Setup
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "someAdapterCorrelationId", req.Header.Get(CorrelationIdHeader))
+		res.Write([]byte("{}"))
+	}))
+	defer func() { testServer.Close() }()
+	authAdapter := &AuthiAdapter{
+		authiLoginUrl: testServer.URL + AuthiRootPath + "/%s" + AuthiLoginPath,
+		correlationId: "someAdapterCorrelationId",
+	}
+	// Exec
+	result, err := authAdapter.GetToken(userId.String(), "somePassword")
+
+	// Assertions
+	assert.Nil(t, err)
+	assert.NotNil(t, result)
+}
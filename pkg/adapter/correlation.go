@@ -0,0 +1,25 @@
+package adapter
+
+import (
+	"context"
+)
+
+const (
+	// Name of header that carries the correlation id of a request
+	CorrelationIdHeader = "X-Correlation-ID"
+)
+
+type (
+	correlationIdKey struct{}
+)
+
+// Returns a copy of ctx that carries the correlation id. Calls of the AuthiAdapter with this context send the id along
+func ContextWithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	return context.WithValue(ctx, correlationIdKey{}, correlationId)
+}
+
+// Returns the correlation id of ctx or an empty string, if ctx carries no correlation id
+func CorrelationIdFromContext(ctx context.Context) string {
+	correlationId, _ := ctx.Value(correlationIdKey{}).(string)
+	return correlationId
+}
@@ -0,0 +1,94 @@
+package adapter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	//Event when a user was created
+	WebhookUserCreated = "user.created"
+	//Event when a user was deleted
+	WebhookUserDeleted = "user.deleted"
+	//Event when the password of a user was changed
+	WebhookPasswordChanged = "password.changed"
+	//Event when the sessions of a user were revoked
+	WebhookSessionRevoked = "session.revoked"
+
+	//Name of header with the type of the event
+	WebhookEventHeaderName = "X-Authi-Event"
+	//Name of header with the id of the event. Retries of a delivery have the same id
+	WebhookDeliveryHeaderName = "X-Authi-Delivery"
+	//Name of header with the unix time the request was signed at
+	WebhookTimestampHeaderName = "X-Authi-Timestamp"
+	//Name of header with the HMAC-SHA256 signature of timestamp and body
+	WebhookSignatureHeaderName = "X-Authi-Signature"
+
+	webhookSignaturePrefix = "sha256="
+)
+
+var (
+	//All events that can be subscribed
+	WebhookEvents = []string{WebhookUserCreated, WebhookUserDeleted, WebhookPasswordChanged, WebhookSessionRevoked}
+
+	//Error that indicates, that the signature of a webhook doesn't match
+	ErrWebhookSignature = errors.New("webhook signature is invalid")
+	//Error that indicates, that the webhook was signed outside of the tolerance
+	ErrWebhookExpired = errors.New("webhook timestamp is outside of tolerance")
+)
+
+type (
+	//Body of a webhook request
+	WebhookEventDTO struct {
+		Id         uuid.UUID `json:"id"`
+		Type       string    `json:"type"`
+		UserId     uuid.UUID `json:"user_id"`
+		OccurredAt time.Time `json:"occurred_at"`
+	}
+)
+
+// Signature of a webhook request. The HMAC-SHA256 is calculated over the timestamp, a dot and the body
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return webhookSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Checks the signature of a webhook request from authi and returns its event.
+// Requests that were signed longer ago than tolerance are rejected to prevent replays
+func VerifyWebhook(req *http.Request, secret string, tolerance time.Duration) (*WebhookEventDTO, error) {
+	timestamp, err := strconv.ParseInt(req.Header.Get(WebhookTimestampHeaderName), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookSignature, err)
+	}
+	if age := time.Since(time.Unix(timestamp, 0)); age > tolerance || age < -tolerance {
+		return nil, ErrWebhookExpired
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	signature := req.Header.Get(WebhookSignatureHeaderName)
+	if !strings.HasPrefix(signature, webhookSignaturePrefix) || !hmac.Equal([]byte(signature), []byte(SignWebhook(secret, timestamp, body))) {
+		return nil, ErrWebhookSignature
+	}
+
+	event := new(WebhookEventDTO)
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, err
+	}
+	return event, nil
+}
@@ -0,0 +1,58 @@
+package adapter
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	webhookSecret = "webhook-secret-1234"
+)
+
+func newWebhookRequest(secret string, timestamp int64, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/hook", bytes.NewBufferString(body))
+	req.Header.Set(WebhookTimestampHeaderName, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeaderName, SignWebhook(secret, timestamp, []byte(body)))
+	return req
+}
+
+func TestVerifyWebhook_Successfully(t *testing.T) {
+	userId := uuid.New()
+	body := `{"id":"` + uuid.NewString() + `","type":"user.created","user_id":"` + userId.String() + `","occurred_at":"2024-01-02T03:04:05Z"}`
+
+	event, err := VerifyWebhook(newWebhookRequest(webhookSecret, time.Now().Unix(), body), webhookSecret, time.Minute)
+
+	assert.Nil(t, err)
+	assert.Equal(t, WebhookUserCreated, event.Type)
+	assert.Equal(t, userId, event.UserId)
+}
+
+func TestVerifyWebhook_WrongSecret(t *testing.T) {
+	_, err := VerifyWebhook(newWebhookRequest("other-secret-1234", time.Now().Unix(), "{}"), webhookSecret, time.Minute)
+
+	assert.True(t, errors.Is(err, ErrWebhookSignature))
+}
+
+func TestVerifyWebhook_ChangedBody(t *testing.T) {
+	timestamp := time.Now().Unix()
+	req := newWebhookRequest(webhookSecret, timestamp, `{"type":"user.created"}`)
+	req.Body = httptest.NewRequest(http.MethodPost, "/hook", bytes.NewBufferString(`{"type":"user.deleted"}`)).Body
+
+	_, err := VerifyWebhook(req, webhookSecret, time.Minute)
+
+	assert.True(t, errors.Is(err, ErrWebhookSignature))
+}
+
+func TestVerifyWebhook_Expired(t *testing.T) {
+	_, err := VerifyWebhook(newWebhookRequest(webhookSecret, time.Now().Add(-time.Hour).Unix(), "{}"), webhookSecret, time.Minute)
+
+	assert.True(t, errors.Is(err, ErrWebhookExpired))
+}
@@ -37,8 +37,11 @@ type JWTParser struct {
 
 // Constructor to create jwt parser. public key path have to be set under environment variable PUBLIC_KEY_PATH
 func NewJWTParser() (Parser, error) {
-	publicKeyPath := util.GetEnvWithFallback(EnvPublicKeyPath, "/token/jwtRS256.key.pub")
+	return NewJWTParserWithKeyPath(util.GetEnvWithFallback(EnvPublicKeyPath, "/token/jwtRS256.key.pub"))
+}
 
+// Constructor to create jwt parser with the public key from the given path
+func NewJWTParserWithKeyPath(publicKeyPath string) (Parser, error) {
 	verifyBytes, err := os.ReadFile(publicKeyPath)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrWhileReadingKey, err)
@@ -71,5 +74,10 @@ func (parser *JWTParser) ParseToken(authorizationString string) (*adapter.Claims
 		return nil, fmt.Errorf("%w: %v", ErrTokenNotValid, err)
 	}
 
+	// Access tokens have no audience. Tokens with audience, like the ones of verification mails, must not grant access
+	if claims.Audience != "" {
+		return nil, fmt.Errorf("%w: token for audience %s is no access token", ErrTokenNotValid, claims.Audience)
+	}
+
 	return claims, nil
 }
@@ -1,8 +1,12 @@
 package parser
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"testing"
+	"time"
 
+	"github.com/BeanCodeDe/authi/pkg/adapter"
 	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
 )
@@ -65,3 +69,17 @@ func loadJWTParser(t *testing.T, pubKey string) *JWTParser {
 	}
 	return &JWTParser{verifyKey: publicKey}
 }
+
+func TestParseToken_ErrorWhenTokenHasAudience(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	claims := &adapter.Claims{StandardClaims: jwt.StandardClaims{Audience: "authi:email-verification", ExpiresAt: time.Now().Add(time.Minute).Unix()}}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(privateKey)
+	assert.Nil(t, err)
+
+	jwtParser := &JWTParser{verifyKey: &privateKey.PublicKey}
+	claim, err := jwtParser.ParseToken("Bearer " + token)
+
+	assert.Nil(t, claim)
+	assert.ErrorIs(t, err, ErrTokenNotValid)
+}
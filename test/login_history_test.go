@@ -0,0 +1,30 @@
+package test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/BeanCodeDe/authi/test/util"
+	"github.com/google/uuid"
+	"gopkg.in/go-playground/assert.v1"
+)
+
+func TestLoginHistory(t *testing.T) {
+	userId := util.CreateUserForFurtherTesting(t)
+	_, status := util.Login(userId, "wrongPassword")
+	assert.Equal(t, status, http.StatusUnauthorized)
+	token, status := util.Login(userId, util.DefaultPassword)
+	assert.Equal(t, status, http.StatusOK)
+
+	loginHistory, status := util.GetLoginHistory(userId, token.AccessToken)
+	assert.Equal(t, status, http.StatusOK)
+	assert.Equal(t, len(loginHistory), 2)
+	assert.Equal(t, loginHistory[0].Success, true)
+	assert.Equal(t, loginHistory[1].Success, false)
+}
+
+func TestLoginHistory_WrongUserIdPath(t *testing.T) {
+	token, _ := util.ObtainToken(t)
+	_, status := util.GetLoginHistory(uuid.NewString(), token.AccessToken)
+	assert.Equal(t, status, http.StatusUnauthorized)
+}
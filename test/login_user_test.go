@@ -27,3 +27,13 @@ func TestLogin_Retry(t *testing.T) {
 	_, status = util.Login(userId, util.DefaultPassword)
 	assert.Equal(t, status, http.StatusOK)
 }
+
+func TestLogin_Locked(t *testing.T) {
+	userId := util.CreateUserForFurtherTesting(t)
+	for i := 0; i < 5; i++ {
+		_, status := util.Login(userId, "wrongPassword")
+		assert.Equal(t, status, http.StatusUnauthorized)
+	}
+	_, status := util.Login(userId, util.DefaultPassword)
+	assert.Equal(t, status, http.StatusLocked)
+}
@@ -16,15 +16,15 @@ const (
 
 func TestUpdatePassword(t *testing.T) {
 	token, userId := util.ObtainToken(t)
-	status := util.UpdatePassword(userId, someNewPassword, token.AccessToken)
-	assert.Equal(t, status, http.StatusNoContent)
+	status := util.UpdatePassword(userId, util.DefaultPassword, someNewPassword, token.AccessToken)
+	assert.Equal(t, status, http.StatusOK)
 	_, status = util.Login(userId, someNewPassword)
 	assert.Equal(t, status, http.StatusOK)
 }
 
 func TestUpdatePasswordWrongFormatAccessToken(t *testing.T) {
 	userId := util.CreateUserForFurtherTesting(t)
-	status := util.UpdatePassword(userId, someNewPassword, "someWrongToken")
+	status := util.UpdatePassword(userId, util.DefaultPassword, someNewPassword, "someWrongToken")
 	assert.Equal(t, status, http.StatusUnauthorized)
 }
 
@@ -32,13 +32,13 @@ func TestUpdatePasswordWrongUserIdToken(t *testing.T) {
 	userId := util.CreateUserForFurtherTesting(t)
 	signKey := util.LoadPrivateKeyFile(util.PrivateKeyFile)
 	customToken := util.CreateCustomJWTToken(uuid.NewString(), time.Now().Add(30*time.Minute).Unix(), signKey)
-	status := util.UpdatePassword(userId, someNewPassword, customToken)
+	status := util.UpdatePassword(userId, util.DefaultPassword, someNewPassword, customToken)
 	assert.Equal(t, status, http.StatusUnauthorized)
 }
 
 func TestUpdatePasswordWrongUserIdPath(t *testing.T) {
 	token, _ := util.ObtainToken(t)
-	status := util.UpdatePassword(uuid.NewString(), someNewPassword, token.AccessToken)
+	status := util.UpdatePassword(uuid.NewString(), util.DefaultPassword, someNewPassword, token.AccessToken)
 	assert.Equal(t, status, http.StatusUnauthorized)
 }
 
@@ -46,6 +46,6 @@ func TestUpdatePasswordExpiredToken(t *testing.T) {
 	userId := util.CreateUserForFurtherTesting(t)
 	signKey := util.LoadPrivateKeyFile(util.PrivateKeyFile)
 	customToken := util.CreateCustomJWTToken(userId, time.Now().Add(-1*time.Second).Unix(), signKey)
-	status := util.UpdatePassword(userId, someNewPassword, customToken)
+	status := util.UpdatePassword(userId, util.DefaultPassword, someNewPassword, customToken)
 	assert.Equal(t, status, http.StatusUnauthorized)
 }
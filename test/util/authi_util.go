@@ -66,6 +66,23 @@ func sendRefreshTokenRequest(userId string, token string, refreshToken string) *
 	return resp
 }
 
+func sendLoginHistoryRequest(userId string, token string) *http.Response {
+	req, err := http.NewRequest(http.MethodGet, Url+adapter.AuthiRootPath+"/"+userId+adapter.AuthiLoginsPath, nil)
+	if err != nil {
+		panic(err)
+	}
+	req.Header.Set(adapter.AuthorizationHeaderName, "Bearer "+token)
+	req.Header.Set(CorrelationId, uuid.NewString())
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		panic(err)
+	}
+
+	return resp
+}
+
 func Login(userId string, password string) (*TokenResponseDTO, int) {
 	response := sendLoginRequest(userId, &Authenticate{Password: password})
 	defer response.Body.Close()
@@ -92,6 +109,19 @@ func RefreshToken(userId string, token string, refreshToken string) (*TokenRespo
 	return tokenResponse, response.StatusCode
 }
 
+func GetLoginHistory(userId string, token string) ([]*LoginHistoryDTO, int) {
+	response := sendLoginHistoryRequest(userId, token)
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, response.StatusCode
+	}
+	var loginHistory []*LoginHistoryDTO
+	if err := json.NewDecoder(response.Body).Decode(&loginHistory); err != nil {
+		panic(err)
+	}
+	return loginHistory, response.StatusCode
+}
+
 func ObtainToken(t *testing.T) (*TokenResponseDTO, string) {
 	userId := CreateUserForFurtherTesting(t)
 	token, status := Login(userId, DefaultPassword)
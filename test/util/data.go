@@ -1,5 +1,7 @@
 package util
 
+import "time"
+
 const (
 	Url           = "http://localhost:1203"
 	CorrelationId = "X-Correlation-ID"
@@ -10,10 +12,22 @@ type (
 		Password string `json:"password" validate:"required"`
 	}
 
+	UpdatePasswordRequest struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+
 	TokenResponseDTO struct {
 		AccessToken      string `json:"access_token"`
 		ExpiresIn        int    `json:"expires_in"`
 		RefreshToken     string `json:"refresh_token"`
 		RefreshExpiresIn int    `json:"refresh_expires_in"`
 	}
+
+	LoginHistoryDTO struct {
+		LoginTime time.Time `json:"login_time"`
+		IP        string    `json:"ip"`
+		UserAgent string    `json:"user_agent"`
+		Success   bool      `json:"success"`
+	}
 )
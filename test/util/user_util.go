@@ -55,8 +55,8 @@ func sendCreateUserRequest(id string, userCreateJson string) *http.Response {
 	return resp
 }
 
-func sendRefreshPasswordRequest(userId string, authenticate *Authenticate, token string) *http.Response {
-	userJson, err := json.Marshal(authenticate)
+func sendRefreshPasswordRequest(userId string, updatePassword *UpdatePasswordRequest, token string) *http.Response {
+	userJson, err := json.Marshal(updatePassword)
 	if err != nil {
 		panic(err)
 	}
@@ -130,8 +130,8 @@ func CreateUserForFurtherTesting(t *testing.T) string {
 	return userId
 }
 
-func UpdatePassword(userId string, password string, tokenString string) int {
-	response := sendRefreshPasswordRequest(userId, &Authenticate{Password: password}, tokenString)
+func UpdatePassword(userId string, currentPassword string, password string, tokenString string) int {
+	response := sendRefreshPasswordRequest(userId, &UpdatePasswordRequest{CurrentPassword: currentPassword, NewPassword: password}, tokenString)
 	defer response.Body.Close()
 	return response.StatusCode
 }